@@ -0,0 +1,169 @@
+package main
+
+import (
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// addressScope 区分地址类型，控制面板据此判断实例实际配置了哪个地址族；
+// OCI 的公网 IPv4 通过 NAT 映射，网卡上只有私网地址，IPv6 则直接配置全局地址
+func addressScope(ip net.IP) string {
+	switch {
+	case ip.IsLoopback():
+		return "loopback"
+	case ip.IsLinkLocalUnicast():
+		return "link_local"
+	case ip.IsPrivate():
+		return "private"
+	case ip.IsGlobalUnicast():
+		return "global"
+	}
+	return "other"
+}
+
+// readAddresses 列出各网卡的 MAC、MTU、链路状态和 IPv4/IPv6 地址，受 -net-include/-net-exclude 过滤
+func readAddresses() (map[string]interface{}, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]interface{}, len(ifaces))
+	for _, iface := range ifaces {
+		if !interfaceIncluded(iface.Name, cfg.NetInclude, cfg.NetExclude) {
+			continue
+		}
+		m := map[string]interface{}{
+			"index":   iface.Index,
+			"mtu":     iface.MTU,
+			"up":      iface.Flags&net.FlagUp != 0,
+			"running": iface.Flags&net.FlagRunning != 0,
+		}
+		if mac := iface.HardwareAddr.String(); mac != "" {
+			m["mac"] = mac
+		}
+		// Linux 的 operstate 能区分 down 和 lowerlayerdown（网线或虚拟网卡对端断开）
+		if state, ok := readSysValue(hostSys("class/net", iface.Name, "operstate")); ok {
+			m["oper_state"] = state
+		}
+		ipv4, ipv6 := []interface{}{}, []interface{}{}
+		addrs, _ := iface.Addrs()
+		sort.Slice(addrs, func(i, j int) bool { return addrs[i].String() < addrs[j].String() })
+		for _, a := range addrs {
+			ipnet, ok := a.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			ones, _ := ipnet.Mask.Size()
+			entry := map[string]interface{}{
+				"address":       ipnet.IP.String(),
+				"prefix_length": ones,
+				"scope":         addressScope(ipnet.IP),
+			}
+			if ipnet.IP.To4() != nil {
+				ipv4 = append(ipv4, entry)
+			} else {
+				ipv6 = append(ipv6, entry)
+			}
+		}
+		m["ipv4"], m["ipv6"] = ipv4, ipv6
+		out[iface.Name] = m
+	}
+	return out, nil
+}
+
+// addressFamilies 汇总是否有可用的 IPv4（私网或全局）和全局 IPv6 地址
+func addressFamilies(interfaces map[string]interface{}) (ipv4, ipv6Global bool) {
+	for _, iface := range interfaces {
+		m := iface.(map[string]interface{})
+		for _, a := range m["ipv4"].([]interface{}) {
+			if scope := a.(map[string]interface{})["scope"]; scope == "private" || scope == "global" {
+				ipv4 = true
+			}
+		}
+		for _, a := range m["ipv6"].([]interface{}) {
+			if a.(map[string]interface{})["scope"] == "global" {
+				ipv6Global = true
+			}
+		}
+	}
+	return ipv4, ipv6Global
+}
+
+// addressSummary 把所有地址压成一行，用于变化事件的 From/To
+func addressSummary(interfaces map[string]interface{}) string {
+	names := make([]string, 0, len(interfaces))
+	for name := range interfaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		m := interfaces[name].(map[string]interface{})
+		var addrs []string
+		for _, family := range []string{"ipv4", "ipv6"} {
+			for _, a := range m[family].([]interface{}) {
+				a := a.(map[string]interface{})
+				if a["scope"] != "link_local" {
+					addrs = append(addrs, a["address"].(string))
+				}
+			}
+		}
+		if len(addrs) > 0 {
+			parts = append(parts, name+" "+strings.Join(addrs, ","))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// addressInventory 记录上一次的地址清单，地址、MTU 或链路状态变化时更新 changed_at；
+// 地址本身变化时另外发送 addresses_changed 事件，-delta 下整个段只在变化时发送
+type addressInventory struct {
+	mu         sync.Mutex
+	interfaces map[string]interface{}
+	summary    string
+	changedAt  time.Time
+}
+
+var addresses = &addressInventory{}
+
+func getAddresses() map[string]interface{} {
+	interfaces, err := readAddresses()
+	if err != nil || len(interfaces) == 0 {
+		return nil
+	}
+	now := time.Now()
+	summary := addressSummary(interfaces)
+	a := addresses
+	a.mu.Lock()
+	previous, first := a.summary, a.interfaces == nil
+	if first || !reflect.DeepEqual(a.interfaces, interfaces) {
+		a.interfaces, a.summary, a.changedAt = interfaces, summary, now
+	}
+	changedAt := a.changedAt
+	a.mu.Unlock()
+	if !first && summary != previous {
+		dispatchAlert(alertEvent{
+			Rule:      "addresses_changed",
+			Severity:  severityInfo,
+			Status:    "changed",
+			Metric:    "addresses",
+			From:      previous,
+			To:        summary,
+			Hostname:  reportedHostname(),
+			AgentID:   agentID(),
+			StartedAt: now.Format(time.RFC3339),
+			At:        now.Format(time.RFC3339),
+		})
+	}
+	ipv4, ipv6Global := addressFamilies(interfaces)
+	return map[string]interface{}{
+		"interfaces":  interfaces,
+		"ipv4":        ipv4,
+		"ipv6_global": ipv6Global,
+		"changed_at":  changedAt.Format(time.RFC3339),
+	}
+}
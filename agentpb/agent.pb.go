@@ -0,0 +1,885 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: agent.proto
+
+package agentpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Report 与 JSON payload 对应，常用指标为强类型字段，其余采集项放在 extra 中，结构与 JSON 相同
+type Report struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InstanceId    string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // unix 秒
+	Labels        map[string]string      `protobuf:"bytes,3,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Host          *Host                  `protobuf:"bytes,4,opt,name=host,proto3" json:"host,omitempty"`
+	Cpu           *Cpu                   `protobuf:"bytes,5,opt,name=cpu,proto3" json:"cpu,omitempty"`
+	Memory        *Memory                `protobuf:"bytes,6,opt,name=memory,proto3" json:"memory,omitempty"`
+	Swap          *Memory                `protobuf:"bytes,7,opt,name=swap,proto3" json:"swap,omitempty"`
+	Disk          *Disk                  `protobuf:"bytes,8,opt,name=disk,proto3" json:"disk,omitempty"`
+	Network       *Network               `protobuf:"bytes,9,opt,name=network,proto3" json:"network,omitempty"`
+	Extra         *structpb.Struct       `protobuf:"bytes,15,opt,name=extra,proto3" json:"extra,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Report) Reset() {
+	*x = Report{}
+	mi := &file_agent_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Report) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Report) ProtoMessage() {}
+
+func (x *Report) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Report.ProtoReflect.Descriptor instead.
+func (*Report) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Report) GetInstanceId() string {
+	if x != nil {
+		return x.InstanceId
+	}
+	return ""
+}
+
+func (x *Report) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Report) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *Report) GetHost() *Host {
+	if x != nil {
+		return x.Host
+	}
+	return nil
+}
+
+func (x *Report) GetCpu() *Cpu {
+	if x != nil {
+		return x.Cpu
+	}
+	return nil
+}
+
+func (x *Report) GetMemory() *Memory {
+	if x != nil {
+		return x.Memory
+	}
+	return nil
+}
+
+func (x *Report) GetSwap() *Memory {
+	if x != nil {
+		return x.Swap
+	}
+	return nil
+}
+
+func (x *Report) GetDisk() *Disk {
+	if x != nil {
+		return x.Disk
+	}
+	return nil
+}
+
+func (x *Report) GetNetwork() *Network {
+	if x != nil {
+		return x.Network
+	}
+	return nil
+}
+
+func (x *Report) GetExtra() *structpb.Struct {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+type Host struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Platform        string                 `protobuf:"bytes,1,opt,name=platform,proto3" json:"platform,omitempty"`
+	Architecture    string                 `protobuf:"bytes,2,opt,name=architecture,proto3" json:"architecture,omitempty"`
+	PlatformVersion string                 `protobuf:"bytes,3,opt,name=platform_version,json=platformVersion,proto3" json:"platform_version,omitempty"`
+	Distribution    string                 `protobuf:"bytes,4,opt,name=distribution,proto3" json:"distribution,omitempty"`
+	Virtualization  string                 `protobuf:"bytes,5,opt,name=virtualization,proto3" json:"virtualization,omitempty"`
+	AgentVersion    string                 `protobuf:"bytes,6,opt,name=agent_version,json=agentVersion,proto3" json:"agent_version,omitempty"`
+	BootTime        string                 `protobuf:"bytes,7,opt,name=boot_time,json=bootTime,proto3" json:"boot_time,omitempty"`
+	Uptime          string                 `protobuf:"bytes,8,opt,name=uptime,proto3" json:"uptime,omitempty"`
+	ProcessCount    uint32                 `protobuf:"varint,9,opt,name=process_count,json=processCount,proto3" json:"process_count,omitempty"`
+	Load1           float64                `protobuf:"fixed64,10,opt,name=load1,proto3" json:"load1,omitempty"`
+	Load5           float64                `protobuf:"fixed64,11,opt,name=load5,proto3" json:"load5,omitempty"`
+	Load15          float64                `protobuf:"fixed64,12,opt,name=load15,proto3" json:"load15,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Host) Reset() {
+	*x = Host{}
+	mi := &file_agent_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Host) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Host) ProtoMessage() {}
+
+func (x *Host) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Host.ProtoReflect.Descriptor instead.
+func (*Host) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Host) GetPlatform() string {
+	if x != nil {
+		return x.Platform
+	}
+	return ""
+}
+
+func (x *Host) GetArchitecture() string {
+	if x != nil {
+		return x.Architecture
+	}
+	return ""
+}
+
+func (x *Host) GetPlatformVersion() string {
+	if x != nil {
+		return x.PlatformVersion
+	}
+	return ""
+}
+
+func (x *Host) GetDistribution() string {
+	if x != nil {
+		return x.Distribution
+	}
+	return ""
+}
+
+func (x *Host) GetVirtualization() string {
+	if x != nil {
+		return x.Virtualization
+	}
+	return ""
+}
+
+func (x *Host) GetAgentVersion() string {
+	if x != nil {
+		return x.AgentVersion
+	}
+	return ""
+}
+
+func (x *Host) GetBootTime() string {
+	if x != nil {
+		return x.BootTime
+	}
+	return ""
+}
+
+func (x *Host) GetUptime() string {
+	if x != nil {
+		return x.Uptime
+	}
+	return ""
+}
+
+func (x *Host) GetProcessCount() uint32 {
+	if x != nil {
+		return x.ProcessCount
+	}
+	return 0
+}
+
+func (x *Host) GetLoad1() float64 {
+	if x != nil {
+		return x.Load1
+	}
+	return 0
+}
+
+func (x *Host) GetLoad5() float64 {
+	if x != nil {
+		return x.Load5
+	}
+	return 0
+}
+
+func (x *Host) GetLoad15() float64 {
+	if x != nil {
+		return x.Load15
+	}
+	return 0
+}
+
+type Cpu struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Model         string                 `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
+	Count         uint32                 `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	EffectiveCpus float64                `protobuf:"fixed64,3,opt,name=effective_cpus,json=effectiveCpus,proto3" json:"effective_cpus,omitempty"`
+	Percent       float64                `protobuf:"fixed64,4,opt,name=percent,proto3" json:"percent,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Cpu) Reset() {
+	*x = Cpu{}
+	mi := &file_agent_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Cpu) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Cpu) ProtoMessage() {}
+
+func (x *Cpu) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Cpu.ProtoReflect.Descriptor instead.
+func (*Cpu) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Cpu) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *Cpu) GetCount() uint32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *Cpu) GetEffectiveCpus() float64 {
+	if x != nil {
+		return x.EffectiveCpus
+	}
+	return 0
+}
+
+func (x *Cpu) GetPercent() float64 {
+	if x != nil {
+		return x.Percent
+	}
+	return 0
+}
+
+type Memory struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	TotalBytes     uint64                 `protobuf:"varint,1,opt,name=total_bytes,json=totalBytes,proto3" json:"total_bytes,omitempty"`
+	UsedBytes      uint64                 `protobuf:"varint,2,opt,name=used_bytes,json=usedBytes,proto3" json:"used_bytes,omitempty"`
+	Percent        float64                `protobuf:"fixed64,3,opt,name=percent,proto3" json:"percent,omitempty"`
+	AvailableBytes uint64                 `protobuf:"varint,4,opt,name=available_bytes,json=availableBytes,proto3" json:"available_bytes,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Memory) Reset() {
+	*x = Memory{}
+	mi := &file_agent_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Memory) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Memory) ProtoMessage() {}
+
+func (x *Memory) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Memory.ProtoReflect.Descriptor instead.
+func (*Memory) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Memory) GetTotalBytes() uint64 {
+	if x != nil {
+		return x.TotalBytes
+	}
+	return 0
+}
+
+func (x *Memory) GetUsedBytes() uint64 {
+	if x != nil {
+		return x.UsedBytes
+	}
+	return 0
+}
+
+func (x *Memory) GetPercent() float64 {
+	if x != nil {
+		return x.Percent
+	}
+	return 0
+}
+
+func (x *Memory) GetAvailableBytes() uint64 {
+	if x != nil {
+		return x.AvailableBytes
+	}
+	return 0
+}
+
+type Disk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TotalBytes    uint64                 `protobuf:"varint,1,opt,name=total_bytes,json=totalBytes,proto3" json:"total_bytes,omitempty"`
+	UsedBytes     uint64                 `protobuf:"varint,2,opt,name=used_bytes,json=usedBytes,proto3" json:"used_bytes,omitempty"`
+	Percent       float64                `protobuf:"fixed64,3,opt,name=percent,proto3" json:"percent,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Disk) Reset() {
+	*x = Disk{}
+	mi := &file_agent_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Disk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Disk) ProtoMessage() {}
+
+func (x *Disk) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Disk.ProtoReflect.Descriptor instead.
+func (*Disk) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Disk) GetTotalBytes() uint64 {
+	if x != nil {
+		return x.TotalBytes
+	}
+	return 0
+}
+
+func (x *Disk) GetUsedBytes() uint64 {
+	if x != nil {
+		return x.UsedBytes
+	}
+	return 0
+}
+
+func (x *Disk) GetPercent() float64 {
+	if x != nil {
+		return x.Percent
+	}
+	return 0
+}
+
+type Network struct {
+	state                    protoimpl.MessageState `protogen:"open.v1"`
+	UploadSpeedBytesPerSec   uint64                 `protobuf:"varint,1,opt,name=upload_speed_bytes_per_sec,json=uploadSpeedBytesPerSec,proto3" json:"upload_speed_bytes_per_sec,omitempty"`
+	DownloadSpeedBytesPerSec uint64                 `protobuf:"varint,2,opt,name=download_speed_bytes_per_sec,json=downloadSpeedBytesPerSec,proto3" json:"download_speed_bytes_per_sec,omitempty"`
+	UploadTotalBytes         uint64                 `protobuf:"varint,3,opt,name=upload_total_bytes,json=uploadTotalBytes,proto3" json:"upload_total_bytes,omitempty"`
+	DownloadTotalBytes       uint64                 `protobuf:"varint,4,opt,name=download_total_bytes,json=downloadTotalBytes,proto3" json:"download_total_bytes,omitempty"`
+	PublicIpv4               string                 `protobuf:"bytes,5,opt,name=public_ipv4,json=publicIpv4,proto3" json:"public_ipv4,omitempty"`
+	PublicIpv6               string                 `protobuf:"bytes,6,opt,name=public_ipv6,json=publicIpv6,proto3" json:"public_ipv6,omitempty"`
+	unknownFields            protoimpl.UnknownFields
+	sizeCache                protoimpl.SizeCache
+}
+
+func (x *Network) Reset() {
+	*x = Network{}
+	mi := &file_agent_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Network) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Network) ProtoMessage() {}
+
+func (x *Network) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Network.ProtoReflect.Descriptor instead.
+func (*Network) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Network) GetUploadSpeedBytesPerSec() uint64 {
+	if x != nil {
+		return x.UploadSpeedBytesPerSec
+	}
+	return 0
+}
+
+func (x *Network) GetDownloadSpeedBytesPerSec() uint64 {
+	if x != nil {
+		return x.DownloadSpeedBytesPerSec
+	}
+	return 0
+}
+
+func (x *Network) GetUploadTotalBytes() uint64 {
+	if x != nil {
+		return x.UploadTotalBytes
+	}
+	return 0
+}
+
+func (x *Network) GetDownloadTotalBytes() uint64 {
+	if x != nil {
+		return x.DownloadTotalBytes
+	}
+	return 0
+}
+
+func (x *Network) GetPublicIpv4() string {
+	if x != nil {
+		return x.PublicIpv4
+	}
+	return ""
+}
+
+func (x *Network) GetPublicIpv6() string {
+	if x != nil {
+		return x.PublicIpv6
+	}
+	return ""
+}
+
+type ReportAck struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp     int64                  `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // 被确认的 Report.timestamp
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportAck) Reset() {
+	*x = ReportAck{}
+	mi := &file_agent_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportAck) ProtoMessage() {}
+
+func (x *ReportAck) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportAck.ProtoReflect.Descriptor instead.
+func (*ReportAck) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ReportAck) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type HeartbeatRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	InstanceId         string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	Status             string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Timestamp          int64                  `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	AgentVersion       string                 `protobuf:"bytes,4,opt,name=agent_version,json=agentVersion,proto3" json:"agent_version,omitempty"`
+	Labels             map[string]string      `protobuf:"bytes,5,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	AgentUptimeSeconds int64                  `protobuf:"varint,6,opt,name=agent_uptime_seconds,json=agentUptimeSeconds,proto3" json:"agent_uptime_seconds,omitempty"`
+	HostUptimeSeconds  uint64                 `protobuf:"varint,7,opt,name=host_uptime_seconds,json=hostUptimeSeconds,proto3" json:"host_uptime_seconds,omitempty"`
+	Hostname           string                 `protobuf:"bytes,8,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *HeartbeatRequest) Reset() {
+	*x = HeartbeatRequest{}
+	mi := &file_agent_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatRequest) ProtoMessage() {}
+
+func (x *HeartbeatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatRequest.ProtoReflect.Descriptor instead.
+func (*HeartbeatRequest) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *HeartbeatRequest) GetInstanceId() string {
+	if x != nil {
+		return x.InstanceId
+	}
+	return ""
+}
+
+func (x *HeartbeatRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *HeartbeatRequest) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *HeartbeatRequest) GetAgentVersion() string {
+	if x != nil {
+		return x.AgentVersion
+	}
+	return ""
+}
+
+func (x *HeartbeatRequest) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *HeartbeatRequest) GetAgentUptimeSeconds() int64 {
+	if x != nil {
+		return x.AgentUptimeSeconds
+	}
+	return 0
+}
+
+func (x *HeartbeatRequest) GetHostUptimeSeconds() uint64 {
+	if x != nil {
+		return x.HostUptimeSeconds
+	}
+	return 0
+}
+
+func (x *HeartbeatRequest) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+type HeartbeatAck struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeartbeatAck) Reset() {
+	*x = HeartbeatAck{}
+	mi := &file_agent_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatAck) ProtoMessage() {}
+
+func (x *HeartbeatAck) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatAck.ProtoReflect.Descriptor instead.
+func (*HeartbeatAck) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{8}
+}
+
+var File_agent_proto protoreflect.FileDescriptor
+
+const file_agent_proto_rawDesc = "" +
+	"\n" +
+	"\vagent.proto\x12\vociagent.v1\x1a\x1cgoogle/protobuf/struct.proto\"\xe2\x03\n" +
+	"\x06Report\x12\x1f\n" +
+	"\vinstance_id\x18\x01 \x01(\tR\n" +
+	"instanceId\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x127\n" +
+	"\x06labels\x18\x03 \x03(\v2\x1f.ociagent.v1.Report.LabelsEntryR\x06labels\x12%\n" +
+	"\x04host\x18\x04 \x01(\v2\x11.ociagent.v1.HostR\x04host\x12\"\n" +
+	"\x03cpu\x18\x05 \x01(\v2\x10.ociagent.v1.CpuR\x03cpu\x12+\n" +
+	"\x06memory\x18\x06 \x01(\v2\x13.ociagent.v1.MemoryR\x06memory\x12'\n" +
+	"\x04swap\x18\a \x01(\v2\x13.ociagent.v1.MemoryR\x04swap\x12%\n" +
+	"\x04disk\x18\b \x01(\v2\x11.ociagent.v1.DiskR\x04disk\x12.\n" +
+	"\anetwork\x18\t \x01(\v2\x14.ociagent.v1.NetworkR\anetwork\x12-\n" +
+	"\x05extra\x18\x0f \x01(\v2\x17.google.protobuf.StructR\x05extra\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x80\x03\n" +
+	"\x04Host\x12\x1a\n" +
+	"\bplatform\x18\x01 \x01(\tR\bplatform\x12\"\n" +
+	"\farchitecture\x18\x02 \x01(\tR\farchitecture\x12)\n" +
+	"\x10platform_version\x18\x03 \x01(\tR\x0fplatformVersion\x12\"\n" +
+	"\fdistribution\x18\x04 \x01(\tR\fdistribution\x12&\n" +
+	"\x0evirtualization\x18\x05 \x01(\tR\x0evirtualization\x12#\n" +
+	"\ragent_version\x18\x06 \x01(\tR\fagentVersion\x12\x1b\n" +
+	"\tboot_time\x18\a \x01(\tR\bbootTime\x12\x16\n" +
+	"\x06uptime\x18\b \x01(\tR\x06uptime\x12#\n" +
+	"\rprocess_count\x18\t \x01(\rR\fprocessCount\x12\x14\n" +
+	"\x05load1\x18\n" +
+	" \x01(\x01R\x05load1\x12\x14\n" +
+	"\x05load5\x18\v \x01(\x01R\x05load5\x12\x16\n" +
+	"\x06load15\x18\f \x01(\x01R\x06load15\"r\n" +
+	"\x03Cpu\x12\x14\n" +
+	"\x05model\x18\x01 \x01(\tR\x05model\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\rR\x05count\x12%\n" +
+	"\x0eeffective_cpus\x18\x03 \x01(\x01R\reffectiveCpus\x12\x18\n" +
+	"\apercent\x18\x04 \x01(\x01R\apercent\"\x8b\x01\n" +
+	"\x06Memory\x12\x1f\n" +
+	"\vtotal_bytes\x18\x01 \x01(\x04R\n" +
+	"totalBytes\x12\x1d\n" +
+	"\n" +
+	"used_bytes\x18\x02 \x01(\x04R\tusedBytes\x12\x18\n" +
+	"\apercent\x18\x03 \x01(\x01R\apercent\x12'\n" +
+	"\x0favailable_bytes\x18\x04 \x01(\x04R\x0eavailableBytes\"`\n" +
+	"\x04Disk\x12\x1f\n" +
+	"\vtotal_bytes\x18\x01 \x01(\x04R\n" +
+	"totalBytes\x12\x1d\n" +
+	"\n" +
+	"used_bytes\x18\x02 \x01(\x04R\tusedBytes\x12\x18\n" +
+	"\apercent\x18\x03 \x01(\x01R\apercent\"\xa7\x02\n" +
+	"\aNetwork\x12:\n" +
+	"\x1aupload_speed_bytes_per_sec\x18\x01 \x01(\x04R\x16uploadSpeedBytesPerSec\x12>\n" +
+	"\x1cdownload_speed_bytes_per_sec\x18\x02 \x01(\x04R\x18downloadSpeedBytesPerSec\x12,\n" +
+	"\x12upload_total_bytes\x18\x03 \x01(\x04R\x10uploadTotalBytes\x120\n" +
+	"\x14download_total_bytes\x18\x04 \x01(\x04R\x12downloadTotalBytes\x12\x1f\n" +
+	"\vpublic_ipv4\x18\x05 \x01(\tR\n" +
+	"publicIpv4\x12\x1f\n" +
+	"\vpublic_ipv6\x18\x06 \x01(\tR\n" +
+	"publicIpv6\")\n" +
+	"\tReportAck\x12\x1c\n" +
+	"\ttimestamp\x18\x01 \x01(\x03R\ttimestamp\"\x8a\x03\n" +
+	"\x10HeartbeatRequest\x12\x1f\n" +
+	"\vinstance_id\x18\x01 \x01(\tR\n" +
+	"instanceId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x1c\n" +
+	"\ttimestamp\x18\x03 \x01(\x03R\ttimestamp\x12#\n" +
+	"\ragent_version\x18\x04 \x01(\tR\fagentVersion\x12A\n" +
+	"\x06labels\x18\x05 \x03(\v2).ociagent.v1.HeartbeatRequest.LabelsEntryR\x06labels\x120\n" +
+	"\x14agent_uptime_seconds\x18\x06 \x01(\x03R\x12agentUptimeSeconds\x12.\n" +
+	"\x13host_uptime_seconds\x18\a \x01(\x04R\x11hostUptimeSeconds\x12\x1a\n" +
+	"\bhostname\x18\b \x01(\tR\bhostname\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x0e\n" +
+	"\fHeartbeatAck2\x97\x01\n" +
+	"\fAgentService\x12@\n" +
+	"\rStreamReports\x12\x13.ociagent.v1.Report\x1a\x16.ociagent.v1.ReportAck(\x010\x01\x12E\n" +
+	"\tHeartbeat\x12\x1d.ociagent.v1.HeartbeatRequest\x1a\x19.ociagent.v1.HeartbeatAckB\x13Z\x11oci-agent/agentpbb\x06proto3"
+
+var (
+	file_agent_proto_rawDescOnce sync.Once
+	file_agent_proto_rawDescData []byte
+)
+
+func file_agent_proto_rawDescGZIP() []byte {
+	file_agent_proto_rawDescOnce.Do(func() {
+		file_agent_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_agent_proto_rawDesc), len(file_agent_proto_rawDesc)))
+	})
+	return file_agent_proto_rawDescData
+}
+
+var file_agent_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_agent_proto_goTypes = []any{
+	(*Report)(nil),           // 0: ociagent.v1.Report
+	(*Host)(nil),             // 1: ociagent.v1.Host
+	(*Cpu)(nil),              // 2: ociagent.v1.Cpu
+	(*Memory)(nil),           // 3: ociagent.v1.Memory
+	(*Disk)(nil),             // 4: ociagent.v1.Disk
+	(*Network)(nil),          // 5: ociagent.v1.Network
+	(*ReportAck)(nil),        // 6: ociagent.v1.ReportAck
+	(*HeartbeatRequest)(nil), // 7: ociagent.v1.HeartbeatRequest
+	(*HeartbeatAck)(nil),     // 8: ociagent.v1.HeartbeatAck
+	nil,                      // 9: ociagent.v1.Report.LabelsEntry
+	nil,                      // 10: ociagent.v1.HeartbeatRequest.LabelsEntry
+	(*structpb.Struct)(nil),  // 11: google.protobuf.Struct
+}
+var file_agent_proto_depIdxs = []int32{
+	9,  // 0: ociagent.v1.Report.labels:type_name -> ociagent.v1.Report.LabelsEntry
+	1,  // 1: ociagent.v1.Report.host:type_name -> ociagent.v1.Host
+	2,  // 2: ociagent.v1.Report.cpu:type_name -> ociagent.v1.Cpu
+	3,  // 3: ociagent.v1.Report.memory:type_name -> ociagent.v1.Memory
+	3,  // 4: ociagent.v1.Report.swap:type_name -> ociagent.v1.Memory
+	4,  // 5: ociagent.v1.Report.disk:type_name -> ociagent.v1.Disk
+	5,  // 6: ociagent.v1.Report.network:type_name -> ociagent.v1.Network
+	11, // 7: ociagent.v1.Report.extra:type_name -> google.protobuf.Struct
+	10, // 8: ociagent.v1.HeartbeatRequest.labels:type_name -> ociagent.v1.HeartbeatRequest.LabelsEntry
+	0,  // 9: ociagent.v1.AgentService.StreamReports:input_type -> ociagent.v1.Report
+	7,  // 10: ociagent.v1.AgentService.Heartbeat:input_type -> ociagent.v1.HeartbeatRequest
+	6,  // 11: ociagent.v1.AgentService.StreamReports:output_type -> ociagent.v1.ReportAck
+	8,  // 12: ociagent.v1.AgentService.Heartbeat:output_type -> ociagent.v1.HeartbeatAck
+	11, // [11:13] is the sub-list for method output_type
+	9,  // [9:11] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_agent_proto_init() }
+func file_agent_proto_init() {
+	if File_agent_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_agent_proto_rawDesc), len(file_agent_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_agent_proto_goTypes,
+		DependencyIndexes: file_agent_proto_depIdxs,
+		MessageInfos:      file_agent_proto_msgTypes,
+	}.Build()
+	File_agent_proto = out.File
+	file_agent_proto_goTypes = nil
+	file_agent_proto_depIdxs = nil
+}
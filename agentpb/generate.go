@@ -0,0 +1,4 @@
+// Package agentpb 是 gRPC 上报协议的 protobuf 定义及生成代码
+package agentpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative agent.proto
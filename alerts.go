@@ -0,0 +1,331 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 告警级别
+const (
+	severityInfo     = "info"
+	severityWarning  = "warning"
+	severityCritical = "critical"
+)
+
+// alertRule 对 payload 中的一个字段做阈值判断，字段路径与 -convert 相同，支持 * 通配，
+// 每个匹配到的具体字段（如每个挂载点）单独触发和恢复
+type alertRule struct {
+	name      string
+	spec      string
+	path      []string
+	op        string
+	threshold float64
+	perCore   bool // 阈值乘以 CPU 数，用于 load average
+	duration  time.Duration
+	severity  string
+}
+
+// 形如 cpu.percent > 90、load_average.1min>1.5xcores，运算符两侧空格可省略
+var alertExprPattern = regexp.MustCompile(`^([A-Za-z0-9_.*\-]+)(>=|<=|==|!=|>|<)(\S+)$`)
+
+// parseAlertRule 解析 NAME: FIELD OP VALUE [for DURATION] [severity LEVEL]，
+// VALUE 可以是数字、true/false，或 Nxcores 表示 N 倍 CPU 数
+func parseAlertRule(spec string) (alertRule, error) {
+	rule := alertRule{spec: spec, severity: severityWarning}
+	colon := strings.Index(spec, ":")
+	if colon <= 0 {
+		return rule, fmt.Errorf("rule %q: expected NAME: FIELD OP VALUE", spec)
+	}
+	rule.name = strings.TrimSpace(spec[:colon])
+
+	// 条件之后是成对的 for/severity 选项，条件本身可能带空格
+	tokens := strings.Fields(spec[colon+1:])
+	end := len(tokens)
+	for i, token := range tokens {
+		if token == "for" || token == "severity" {
+			end = i
+			break
+		}
+	}
+	condition := strings.Join(tokens[:end], "")
+	m := alertExprPattern.FindStringSubmatch(condition)
+	if m == nil {
+		return rule, fmt.Errorf("rule %s: invalid condition %q", rule.name, strings.Join(tokens[:end], " "))
+	}
+	rule.path, rule.op = strings.Split(m[1], "."), m[2]
+	value := m[3]
+	switch {
+	case value == "true":
+		rule.threshold = 1
+	case value == "false":
+		rule.threshold = 0
+	case strings.HasSuffix(value, "xcores"):
+		rule.perCore = true
+		value = strings.TrimSuffix(value, "xcores")
+		fallthrough
+	default:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return rule, fmt.Errorf("rule %s: invalid threshold %q", rule.name, m[3])
+		}
+		rule.threshold = n
+	}
+
+	options := tokens[end:]
+	if len(options)%2 != 0 {
+		return rule, fmt.Errorf("rule %s: option %q needs a value", rule.name, options[len(options)-1])
+	}
+	for i := 0; i < len(options); i += 2 {
+		switch key, value := options[i], options[i+1]; key {
+		case "for":
+			d, err := time.ParseDuration(value)
+			if err != nil || d < 0 {
+				return rule, fmt.Errorf("rule %s: invalid duration %q", rule.name, value)
+			}
+			rule.duration = d
+		case "severity":
+			switch value {
+			case severityInfo, severityWarning, severityCritical:
+				rule.severity = value
+			default:
+				return rule, fmt.Errorf("rule %s: unknown severity %q, expected info, warning or critical", rule.name, value)
+			}
+		default:
+			return rule, fmt.Errorf("rule %s: unknown option %q, expected for or severity", rule.name, key)
+		}
+	}
+	return rule, nil
+}
+
+func compileAlertRules(specs []string) ([]alertRule, error) {
+	rules := make([]alertRule, 0, len(specs))
+	names := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		rule, err := parseAlertRule(spec)
+		if err != nil {
+			return nil, err
+		}
+		if names[rule.name] {
+			return nil, fmt.Errorf("duplicate rule name %q", rule.name)
+		}
+		names[rule.name] = true
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (r alertRule) limit() float64 {
+	if r.perCore {
+		return r.threshold * float64(runtime.NumCPU())
+	}
+	return r.threshold
+}
+
+func (r alertRule) matches(value float64) bool {
+	limit := r.limit()
+	switch r.op {
+	case ">":
+		return value > limit
+	case ">=":
+		return value >= limit
+	case "<":
+		return value < limit
+	case "<=":
+		return value <= limit
+	case "==":
+		return value == limit
+	case "!=":
+		return value != limit
+	}
+	return false
+}
+
+// alertValues 取出 path 匹配到的所有数值字段，key 为具体的字段路径；布尔值按 1/0 处理
+func alertValues(v interface{}, path []string, prefix string, out map[string]float64) {
+	if len(path) == 0 {
+		if b, ok := v.(bool); ok {
+			out[prefix] = 0
+			if b {
+				out[prefix] = 1
+			}
+		} else if n, ok := toFloat(v); ok {
+			out[prefix] = n
+		}
+		return
+	}
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch m := v.(type) {
+	case map[string]interface{}:
+		for key, child := range m {
+			if path[0] == "*" || path[0] == key {
+				alertValues(child, path[1:], join(key), out)
+			}
+		}
+	case map[string]float64:
+		for key, child := range m {
+			if path[0] == "*" || path[0] == key {
+				alertValues(child, path[1:], join(key), out)
+			}
+		}
+	}
+}
+
+// alertEvent 是一次触发或恢复，交给 dispatchAlert 记录日志和发送通知
+type alertEvent struct {
+	Rule      string  `json:"rule"`
+	Severity  string  `json:"severity"`
+	Status    string  `json:"status"` // firing、resolved，或非阈值事件的 changed
+	Metric    string  `json:"metric"`
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+	Condition string  `json:"condition,omitempty"`
+	From      string  `json:"from,omitempty"` // changed 事件的旧值和新值，如公网 IP
+	To        string  `json:"to,omitempty"`
+	Hostname  string  `json:"hostname"`
+	AgentID   string  `json:"agent_id"`
+	StartedAt string  `json:"started_at"`
+	At        string  `json:"at"`
+}
+
+type alertState struct {
+	since  time.Time // 条件首次满足的时间
+	firing bool
+	value  float64
+}
+
+// alertEngine 每个周期用最新的 payload 评估全部规则，条件持续满足 for 指定的时长后触发，
+// 条件不再满足时恢复；字段本周期缺失（如采集超时）时保持原状态
+type alertEngine struct {
+	mu     sync.Mutex
+	rules  []alertRule
+	states map[string]*alertState // rule.name + "|" + 具体字段路径
+}
+
+var alerts = &alertEngine{states: make(map[string]*alertState)}
+
+func (e *alertEngine) setRules(rules []alertRule) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rules = rules
+	e.states = make(map[string]*alertState)
+}
+
+func (e *alertEngine) enabled() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.rules) > 0
+}
+
+// evaluate 返回本次产生的触发和恢复事件
+func (e *alertEngine) evaluate(info map[string]interface{}, now time.Time) []alertEvent {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var events []alertEvent
+	for _, rule := range e.rules {
+		values := make(map[string]float64)
+		alertValues(info, rule.path, "", values)
+		for metric, value := range values {
+			key := rule.name + "|" + metric
+			state := e.states[key]
+			if !rule.matches(value) {
+				if state != nil && state.firing {
+					events = append(events, rule.event("resolved", metric, value, state.since, now))
+				}
+				delete(e.states, key)
+				continue
+			}
+			if state == nil {
+				state = &alertState{since: now}
+				e.states[key] = state
+			}
+			state.value = value
+			if !state.firing && now.Sub(state.since) >= rule.duration {
+				state.firing = true
+				events = append(events, rule.event("firing", metric, value, state.since, now))
+			}
+		}
+	}
+	return events
+}
+
+// active 返回当前处于触发状态的告警，放进 payload 的 alerts 字段
+func (e *alertEngine) active() []interface{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	list := make([]interface{}, 0)
+	for _, rule := range e.rules {
+		var metrics []string
+		for key, state := range e.states {
+			if state.firing && strings.HasPrefix(key, rule.name+"|") {
+				metrics = append(metrics, strings.TrimPrefix(key, rule.name+"|"))
+			}
+		}
+		sort.Strings(metrics)
+		for _, metric := range metrics {
+			state := e.states[rule.name+"|"+metric]
+			list = append(list, map[string]interface{}{
+				"rule":       rule.name,
+				"severity":   rule.severity,
+				"metric":     metric,
+				"value":      math.Round(state.value*100) / 100,
+				"threshold":  rule.limit(),
+				"started_at": state.since.Format(time.RFC3339),
+			})
+		}
+	}
+	return list
+}
+
+func (r alertRule) event(status, metric string, value float64, since, now time.Time) alertEvent {
+	hostname := reportedHostname()
+	return alertEvent{
+		Rule:      r.name,
+		Severity:  r.severity,
+		Status:    status,
+		Metric:    metric,
+		Value:     math.Round(value*100) / 100,
+		Threshold: r.limit(),
+		Condition: strings.TrimSpace(r.spec[strings.Index(r.spec, ":")+1:]),
+		Hostname:  hostname,
+		AgentID:   agentID(),
+		StartedAt: since.Format(time.RFC3339),
+		At:        now.Format(time.RFC3339),
+	}
+}
+
+func dispatchAlert(ev alertEvent) {
+	attrs := []interface{}{"component", "alerts", "rule", ev.Rule, "severity", ev.Severity, "metric", ev.Metric, "value", ev.Value, "threshold", ev.Threshold}
+	switch ev.Status {
+	case "firing":
+		slog.Warn("alert firing", attrs...)
+	case "resolved":
+		slog.Info("alert resolved", attrs...)
+	default:
+		slog.Info("event", "component", "alerts", "rule", ev.Rule, "metric", ev.Metric, "from", ev.From, "to", ev.To)
+	}
+	sendNotifications(ev)
+}
+
+// evaluateAlerts 评估规则、分发事件，并把当前触发中的告警写入 info
+func evaluateAlerts(info map[string]interface{}) {
+	if !alerts.enabled() {
+		return
+	}
+	for _, ev := range alerts.evaluate(info, time.Now()) {
+		dispatchAlert(ev)
+	}
+	info["alerts"] = alerts.active()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// 跳过 loop、ram 等虚拟块设备
+var virtualBlockPrefixes = []string{"loop", "ram"}
+
+func readSysValue(path string) (string, bool) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(string(content)), true
+}
+
+// parseIOScheduler 解析 "none [mq-deadline] kyber bfq"，方括号内为当前调度器
+func parseIOScheduler(s string) (active string, available []string) {
+	for _, field := range strings.Fields(s) {
+		if strings.HasPrefix(field, "[") && strings.HasSuffix(field, "]") {
+			field = strings.Trim(field, "[]")
+			active = field
+		}
+		available = append(available, field)
+	}
+	return active, available
+}
+
+func getBlockDevices() map[string]interface{} {
+	dirs, err := filepath.Glob(hostSys("block", "*"))
+	if err != nil || len(dirs) == 0 {
+		return nil
+	}
+	devices := make(map[string]interface{})
+	for _, dir := range dirs {
+		name := filepath.Base(dir)
+		virtual := false
+		for _, prefix := range virtualBlockPrefixes {
+			if strings.HasPrefix(name, prefix) {
+				virtual = true
+				break
+			}
+		}
+		if virtual {
+			continue
+		}
+
+		device := make(map[string]interface{})
+		if s, ok := readSysValue(filepath.Join(dir, "queue", "scheduler")); ok {
+			active, available := parseIOScheduler(s)
+			device["scheduler"] = active
+			device["available_schedulers"] = available
+		}
+		if s, ok := readSysValue(filepath.Join(dir, "queue", "nr_requests")); ok {
+			if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+				device["nr_requests"] = n
+			}
+		}
+		if s, ok := readSysValue(filepath.Join(dir, "queue", "read_ahead_kb")); ok {
+			if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+				device["read_ahead_kb"] = n
+			}
+		}
+		if len(device) > 0 {
+			devices[name] = device
+		}
+	}
+	if len(devices) == 0 {
+		return nil
+	}
+	return devices
+}
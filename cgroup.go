@@ -0,0 +1,171 @@
+package main
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+func isCgroupV2() bool {
+	_, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers"))
+	return err == nil
+}
+
+// cgroupPaths 解析 /proc/self/cgroup，返回 controller -> 所在 cgroup 路径，v2 的 key 为空字符串
+func cgroupPaths() map[string]string {
+	content, err := ioutil.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return nil
+	}
+	paths := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[1] == "" {
+			paths[""] = parts[2]
+			continue
+		}
+		for _, controller := range strings.Split(parts[1], ",") {
+			paths[controller] = parts[2]
+		}
+	}
+	return paths
+}
+
+// readCgroupFile 读取当前进程所在 cgroup 的控制文件。容器内 /proc/self/cgroup 的路径
+// 通常在挂载点下不存在（cgroup namespace），此时退回到挂载点根目录
+func readCgroupFile(controller, name string) (string, bool) {
+	var dirs []string
+	if isCgroupV2() {
+		if p, ok := cgroupPaths()[""]; ok {
+			dirs = append(dirs, filepath.Join(cgroupRoot, p))
+		}
+		dirs = append(dirs, cgroupRoot)
+	} else {
+		matches, _ := filepath.Glob(filepath.Join(cgroupRoot, "*"))
+		for _, m := range matches {
+			for _, c := range strings.Split(filepath.Base(m), ",") {
+				if c != controller {
+					continue
+				}
+				if p, ok := cgroupPaths()[controller]; ok {
+					dirs = append(dirs, filepath.Join(m, p))
+				}
+				dirs = append(dirs, m)
+			}
+		}
+	}
+	for _, dir := range dirs {
+		if content, err := ioutil.ReadFile(filepath.Join(dir, name)); err == nil {
+			return strings.TrimSpace(string(content)), true
+		}
+	}
+	return "", false
+}
+
+// cgroupCPULimit 返回 CPU 配额折算的核数，没有限制时返回 0
+func cgroupCPULimit() float64 {
+	var quota, period float64
+	if isCgroupV2() {
+		// cpu.max 形如 "max 100000" 或 "200000 100000"
+		s, ok := readCgroupFile("cpu", "cpu.max")
+		if !ok {
+			return 0
+		}
+		fields := strings.Fields(s)
+		if len(fields) != 2 || fields[0] == "max" {
+			return 0
+		}
+		quota, _ = strconv.ParseFloat(fields[0], 64)
+		period, _ = strconv.ParseFloat(fields[1], 64)
+	} else {
+		q, ok1 := readCgroupFile("cpu", "cpu.cfs_quota_us")
+		p, ok2 := readCgroupFile("cpu", "cpu.cfs_period_us")
+		if !ok1 || !ok2 {
+			return 0
+		}
+		quota, _ = strconv.ParseFloat(q, 64)
+		period, _ = strconv.ParseFloat(p, 64)
+	}
+	if quota <= 0 || period <= 0 {
+		return 0
+	}
+	return quota / period
+}
+
+// getEffectiveCPUs 返回考虑了 cgroup 配额后实际可用的 CPU 数
+func getEffectiveCPUs() float64 {
+	cpus := float64(runtime.NumCPU())
+	if limit := cgroupCPULimit(); limit > 0 && limit < cpus {
+		cpus = limit
+	}
+	return math.Round(cpus*100) / 100
+}
+
+// cgroupMemoryStat 读取 memory.stat 中的一项，v1 优先使用包含子 cgroup 的 total_ 前缀
+func cgroupMemoryStat(key string) (uint64, bool) {
+	s, ok := readCgroupFile("memory", "memory.stat")
+	if !ok {
+		return 0, false
+	}
+	values := make(map[string]uint64)
+	for _, line := range strings.Split(s, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			if v, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+				values[fields[0]] = v
+			}
+		}
+	}
+	if v, ok := values["total_"+key]; ok {
+		return v, true
+	}
+	v, ok := values[key]
+	return v, ok
+}
+
+// cgroupMemory 在 cgroup 设置了低于宿主机总内存的上限时返回限额与用量，否则返回 nil。
+// working_set 与 kubectl top、docker stats 一致，是用量减去可回收的 inactive_file
+func cgroupMemory(hostTotal uint64) map[string]interface{} {
+	limitFile, usageFile := "memory.limit_in_bytes", "memory.usage_in_bytes"
+	if isCgroupV2() {
+		limitFile, usageFile = "memory.max", "memory.current"
+	}
+	limitStr, ok := readCgroupFile("memory", limitFile)
+	if !ok || limitStr == "max" {
+		return nil
+	}
+	limit, err := strconv.ParseUint(limitStr, 10, 64)
+	// v1 没有限制时是一个接近 2^63 的数
+	if err != nil || limit == 0 || (hostTotal > 0 && limit >= hostTotal) {
+		return nil
+	}
+	usageStr, ok := readCgroupFile("memory", usageFile)
+	if !ok {
+		return nil
+	}
+	usage, err := strconv.ParseUint(usageStr, 10, 64)
+	if err != nil {
+		return nil
+	}
+	workingSet := usage
+	if inactive, ok := cgroupMemoryStat("inactive_file"); ok && inactive < usage {
+		workingSet = usage - inactive
+	}
+	return map[string]interface{}{
+		"limit":             formatBytes(limit),
+		"limit_bytes":       limit,
+		"usage_bytes":       usage,
+		"working_set":       formatBytes(workingSet),
+		"working_set_bytes": workingSet,
+		"percent":           percentOf(workingSet, limit),
+	}
+}
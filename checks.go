@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// serviceCheck 是一个 -check 目标：tcp 只测能否建立连接，http/https 检查状态码，tls 和 https 还报告证书剩余天数
+type serviceCheck struct {
+	name    string
+	kind    string // tcp、http、tls
+	target  string // http 为完整 URL，其余为 HOST:PORT
+	codeMin int
+	codeMax int
+}
+
+// parseCheckStatus 解析 -check-status 的 200 或 200-399
+func parseCheckStatus(spec string) (min, max int, err error) {
+	lo, hi, found := strings.Cut(spec, "-")
+	if min, err = strconv.Atoi(strings.TrimSpace(lo)); err != nil {
+		return 0, 0, fmt.Errorf("invalid status %q", spec)
+	}
+	max = min
+	if found {
+		if max, err = strconv.Atoi(strings.TrimSpace(hi)); err != nil {
+			return 0, 0, fmt.Errorf("invalid status %q", spec)
+		}
+	}
+	if min < 100 || max > 599 || min > max {
+		return 0, 0, fmt.Errorf("invalid status range %q", spec)
+	}
+	return min, max, nil
+}
+
+func parseServiceCheck(name, spec, status string) (serviceCheck, error) {
+	c := serviceCheck{name: name, codeMin: 200, codeMax: 399}
+	u, err := url.Parse(spec)
+	if err != nil || !strings.Contains(spec, "://") {
+		return c, fmt.Errorf("invalid check %q, expected tcp://HOST:PORT, tls://HOST:PORT or an http(s) URL", spec)
+	}
+	switch u.Scheme {
+	case "tcp", "tls":
+		if _, port, err := net.SplitHostPort(u.Host); err != nil || port == "" {
+			return c, fmt.Errorf("invalid %s check %q, expected %s://HOST:PORT", u.Scheme, spec, u.Scheme)
+		}
+		c.kind, c.target = u.Scheme, u.Host
+	case "http", "https":
+		if u.Host == "" {
+			return c, fmt.Errorf("invalid http check %q", spec)
+		}
+		c.kind, c.target = "http", spec
+	default:
+		return c, fmt.Errorf("unsupported check %q, expected tcp://, tls://, http:// or https://", spec)
+	}
+	if status != "" {
+		if c.kind != "http" {
+			return c, fmt.Errorf("check-status only applies to http checks")
+		}
+		if c.codeMin, c.codeMax, err = parseCheckStatus(status); err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+func compileServiceChecks(specs, statuses map[string]string) ([]serviceCheck, error) {
+	for name := range statuses {
+		if _, ok := specs[name]; !ok {
+			return nil, fmt.Errorf("check-status: no -check named %q", name)
+		}
+	}
+	names := make([]string, 0, len(specs))
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	checks := make([]serviceCheck, 0, len(names))
+	for _, name := range names {
+		c, err := parseServiceCheck(name, specs[name], statuses[name])
+		if err != nil {
+			return nil, fmt.Errorf("check %s: %w", name, err)
+		}
+		checks = append(checks, c)
+	}
+	return checks, nil
+}
+
+var serviceChecks []serviceCheck
+
+// checkResult 是一次检查的结果，certExpiry 为零值表示没有证书
+type checkResult struct {
+	up         bool
+	latency    time.Duration
+	statusCode int
+	certExpiry time.Time
+	err        error
+	at         time.Time
+}
+
+// checkTLSConfig 自行校验证书而不是交给 crypto/tls，这样证书无效或已过期时也能记下到期时间
+func checkTLSConfig(expiry *time.Time) *tls.Config {
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return fmt.Errorf("no certificate presented")
+			}
+			*expiry = cs.PeerCertificates[0].NotAfter
+			opts := x509.VerifyOptions{DNSName: cs.ServerName, Intermediates: x509.NewCertPool()}
+			for _, cert := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+			_, err := cs.PeerCertificates[0].Verify(opts)
+			return err
+		},
+	}
+}
+
+func runServiceCheck(c serviceCheck, timeout time.Duration) checkResult {
+	r := checkResult{at: time.Now()}
+	start := time.Now()
+	switch c.kind {
+	case "tcp":
+		conn, err := net.DialTimeout("tcp", c.target, timeout)
+		if err != nil {
+			r.err = err
+			return r
+		}
+		conn.Close()
+	case "tls":
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", c.target, checkTLSConfig(&r.certExpiry))
+		if err != nil {
+			r.err = err
+			return r
+		}
+		conn.Close()
+	case "http":
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.target, nil)
+		if err != nil {
+			r.err = err
+			return r
+		}
+		req.Header.Set("User-Agent", "oci-agent/"+version)
+		// 不跟随重定向，301/302 按状态码判断，避免检查结果取决于跳转后的站点
+		client := &http.Client{
+			CheckRedirect: func(req *http.Request, via []*http.Request) error { return http.ErrUseLastResponse },
+			Transport: &http.Transport{
+				Proxy:             http.ProxyFromEnvironment,
+				TLSClientConfig:   checkTLSConfig(&r.certExpiry),
+				DisableKeepAlives: true,
+			},
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			r.err = err
+			return r
+		}
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+		r.statusCode = resp.StatusCode
+		if resp.StatusCode < c.codeMin || resp.StatusCode > c.codeMax {
+			r.err = fmt.Errorf("status %d, expected %d-%d", resp.StatusCode, c.codeMin, c.codeMax)
+		}
+	}
+	r.latency = time.Since(start)
+	r.up = r.err == nil
+	return r
+}
+
+func (r checkResult) certDays() float64 {
+	return math.Floor(time.Until(r.certExpiry).Hours()/24*10) / 10
+}
+
+// checkState 记录一个目标当前的状态，连续失败达到 -check-failures 次才判定为 down
+type checkState struct {
+	last     checkResult
+	state    string // up、down，尚未判定时为空
+	since    time.Time
+	failures int
+}
+
+// checker 在后台按 -check-interval 执行所有检查，状态变化时发送 check_down、check_up 事件
+type checker struct {
+	mu     sync.RWMutex
+	states map[string]*checkState
+}
+
+var checks = &checker{states: map[string]*checkState{}}
+
+// round 并发执行所有检查一次，-once 模式下在采集前同步执行
+func (k *checker) round(list []serviceCheck) {
+	var wg sync.WaitGroup
+	for _, c := range list {
+		wg.Add(1)
+		go func(c serviceCheck) {
+			defer wg.Done()
+			k.record(c, runServiceCheck(c, cfg.CheckTimeout))
+		}(c)
+	}
+	wg.Wait()
+}
+
+func (k *checker) record(c serviceCheck, r checkResult) {
+	k.mu.Lock()
+	s := k.states[c.name]
+	if s == nil {
+		s = &checkState{}
+		k.states[c.name] = s
+	}
+	s.last = r
+	next := s.state
+	if r.up {
+		s.failures = 0
+		next = "up"
+	} else {
+		s.failures++
+		if s.failures >= cfg.CheckFailures {
+			next = "down"
+		}
+	}
+	previous := s.state
+	if next != previous {
+		s.state, s.since = next, r.at
+	}
+	k.mu.Unlock()
+	if next == previous || next == "" {
+		return
+	}
+	if r.err != nil {
+		slog.Warn("check failed", "component", "checks", "check", c.name, "target", c.target, "err", r.err)
+	}
+	// 启动后第一次判定为 up 不算状态变化，第一次就 down 需要通知
+	if previous == "" && next == "up" {
+		return
+	}
+	ev := alertEvent{
+		Rule:      "check_" + next,
+		Severity:  severityCritical,
+		Status:    "changed",
+		Metric:    "checks." + c.name + ".up",
+		From:      previous,
+		To:        next,
+		Hostname:  reportedHostname(),
+		AgentID:   agentID(),
+		StartedAt: r.at.Format(time.RFC3339),
+		At:        r.at.Format(time.RFC3339),
+	}
+	if previous == "" {
+		ev.From = "unknown"
+	}
+	if next == "up" {
+		ev.Severity, ev.Value = severityInfo, 1
+	} else if r.err != nil {
+		ev.To = "down: " + r.err.Error()
+	}
+	dispatchAlert(ev)
+}
+
+func (k *checker) run(ctx context.Context, list []serviceCheck, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		k.round(list)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// getChecks 返回各 -check 最近一次的结果；证书剩余天数不足 -check-cert-days 时 cert_expiring 为 true
+func getChecks() map[string]interface{} {
+	checks.mu.RLock()
+	defer checks.mu.RUnlock()
+	if len(checks.states) == 0 {
+		return nil
+	}
+	out := make(map[string]interface{}, len(checks.states))
+	for _, c := range serviceChecks {
+		s := checks.states[c.name]
+		if s == nil {
+			continue
+		}
+		m := map[string]interface{}{
+			"type":       c.kind,
+			"target":     c.target,
+			"up":         s.last.up,
+			"checked_at": s.last.at.Format(time.RFC3339),
+		}
+		if s.state != "" {
+			m["state"] = s.state
+			m["state_since"] = s.since.Format(time.RFC3339)
+		}
+		if s.failures > 0 {
+			m["consecutive_failures"] = s.failures
+		}
+		if s.last.up {
+			m["latency_ms"] = math.Round(float64(s.last.latency)/float64(time.Millisecond)*100) / 100
+		}
+		if s.last.statusCode != 0 {
+			m["status_code"] = s.last.statusCode
+		}
+		if !s.last.certExpiry.IsZero() {
+			days := s.last.certDays()
+			m["cert_expires_at"] = s.last.certExpiry.Format(time.RFC3339)
+			m["cert_expiry_days"] = days
+			m["cert_expiring"] = days < float64(cfg.CheckCertDays)
+		}
+		if s.last.err != nil {
+			m["error"] = s.last.err.Error()
+		}
+		out[c.name] = m
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// 子命令；不带子命令时等同于 run，保持与旧的纯参数用法兼容
+const (
+	cmdRun       = "run"
+	cmdOnce      = "once"
+	cmdVersion   = "version"
+	cmdInstall   = "install"
+	cmdUninstall = "uninstall"
+	cmdUpdate    = "update"
+)
+
+var subcommands = []struct{ name, help string }{
+	{cmdRun, "collect and report on -interval until stopped (default)"},
+	{cmdOnce, "collect once, report, print the JSON snapshot and exit (same as -once)"},
+	{cmdVersion, "print version, commit and build date (same as -version)"},
+	{cmdInstall, "write and enable a systemd unit that runs this binary with the given flags"},
+	{cmdUninstall, "stop, disable and remove the systemd unit"},
+	{cmdUpdate, "check -update-url once and install a newer version if there is one"},
+}
+
+// subcommand 为本次运行的子命令，subcommandArgs 为其后的参数，由 parseFlags 设置
+var (
+	subcommand     = cmdRun
+	subcommandArgs []string
+)
+
+// splitSubcommand 取出第一个参数中的子命令，其余参数交给 flag 解析
+func splitSubcommand(args []string) (string, []string, error) {
+	if len(args) == 0 || len(args[0]) == 0 || args[0][0] == '-' {
+		return cmdRun, args, nil
+	}
+	for _, c := range subcommands {
+		if c.name == args[0] {
+			return c.name, args[1:], nil
+		}
+	}
+	return "", nil, fmt.Errorf("unknown command %q, expected one of run, once, version, install, uninstall, update", args[0])
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [command] [flags]\n\nCommands:\n", filepath.Base(os.Args[0]))
+	for _, c := range subcommands {
+		fmt.Fprintf(out, "  %-10s %s\n", c.name, c.help)
+	}
+	fmt.Fprintf(out, "\nFlags:\n")
+	flag.PrintDefaults()
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// collector 是 getSystemInfo 中可单独开关的一个采集项，返回 nil 表示不输出该字段
+type collector struct {
+	name    string
+	collect func() map[string]interface{}
+}
+
+var optionalCollectors = []collector{
+	{"process_states", func() map[string]interface{} { return getProcessStates(sampleWindow) }},
+	{"pressure", getPressure},
+	{"file_descriptors", getFileDescriptors},
+	{"entropy", getEntropy},
+	{"time_sync", getTimeSync},
+	{"raw_files", func() map[string]interface{} { return getRawFiles(cfg.RawFiles) }},
+	{"addresses", getAddresses},
+	{"tunnels", getTunnels},
+	{"firewall", getFirewall},
+	{"integrity", func() map[string]interface{} { return integrity.check(cfg.IntegrityFiles, cfg.IntegrityInterval) }},
+	{"block_devices", getBlockDevices},
+	{"top_processes", func() map[string]interface{} { return getTopProcesses(cfg.TopProcesses, sampleWindow) }},
+	{"traffic", getTraffic},
+	{"connections", getConnections},
+	{"latency", getLatency},
+	{"checks", getChecks},
+	{"custom", getCustom},
+	{"jobs", getJobs},
+	{"units", getUnits},
+	{"ssh", getSSH},
+	{"gpus", getGPUs},
+	{"smart", func() map[string]interface{} {
+		if !cfg.SMART {
+			return nil
+		}
+		return smartStatus.get(cfg.SMARTInterval)
+	}},
+	{"containers", func() map[string]interface{} { return getContainers(cfg.DockerSocket) }},
+	{"kubernetes", func() map[string]interface{} { return kubernetesNode.get(cfg.KubernetesRefresh) }},
+	{"oci_metadata", func() map[string]interface{} { return ociMetadata.get(cfg.OCIMetadataRefresh) }},
+	{"self", getSelf},
+	{"temperatures", func() map[string]interface{} {
+		if temps := getTemperatures(); len(temps) > 0 {
+			return temps
+		}
+		return nil
+	}},
+}
+
+// timeWindow 表示一周中某几天的一个时间段，start > end 时跨越午夜
+type timeWindow struct {
+	days  [7]bool
+	start int // 距离 00:00 的分钟数
+	end   int
+}
+
+var collectorSchedules = map[string][]timeWindow{}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+func parseWeekday(s string) (time.Weekday, error) {
+	d, ok := weekdayNames[strings.ToLower(strings.TrimSpace(s))]
+	if !ok {
+		return 0, fmt.Errorf("unknown weekday %q", s)
+	}
+	return d, nil
+}
+
+func parseClock(s string) (int, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	h, err1 := strconv.Atoi(parts[0])
+	m, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || h < 0 || h > 24 || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	return h*60 + m, nil
+}
+
+// parseTimeWindow 解析 [DAYS@]HH:MM-HH:MM，DAYS 形如 Mon-Fri 或 Sat,Sun
+func parseTimeWindow(spec string) (timeWindow, error) {
+	var w timeWindow
+	clock := spec
+	if at := strings.Index(spec, "@"); at >= 0 {
+		for _, part := range strings.Split(spec[:at], ",") {
+			bounds := strings.SplitN(part, "-", 2)
+			from, err := parseWeekday(bounds[0])
+			if err != nil {
+				return w, err
+			}
+			to := from
+			if len(bounds) == 2 {
+				if to, err = parseWeekday(bounds[1]); err != nil {
+					return w, err
+				}
+			}
+			for d := from; ; d = (d + 1) % 7 {
+				w.days[d] = true
+				if d == to {
+					break
+				}
+			}
+		}
+		clock = spec[at+1:]
+	} else {
+		for d := range w.days {
+			w.days[d] = true
+		}
+	}
+
+	bounds := strings.SplitN(clock, "-", 2)
+	if len(bounds) != 2 {
+		return w, fmt.Errorf("invalid window %q, expected [DAYS@]HH:MM-HH:MM", spec)
+	}
+	var err error
+	if w.start, err = parseClock(bounds[0]); err != nil {
+		return w, err
+	}
+	if w.end, err = parseClock(bounds[1]); err != nil {
+		return w, err
+	}
+	if w.start == w.end {
+		return w, fmt.Errorf("invalid window %q, start equals end", spec)
+	}
+	return w, nil
+}
+
+func (w timeWindow) contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if w.start < w.end {
+		return w.days[t.Weekday()] && minute >= w.start && minute < w.end
+	}
+	// 跨午夜的窗口，凌晨部分属于前一天
+	if minute >= w.start {
+		return w.days[t.Weekday()]
+	}
+	return minute < w.end && w.days[(t.Weekday()+6)%7]
+}
+
+func compileCollectorSchedules(specs map[string][]string) (map[string][]timeWindow, error) {
+	schedules := make(map[string][]timeWindow, len(specs))
+	for name, windows := range specs {
+		if !isOptionalCollector(name) {
+			return nil, fmt.Errorf("unknown collector %q", name)
+		}
+		for _, spec := range windows {
+			w, err := parseTimeWindow(spec)
+			if err != nil {
+				return nil, fmt.Errorf("collector %s: %w", name, err)
+			}
+			schedules[name] = append(schedules[name], w)
+		}
+	}
+	return schedules, nil
+}
+
+func isOptionalCollector(name string) bool {
+	for _, c := range optionalCollectors {
+		if c.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// collectorActive 判断采集项当前是否启用：未被禁用，且没有配置时间窗口或落在某个窗口内
+func collectorActive(name string, now time.Time) bool {
+	for _, disabled := range cfg.DisabledCollectors {
+		if disabled == name {
+			return false
+		}
+	}
+	windows, ok := collectorSchedules[name]
+	if !ok {
+		return true
+	}
+	for _, w := range windows {
+		if w.contains(now) {
+			return true
+		}
+	}
+	return false
+}
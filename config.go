@@ -0,0 +1,908 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type Config struct {
+	RawFiles        map[string]string
+	UnitConversions map[string]string
+	TempWarn        map[string]string
+	Probes          map[string]string
+	Plugins         map[string]string
+	PluginTimeouts  map[string]string
+	PluginIntervals map[string]string
+
+	IntegrityFiles    []string
+	IntegrityInterval time.Duration
+
+	FIFOPath string
+
+	DisabledCollectors []string
+	CollectorSchedules map[string][]string
+	CollectorTimeout   time.Duration
+
+	Format        string
+	SummaryFields string
+
+	TestCollectorAddr string
+
+	ReportURLs        []string
+	HeartbeatURL      string
+	Interval          time.Duration
+	HeartbeatInterval time.Duration
+	Jitter            float64
+	Splay             time.Duration
+	SampleWindow      time.Duration
+	AuthToken         string
+	SigningSecret     string
+	EncryptionKey     string
+
+	OfflineHeartbeat bool
+	ShutdownTimeout  time.Duration
+
+	RetryMaxAttempts int
+	RetryMaxBackoff  time.Duration
+	HTTPTimeout      time.Duration
+	Proxy            string
+
+	TLSCertFile string
+	TLSKeyFile  string
+	TLSCAFile   string
+
+	TLSServerName         string
+	TLSInsecureSkipVerify bool
+
+	ListenAddr        string
+	PprofAddr         string
+	HealthMaxFailures int
+	LogLevel          string
+
+	LogFormat     string
+	LogFile       string
+	LogMaxSize    string
+	LogMaxAge     time.Duration
+	LogMaxBackups int
+
+	DiskSkipFstypes     []string
+	DiskSkipMountpoints []string
+	NetInclude          []string
+	NetExclude          []string
+
+	TopProcesses int
+
+	PublicIPEchoURLs  []string
+	PublicIPRefresh   time.Duration
+	PublicIPStateFile string
+
+	OCIMetadataRefresh time.Duration
+	NTPServer          string
+	NTPInterval        time.Duration
+	FactsRefresh       time.Duration
+
+	DockerSocket string
+
+	KubernetesNode    string
+	KubernetesRefresh time.Duration
+
+	HostProc string
+	HostSys  string
+	HostEtc  string
+	HostVar  string
+	HostRun  string
+
+	SSHAuthLog string
+
+	LogWatches       map[string][]string
+	LogMatches       map[string][]string
+	LogWatchInterval time.Duration
+	LogWatchMaxLines int
+	LogWatchNotify   bool
+
+	Units       []string
+	UnitRestart bool
+
+	SMART         bool
+	SMARTInterval time.Duration
+
+	ProbeInterval time.Duration
+	ProbeCount    int
+	ProbeTimeout  time.Duration
+
+	Checks        map[string]string
+	CheckStatuses map[string]string
+	CheckInterval time.Duration
+	CheckTimeout  time.Duration
+	CheckFailures int
+	CheckCertDays int
+
+	SpeedtestURL       string
+	SpeedtestUploadURL string
+	SpeedtestInterval  time.Duration
+	SpeedtestDuration  time.Duration
+	SpeedtestMaxBytes  int64
+
+	TrafficStateFile      string
+	TrafficResetDay       int
+	TrafficQuotaSize      string
+	TrafficQuota          uint64
+	TrafficQuotaDirection string
+	TrafficQuotaCommand   string
+
+	HistoryWindow     time.Duration
+	HistoryResolution time.Duration
+	HistoryMetrics    []string
+	HistoryFile       string
+
+	QueueSize     int
+	SpoolPath     string
+	SpoolMaxBytes int64
+
+	Gzip              bool
+	Delta             bool
+	DeltaFullInterval time.Duration
+
+	ShowVersion bool
+	Once        bool
+
+	Labels         map[string]string
+	Hostname       string
+	InstanceIDFile string
+	RegisterURL    string
+	AgentTokenFile string
+
+	ByteUnits      string
+	FormattedBytes bool
+
+	WebSocketURL  string
+	GRPCAddr      string
+	GRPCPlaintext bool
+
+	NezhaServer string
+	NezhaSecret string
+	NezhaTLS    bool
+
+	InfluxURL    string
+	InfluxOrg    string
+	InfluxBucket string
+	InfluxToken  string
+
+	MQTTBroker      string
+	MQTTTopicPrefix string
+	MQTTQoS         int
+	MQTTRetain      bool
+	MQTTClientID    string
+	MQTTUsername    string
+	MQTTPassword    string
+
+	TaskURL          string
+	TaskPollInterval time.Duration
+	TaskTimeout      time.Duration
+	TaskAllow        []string
+
+	Jobs       map[string]string
+	JobTimeout time.Duration
+
+	TerminalAllow       []string
+	TerminalShell       string
+	TerminalIdleTimeout time.Duration
+	TerminalAuditLog    string
+
+	UpdateURL       string
+	UpdateInterval  time.Duration
+	UpdatePublicKey string
+
+	AlertRules     []string
+	NotifyWebhooks []string
+	NotifyTemplate string
+	TelegramToken  string
+	TelegramChatID string
+	BarkURL        string
+	ServerChanKey  string
+}
+
+var cfg = Config{
+	RawFiles:        map[string]string{},
+	UnitConversions: map[string]string{},
+	TempWarn:        map[string]string{},
+	Probes:          map[string]string{},
+	Plugins:         map[string]string{},
+	PluginTimeouts:  map[string]string{},
+	PluginIntervals: map[string]string{},
+
+	CollectorSchedules: map[string][]string{},
+	CollectorTimeout:   5 * time.Second,
+	Labels:             map[string]string{},
+
+	Format:        "json",
+	SummaryFields: "cpu,mem,disk,net,load",
+
+	Interval:         1 * time.Second,
+	SampleWindow:     1 * time.Second,
+	OfflineHeartbeat: true,
+	ShutdownTimeout:  10 * time.Second,
+
+	RetryMaxAttempts: 3,
+	RetryMaxBackoff:  30 * time.Second,
+	HTTPTimeout:      10 * time.Second,
+
+	DiskSkipFstypes: []string{
+		"tmpfs", "devtmpfs", "overlay", "squashfs", "proc", "sysfs", "cgroup", "cgroup2",
+		"devpts", "mqueue", "debugfs", "tracefs", "securityfs", "pstore", "bpf", "autofs",
+		"hugetlbfs", "configfs", "fusectl", "nsfs", "ramfs", "binfmt_misc",
+	},
+	DiskSkipMountpoints: []string{"/snap/*", "/var/lib/docker/*", "/run/*"},
+	NetExclude:          []string{"lo", "docker*", "veth*"},
+	LogLevel:            "info",
+
+	LogFormat:     "text",
+	LogMaxSize:    "10MiB",
+	LogMaxBackups: 5,
+
+	HealthMaxFailures: 5,
+
+	TopProcesses: 5,
+	ByteUnits:    byteUnitsLegacy,
+
+	FormattedBytes: true,
+
+	PublicIPRefresh: 5 * time.Minute,
+
+	OCIMetadataRefresh: 10 * time.Minute,
+	NTPInterval:        5 * time.Minute,
+	FactsRefresh:       time.Hour,
+
+	DockerSocket: "/var/run/docker.sock",
+
+	KubernetesRefresh: time.Minute,
+
+	LogWatches:       map[string][]string{},
+	LogMatches:       map[string][]string{},
+	LogWatchInterval: 10 * time.Second,
+	LogWatchMaxLines: 20,
+
+	SMARTInterval: 30 * time.Minute,
+
+	ProbeInterval: 30 * time.Second,
+	ProbeCount:    5,
+	ProbeTimeout:  2 * time.Second,
+
+	Checks:        map[string]string{},
+	CheckStatuses: map[string]string{},
+	CheckInterval: 30 * time.Second,
+	CheckTimeout:  5 * time.Second,
+	CheckFailures: 2,
+	CheckCertDays: 14,
+
+	SpeedtestDuration: 10 * time.Second,
+	SpeedtestMaxBytes: 100 << 20,
+
+	MQTTTopicPrefix: "oci-agent",
+	MQTTQoS:         1,
+	MQTTRetain:      true,
+
+	TrafficResetDay:       1,
+	TrafficQuotaDirection: "sent",
+
+	HistoryResolution: 10 * time.Second,
+	HistoryMetrics:    defaultHistoryMetrics,
+
+	QueueSize:     300,
+	SpoolMaxBytes: 10 << 20,
+
+	IntegrityInterval: 10 * time.Minute,
+
+	TaskPollInterval: 30 * time.Second,
+	TaskTimeout:      5 * time.Minute,
+
+	Jobs:       map[string]string{},
+	JobTimeout: jobDefaultTimeout,
+
+	TerminalIdleTimeout: 15 * time.Minute,
+
+	UpdateInterval: 6 * time.Hour,
+}
+
+// kvFlag 支持重复传入 key=value 形式的参数
+type kvFlag map[string]string
+
+func (f kvFlag) String() string {
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+f[k])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (f kvFlag) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	f[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	return nil
+}
+
+// stringsFlag 支持重复传入同一个参数
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// multiKVFlag 与 kvFlag 类似，但同一个 key 可以出现多次
+type multiKVFlag map[string][]string
+
+func (f multiKVFlag) String() string {
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, k := range keys {
+		for _, v := range f[k] {
+			pairs = append(pairs, k+"="+v)
+		}
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (f multiKVFlag) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	key := strings.TrimSpace(parts[0])
+	f[key] = append(f[key], strings.TrimSpace(parts[1]))
+	return nil
+}
+
+// applyEnv 用 OCI_AGENT_* 环境变量覆盖默认值和配置文件，命令行参数的优先级更高
+func applyEnv() error {
+	if v := os.Getenv("OCI_AGENT_REPORT_URL"); v != "" {
+		(*listFlag)(&cfg.ReportURLs).Set(v)
+	}
+	if v := os.Getenv("OCI_AGENT_HOSTNAME"); v != "" {
+		cfg.Hostname = v
+	}
+	if v := os.Getenv("OCI_AGENT_KUBERNETES_NODE"); v != "" {
+		cfg.KubernetesNode = v
+	}
+	if v := os.Getenv("OCI_AGENT_PROXY"); v != "" {
+		cfg.Proxy = v
+	}
+	if v := os.Getenv("OCI_AGENT_HEARTBEAT_URL"); v != "" {
+		cfg.HeartbeatURL = v
+	}
+	if v := os.Getenv("OCI_AGENT_TOKEN"); v != "" {
+		cfg.AuthToken = v
+	}
+	if v := os.Getenv("OCI_AGENT_SIGNING_SECRET"); v != "" {
+		cfg.SigningSecret = v
+	}
+	if v := os.Getenv("OCI_AGENT_ENCRYPTION_KEY"); v != "" {
+		cfg.EncryptionKey = v
+	}
+	if v := os.Getenv("OCI_AGENT_TELEGRAM_TOKEN"); v != "" {
+		cfg.TelegramToken = v
+	}
+	if v := os.Getenv("OCI_AGENT_SERVERCHAN_KEY"); v != "" {
+		cfg.ServerChanKey = v
+	}
+	if v := os.Getenv("OCI_AGENT_INFLUX_TOKEN"); v != "" {
+		cfg.InfluxToken = v
+	}
+	if v := os.Getenv("OCI_AGENT_NEZHA_SECRET"); v != "" {
+		cfg.NezhaSecret = v
+	}
+	if v := os.Getenv("OCI_AGENT_MQTT_PASSWORD"); v != "" {
+		cfg.MQTTPassword = v
+	}
+	if v := os.Getenv("OCI_AGENT_LABELS"); v != "" {
+		for _, pair := range strings.Split(v, ",") {
+			if err := kvFlag(cfg.Labels).Set(pair); err != nil {
+				return fmt.Errorf("OCI_AGENT_LABELS: %w", err)
+			}
+		}
+	}
+	if v := os.Getenv("OCI_AGENT_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("OCI_AGENT_INTERVAL: %w", err)
+		}
+		cfg.Interval = d
+	}
+	if v := os.Getenv("OCI_AGENT_HEARTBEAT_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("OCI_AGENT_HEARTBEAT_INTERVAL: %w", err)
+		}
+		cfg.HeartbeatInterval = d
+	}
+	return nil
+}
+
+// listFlag 是逗号分隔的列表，传入时整体替换默认值
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(value string) error {
+	*f = nil
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*f = append(*f, item)
+		}
+	}
+	return nil
+}
+
+// parseFlags 的优先级从低到高为：内置默认值、配置文件、环境变量、命令行参数
+func parseFlags() error {
+	flag.Var(kvFlag(cfg.RawFiles), "raw-file", "read a /proc or /sys file into raw_files, as label=path (repeatable)")
+	flag.Var(kvFlag(cfg.TempWarn), "temp-warn", "temperature warning threshold in celsius, as cpu=85, nvme=70 or sensor-glob=value; defaults to each sensor's own high value (repeatable)")
+	flag.Var(kvFlag(cfg.UnitConversions), "convert", "convert a numeric field before serialization, as field.path=unit, e.g. tunnels.*.rx_bytes=MiB; the field is renamed after its new unit, here rx_mib (repeatable)")
+	flag.Var((*stringsFlag)(&cfg.IntegrityFiles), "integrity-file", "track changes to a critical file such as /etc/passwd or /etc/ssh/sshd_config (repeatable)")
+	flag.DurationVar(&cfg.IntegrityInterval, "integrity-interval", cfg.IntegrityInterval, "how often tracked files are re-hashed")
+	flag.StringVar(&cfg.FIFOPath, "fifo", "", "write NDJSON reports to this named pipe, created if missing")
+	flag.Var((*stringsFlag)(&cfg.DisabledCollectors), "disable-collector", "skip an optional collector such as tunnels or integrity (repeatable)")
+	flag.Var(multiKVFlag(cfg.CollectorSchedules), "collector-schedule", "only run a collector inside a local time window, as name=[DAYS@]HH:MM-HH:MM, e.g. integrity=Mon-Fri@01:00-05:00 (repeatable)")
+	flag.DurationVar(&cfg.CollectorTimeout, "collector-timeout", cfg.CollectorTimeout, "give up on collectors still running after this long and report the partial result; must exceed -sample-window")
+	flag.StringVar(&cfg.Format, "format", cfg.Format, "stdout output format: json or summary")
+	flag.StringVar(&cfg.SummaryFields, "summary-fields", cfg.SummaryFields, "comma-separated fields for -format=summary: "+strings.Join(summaryFieldNames, ","))
+	flag.StringVar(&cfg.TestCollectorAddr, "serve-test-collector", "", "run a test collector on this address (e.g. :8080) that validates and prints received reports, instead of the agent")
+	flag.Var((*listFlag)(&cfg.ReportURLs), "report-url", "comma-separated URLs that receive full reports; each has its own queue, retries and spool, so a dead one does not hold back the others (env OCI_AGENT_REPORT_URL)")
+	flag.StringVar(&cfg.HeartbeatURL, "heartbeat-url", cfg.HeartbeatURL, "POST heartbeats to this URL (env OCI_AGENT_HEARTBEAT_URL)")
+	flag.DurationVar(&cfg.Interval, "interval", cfg.Interval, "report interval, e.g. 30s or 2m (env OCI_AGENT_INTERVAL)")
+	flag.DurationVar(&cfg.HeartbeatInterval, "heartbeat-interval", 0, "send heartbeats on their own schedule instead of after every report cycle (env OCI_AGENT_HEARTBEAT_INTERVAL)")
+	flag.Float64Var(&cfg.Jitter, "jitter", 0, "randomize each report and heartbeat interval by up to this fraction, e.g. 0.1 for ±10%, so many agents drift apart")
+	flag.DurationVar(&cfg.Splay, "splay", 0, "wait a random time up to this long before the first report and the first heartbeat, e.g. 30s, to spread agents that start together")
+	flag.DurationVar(&cfg.SampleWindow, "sample-window", cfg.SampleWindow, "sampling window for CPU usage, network speed and other rates")
+	flag.StringVar(&cfg.AuthToken, "auth-token", cfg.AuthToken, "bearer token sent with reports and heartbeats, also required by -serve-test-collector when set (env OCI_AGENT_TOKEN)")
+	flag.StringVar(&cfg.SigningSecret, "signing-secret", cfg.SigningSecret, "shared secret for HMAC-SHA256 request signatures (X-Signature over timestamp, nonce and body), also verified by -serve-test-collector when set and required on web terminal open messages (env OCI_AGENT_SIGNING_SECRET)")
+	flag.StringVar(&cfg.EncryptionKey, "encryption-key", cfg.EncryptionKey, "pre-shared 32-byte key (base64 or hex) to encrypt HTTP report and heartbeat bodies with AES-256-GCM for collectors reached over plain HTTP, also used by -serve-test-collector to decrypt; not supported with -ws-url, -mqtt-broker or -grpc-addr (env OCI_AGENT_ENCRYPTION_KEY)")
+	flag.IntVar(&cfg.RetryMaxAttempts, "retry-max-attempts", cfg.RetryMaxAttempts, "maximum attempts per report, including the first")
+	flag.DurationVar(&cfg.RetryMaxBackoff, "retry-max-backoff", cfg.RetryMaxBackoff, "upper bound for the exponential backoff between retries")
+	flag.DurationVar(&cfg.HTTPTimeout, "http-timeout", cfg.HTTPTimeout, "timeout for each report or heartbeat request")
+	flag.StringVar(&cfg.Proxy, "proxy", cfg.Proxy, "proxy for HTTP(S) and WebSocket traffic: http://, https://, socks5:// or socks5h://host:port, or direct to ignore the environment (default HTTPS_PROXY/HTTP_PROXY/NO_PROXY, env OCI_AGENT_PROXY)")
+	flag.StringVar(&cfg.TLSCertFile, "tls-cert", "", "PEM client certificate presented to the collector for mutual TLS (requires -tls-key)")
+	flag.StringVar(&cfg.TLSKeyFile, "tls-key", "", "PEM private key for -tls-cert")
+	flag.StringVar(&cfg.TLSCAFile, "tls-ca", "", "PEM CA bundle used to verify the collector instead of the system roots")
+	flag.StringVar(&cfg.TLSServerName, "tls-server-name", "", "override the SNI and the host name the collector certificate is verified against")
+	flag.BoolVar(&cfg.TLSInsecureSkipVerify, "tls-insecure-skip-verify", false, "do not verify the collector certificate at all; for debugging only")
+	flag.StringVar(&cfg.PprofAddr, "pprof-listen", "", "serve net/http/pprof at /debug/pprof/ on this loopback address, e.g. 127.0.0.1:6060, for debugging the agent itself")
+	flag.StringVar(&cfg.ListenAddr, "listen", "", "serve Prometheus metrics at /metrics, the full report at /info and a liveness probe at /healthz on this address, e.g. :9101 or 127.0.0.1:9101")
+	flag.IntVar(&cfg.HealthMaxFailures, "health-max-failures", cfg.HealthMaxFailures, "/healthz returns 503 after this many consecutive failed reports, 0 never fails")
+	flag.Var((*listFlag)(&cfg.DiskSkipFstypes), "disk-skip-fstypes", "comma-separated filesystem types excluded from disk totals and partitions")
+	flag.Var((*listFlag)(&cfg.DiskSkipMountpoints), "disk-skip-mountpoints", "comma-separated mountpoint globs excluded from disk totals and partitions")
+	flag.Var((*listFlag)(&cfg.NetInclude), "net-include", "comma-separated interface name globs; when set, only matching interfaces appear in network_interfaces and addresses")
+	flag.Var((*listFlag)(&cfg.NetExclude), "net-exclude", "comma-separated interface name globs left out of network_interfaces and addresses, applied after -net-include")
+	flag.BoolVar(&cfg.OfflineHeartbeat, "offline-heartbeat", cfg.OfflineHeartbeat, "send a final heartbeat with status offline on SIGINT/SIGTERM")
+	flag.DurationVar(&cfg.ShutdownTimeout, "shutdown-timeout", cfg.ShutdownTimeout, "on SIGINT/SIGTERM, how long each -report-url queue may spend delivering what is still queued before it is spooled (0 = spool immediately)")
+	flag.StringVar(&cfg.LogLevel, "log-level", cfg.LogLevel, "log level: debug, info, warn or error")
+	flag.StringVar(&cfg.LogFormat, "log-format", cfg.LogFormat, "log output format: text or json")
+	flag.StringVar(&cfg.LogFile, "log-file", "", "write logs to this file instead of stderr, rotated by -log-max-size and -log-max-age")
+	flag.StringVar(&cfg.LogMaxSize, "log-max-size", cfg.LogMaxSize, "rotate -log-file once it reaches this size, e.g. 10MiB")
+	flag.DurationVar(&cfg.LogMaxAge, "log-max-age", 0, "also rotate -log-file once it is this old, e.g. 24h (0 = size only)")
+	flag.IntVar(&cfg.LogMaxBackups, "log-max-backups", cfg.LogMaxBackups, "rotated log files to keep")
+	flag.IntVar(&cfg.TopProcesses, "top-processes", cfg.TopProcesses, "number of top processes by CPU and memory to report, 0 disables")
+	flag.Var((*listFlag)(&cfg.PublicIPEchoURLs), "public-ip-echo-url", "comma-separated external services that echo the caller's IP, tried in order, e.g. https://api64.ipify.org; used when no interface has a public address (off by default)")
+	flag.StringVar(&cfg.PublicIPStateFile, "public-ip-state-file", "", "where the last public IPs are kept so changes across restarts are detected (default /var/lib/oci-agent/public_ip, or the user config dir)")
+	flag.DurationVar(&cfg.PublicIPRefresh, "public-ip-refresh", cfg.PublicIPRefresh, "how long a detected public IP is cached")
+	flag.DurationVar(&cfg.HistoryWindow, "history-window", 0, "keep this much local history of -history-metric at -history-resolution for GET /history on -listen, e.g. 24h (0 = disabled)")
+	flag.DurationVar(&cfg.HistoryResolution, "history-resolution", cfg.HistoryResolution, "history bucket size; reports within one bucket are averaged")
+	flag.Var((*listFlag)(&cfg.HistoryMetrics), "history-metric", "comma-separated numeric payload fields kept in the history")
+	flag.StringVar(&cfg.HistoryFile, "history-file", "", "persist the history here every 5 minutes and at shutdown so it survives restarts (off by default)")
+	flag.IntVar(&cfg.QueueSize, "queue-size", cfg.QueueSize, "reports kept in memory while the collector is unreachable; older ones spill to -spool-path or are dropped")
+	flag.DurationVar(&cfg.OCIMetadataRefresh, "oci-metadata-refresh", cfg.OCIMetadataRefresh, "how long OCI instance metadata (OCID, shape, region, tags) is cached; disable with -disable-collector oci_metadata")
+	flag.StringVar(&cfg.NTPServer, "ntp-server", "", "measure clock offset against this NTP server with SNTP, e.g. 169.254.169.254 (the OCI NTP service) or pool.ntp.org; reported as time_sync.offset_ms and in heartbeats as clock_offset_ms")
+	flag.DurationVar(&cfg.NTPInterval, "ntp-interval", cfg.NTPInterval, "how often -ntp-server is queried")
+	flag.DurationVar(&cfg.FactsRefresh, "facts-refresh", cfg.FactsRefresh, "re-read static host facts (CPU model, distribution, virtualization, partition list) this often; SIGHUP or POST /refresh on -listen does it on demand (0 = only on demand)")
+	flag.Var(kvFlag(cfg.Probes), "probe", "measure latency and packet loss to a target, as name=icmp://1.1.1.1 or name=tcp://panel.example.com:443; a bare host means icmp (repeatable)")
+	flag.Var(kvFlag(cfg.Plugins), "plugin", "run an external command and merge its JSON object stdout into the report as custom.<name>, as name=COMMAND; the command runs through sh -c (cmd /C on Windows) (repeatable)")
+	flag.Var(kvFlag(cfg.PluginTimeouts), "plugin-timeout", "per-plugin timeout as name=DURATION (default 10s) (repeatable)")
+	flag.Var(kvFlag(cfg.PluginIntervals), "plugin-interval", "per-plugin schedule as name=DURATION (default -interval) (repeatable)")
+	flag.DurationVar(&cfg.ProbeInterval, "probe-interval", cfg.ProbeInterval, "how often each -probe target is measured")
+	flag.IntVar(&cfg.ProbeCount, "probe-count", cfg.ProbeCount, "pings or TCP connects per -probe round")
+	flag.DurationVar(&cfg.ProbeTimeout, "probe-timeout", cfg.ProbeTimeout, "timeout for a single ping or TCP connect")
+	flag.Var(kvFlag(cfg.Checks), "check", "monitor a service, as name=tcp://HOST:PORT, name=tls://HOST:PORT or name=https://example.com/health; reports up, latency and certificate expiry under checks and emits check_down/check_up events (repeatable)")
+	flag.Var(kvFlag(cfg.CheckStatuses), "check-status", "HTTP status accepted by an http -check, as name=200 or name=200-299 (default 200-399, redirects are not followed) (repeatable)")
+	flag.DurationVar(&cfg.CheckInterval, "check-interval", cfg.CheckInterval, "how often each -check is run")
+	flag.DurationVar(&cfg.CheckTimeout, "check-timeout", cfg.CheckTimeout, "timeout for a single -check, including the TLS handshake and HTTP response")
+	flag.IntVar(&cfg.CheckFailures, "check-failures", cfg.CheckFailures, "consecutive failures before a -check is considered down and check_down is sent")
+	flag.IntVar(&cfg.CheckCertDays, "check-cert-days", cfg.CheckCertDays, "mark a certificate as cert_expiring when it expires within this many days; alert on it with e.g. -alert \"cert: checks.*.cert_expiry_days < 14\"")
+	flag.StringVar(&cfg.SpeedtestURL, "speedtest-url", "", "bandwidth test target: an http(s) URL of a large file to download, or iperf3://HOST[:PORT]; run by a speedtest task or -speedtest-interval")
+	flag.StringVar(&cfg.SpeedtestUploadURL, "speedtest-upload-url", "", "http(s) URL that accepts a POST body for the upload half of an http speedtest")
+	flag.DurationVar(&cfg.SpeedtestInterval, "speedtest-interval", 0, "run a speedtest this often and attach the result once to the next report as speedtest (0 = only on demand)")
+	flag.DurationVar(&cfg.SpeedtestDuration, "speedtest-duration", cfg.SpeedtestDuration, "upper bound for each direction of a speedtest")
+	flag.Int64Var(&cfg.SpeedtestMaxBytes, "speedtest-max-bytes", cfg.SpeedtestMaxBytes, "upper bound for the bytes transferred in each direction of an http speedtest")
+	flag.StringVar(&cfg.DockerSocket, "docker-socket", cfg.DockerSocket, "Docker Engine API socket used by the containers collector (a Podman API socket also works); skipped when absent")
+	flag.StringVar(&cfg.KubernetesNode, "kubernetes-node", cfg.KubernetesNode, "Kubernetes node name whose conditions and pods are reported, usually injected from spec.nodeName in a DaemonSet (default the hostname, env OCI_AGENT_KUBERNETES_NODE)")
+	flag.DurationVar(&cfg.KubernetesRefresh, "kubernetes-refresh", cfg.KubernetesRefresh, "how long Kubernetes node status, pod counts and kubelet health are cached")
+	flag.StringVar(&cfg.HostProc, "host-proc", "", "host /proc mounted into the container, e.g. /host/proc (sets HOST_PROC for gopsutil)")
+	flag.StringVar(&cfg.HostSys, "host-sys", "", "host /sys mounted into the container, e.g. /host/sys (sets HOST_SYS)")
+	flag.StringVar(&cfg.HostEtc, "host-etc", "", "host /etc mounted into the container, e.g. /host/etc (sets HOST_ETC)")
+	flag.StringVar(&cfg.HostVar, "host-var", "", "host /var mounted into the container, e.g. /host/var (sets HOST_VAR)")
+	flag.StringVar(&cfg.HostRun, "host-run", "", "host /run mounted into the container, e.g. /host/run (sets HOST_RUN)")
+	flag.StringVar(&cfg.SSHAuthLog, "ssh-auth-log", "", "sshd log tailed for failed and accepted logins (default /var/log/auth.log or /var/log/secure, whichever exists)")
+	flag.Var(multiKVFlag(cfg.LogWatches), "log-watch", "tail log files matching a glob, as name=GLOB, e.g. nginx=/var/log/nginx/error.log; lines matching -log-match are reported under log_events.<name> (repeatable)")
+	flag.Var(multiKVFlag(cfg.LogMatches), "log-match", "regular expression selecting the lines of a -log-watch to forward, as name=REGEX (repeatable, default matches error, crit, fatal, panic, emerg and alert)")
+	flag.DurationVar(&cfg.LogWatchInterval, "log-watch-interval", cfg.LogWatchInterval, "how often watched log files are read")
+	flag.IntVar(&cfg.LogWatchMaxLines, "log-watch-max-lines", cfg.LogWatchMaxLines, "rate limit: lines forwarded per -log-watch per -log-watch-interval; further matches are only counted as suppressed")
+	flag.BoolVar(&cfg.LogWatchNotify, "log-watch-notify", false, "also send a log_matched event to the -notify-* channels for each -log-watch with new matches, at most one per -log-watch-interval")
+	flag.Var((*stringsFlag)(&cfg.Units), "unit", "report the state of this systemd unit, e.g. nginx or wg-quick@wg0, and emit a unit_state_changed event when it changes (repeatable)")
+	flag.BoolVar(&cfg.UnitRestart, "unit-restart", false, "restart a -unit that has entered the failed state, at most once every 5 minutes, and emit a unit_restarted event")
+	flag.BoolVar(&cfg.SMART, "smart", false, "report disk health from smartctl (smartmontools): PASSED/FAILED, reallocated sectors and SSD/NVMe wear; usually needs root")
+	flag.DurationVar(&cfg.SMARTInterval, "smart-interval", cfg.SMARTInterval, "how long -smart results are cached before smartctl is run again")
+	flag.StringVar(&cfg.TrafficStateFile, "traffic-state-file", "", "where month-to-date traffic is persisted (default /var/lib/oci-agent/traffic.json, or the user config dir)")
+	flag.IntVar(&cfg.TrafficResetDay, "traffic-reset-day", cfg.TrafficResetDay, "day of month (1-31) on which traffic accounting restarts; clamped to the last day of short months")
+	flag.StringVar(&cfg.TrafficQuotaSize, "traffic-quota", "", "monthly traffic quota such as 10TB (OCI free tier egress); reports traffic.quota_percent for use with -alert")
+	flag.StringVar(&cfg.TrafficQuotaDirection, "traffic-quota-direction", cfg.TrafficQuotaDirection, "traffic counted against -traffic-quota: sent, recv or both")
+	flag.StringVar(&cfg.TrafficQuotaCommand, "traffic-quota-command", "", "shell command run once per period when the quota is reached, e.g. to take an interface down")
+	flag.StringVar(&cfg.SpoolPath, "spool-path", "", "NDJSON file that receives reports overflowing the in-memory queue or still queued at shutdown, replayed once the collector is back")
+	flag.Int64Var(&cfg.SpoolMaxBytes, "spool-max-bytes", cfg.SpoolMaxBytes, "maximum spool file size; the oldest reports are dropped once full")
+	flag.BoolVar(&cfg.Gzip, "gzip", false, "gzip-compress report and heartbeat bodies (Content-Encoding: gzip); the collector must support it")
+	flag.BoolVar(&cfg.Delta, "delta", false, "omit static fields such as cpu.model, distribution and memory.total from -report-url payloads unless they changed; such payloads carry \"delta\": true")
+	flag.DurationVar(&cfg.DeltaFullInterval, "delta-full-interval", cfg.DeltaFullInterval, "with -delta, still send the static fields this often so a restarted collector catches up (0 = only on change)")
+	flag.BoolVar(&cfg.ShowVersion, "version", false, "print version, commit and build date, then exit")
+	flag.BoolVar(&cfg.Once, "once", false, "collect once, report to the configured destinations, print the result and exit (non-zero if reporting failed)")
+	flag.Var(kvFlag(cfg.Labels), "label", "attach a key=value label to every report and heartbeat (repeatable, env OCI_AGENT_LABELS=k=v,k2=v2)")
+	flag.StringVar(&cfg.Hostname, "hostname", cfg.Hostname, "hostname reported in every report, heartbeat and alert instead of the system one (env OCI_AGENT_HOSTNAME)")
+	flag.StringVar(&cfg.InstanceIDFile, "instance-id-file", "", "where the generated instance UUID is persisted (default /var/lib/oci-agent/instance_id, or the user config dir)")
+	flag.StringVar(&cfg.RegisterURL, "register-url", "", "on first run POST hostname, OS, arch and version here (using -auth-token as the bootstrap token) and use the returned token for everything afterwards")
+	flag.StringVar(&cfg.AgentTokenFile, "agent-token-file", "", "where the token returned by -register-url is stored (default /var/lib/oci-agent/agent_token, or the user config dir)")
+	flag.StringVar(&cfg.ByteUnits, "byte-units", cfg.ByteUnits, "formatting of human-readable sizes: legacy (1024, K/M/G), iec (1024, KiB/MiB) or si (1000, KB/MB)")
+	flag.BoolVar(&cfg.FormattedBytes, "formatted-bytes", cfg.FormattedBytes, "include human-readable size strings such as \"1.50G\" next to the raw *_bytes fields; false sends only the raw values")
+	flag.StringVar(&cfg.GRPCAddr, "grpc-addr", "", "report over gRPC (agentpb/agent.proto) to this host:port: a bidirectional stream for metrics and a unary call for heartbeats; uses the -tls-* settings")
+	flag.BoolVar(&cfg.GRPCPlaintext, "grpc-plaintext", false, "connect to -grpc-addr without TLS, for trusted networks and testing")
+	flag.StringVar(&cfg.NezhaServer, "nezha-server", "", "also report to a Nezha dashboard at this host:port using the Nezha v1 agent gRPC protocol; the agent shows up under its instance id as client UUID")
+	flag.StringVar(&cfg.NezhaSecret, "nezha-secret", cfg.NezhaSecret, "client secret of the Nezha dashboard, required with -nezha-server (env OCI_AGENT_NEZHA_SECRET)")
+	flag.BoolVar(&cfg.NezhaTLS, "nezha-tls", false, "connect to -nezha-server over TLS (uses the -tls-* settings)")
+	flag.StringVar(&cfg.InfluxURL, "influx-url", "", "write InfluxDB line protocol to an InfluxDB v2 server (http://host:8086, needs -influx-org and -influx-bucket) or a Telegraf socket_listener (udp://, tcp:// or unix:///path)")
+	flag.StringVar(&cfg.InfluxOrg, "influx-org", "", "InfluxDB v2 organization")
+	flag.StringVar(&cfg.InfluxBucket, "influx-bucket", "", "InfluxDB v2 bucket")
+	flag.StringVar(&cfg.InfluxToken, "influx-token", cfg.InfluxToken, "InfluxDB v2 API token (env OCI_AGENT_INFLUX_TOKEN)")
+	flag.StringVar(&cfg.MQTTBroker, "mqtt-broker", "", "publish metrics and heartbeats to this MQTT broker, e.g. tcp://localhost:1883 or ssl://broker:8883 (TLS uses the -tls-* settings)")
+	flag.StringVar(&cfg.MQTTTopicPrefix, "mqtt-topic-prefix", cfg.MQTTTopicPrefix, "MQTT topic prefix; messages go to <prefix>/<instance_id>/metrics and .../heartbeat")
+	flag.IntVar(&cfg.MQTTQoS, "mqtt-qos", cfg.MQTTQoS, "MQTT QoS level: 0, 1 or 2")
+	flag.BoolVar(&cfg.MQTTRetain, "mqtt-retain", cfg.MQTTRetain, "publish MQTT messages as retained so new subscribers get the latest values")
+	flag.StringVar(&cfg.MQTTClientID, "mqtt-client-id", "", "MQTT client id (default oci-agent-<instance_id>)")
+	flag.StringVar(&cfg.MQTTUsername, "mqtt-username", "", "MQTT username")
+	flag.StringVar(&cfg.MQTTPassword, "mqtt-password", cfg.MQTTPassword, "MQTT password (env OCI_AGENT_MQTT_PASSWORD)")
+	flag.StringVar(&cfg.WebSocketURL, "ws-url", "", "keep one WebSocket connection to this ws:// or wss:// URL carrying {\"channel\",\"data\"} frames: metrics, heartbeat, and task/task_result for commands, terminal for -terminal-allow")
+	flag.StringVar(&cfg.UpdateURL, "update-url", "", "check this release endpoint for newer agent versions and replace the binary in place; either a GitHub releases API URL such as https://api.github.com/repos/OWNER/REPO/releases/latest or a control server manifest")
+	flag.DurationVar(&cfg.UpdateInterval, "update-interval", cfg.UpdateInterval, "how often -update-url is checked")
+	flag.StringVar(&cfg.UpdatePublicKey, "update-public-key", "", "base64 ed25519 public key; when set, updates must carry a valid signature in addition to the sha256 checksum")
+	flag.StringVar(&cfg.TaskURL, "task-url", "", "poll this URL for tasks from the control server (GET, {\"tasks\":[...]}) and POST each result back to it")
+	flag.DurationVar(&cfg.TaskPollInterval, "task-poll-interval", cfg.TaskPollInterval, "how often -task-url is polled")
+	flag.DurationVar(&cfg.TaskTimeout, "task-timeout", cfg.TaskTimeout, "kill a task that runs longer than this")
+	flag.Var(kvFlag(cfg.Jobs), "job", "run a local scheduled job, as name=SCHEDULE COMMAND with a crontab schedule, e.g. \"certbot=0 3 * * * certbot renew\" or \"prune=@every 6h docker system prune -f\"; outcomes are reported under jobs and job_results, failures emit job_failed (repeatable)")
+	flag.DurationVar(&cfg.JobTimeout, "job-timeout", cfg.JobTimeout, "kill a -job that runs longer than this")
+	flag.Var((*stringsFlag)(&cfg.AlertRules), "alert", "local alert rule as \"NAME: FIELD OP VALUE [for DURATION] [severity info|warning|critical]\", e.g. \"cpu_high: cpu.percent > 90 for 5m\" or \"load: load_average.1min > 2xcores\"; FIELD accepts * like -convert (repeatable)")
+	flag.Var((*stringsFlag)(&cfg.NotifyWebhooks), "notify-webhook", "POST alert events as JSON to this URL (repeatable)")
+	flag.StringVar(&cfg.TelegramToken, "notify-telegram-token", cfg.TelegramToken, "Telegram bot token for alert messages, used with -notify-telegram-chat (env OCI_AGENT_TELEGRAM_TOKEN)")
+	flag.StringVar(&cfg.TelegramChatID, "notify-telegram-chat", "", "Telegram chat id that receives alert messages")
+	flag.StringVar(&cfg.BarkURL, "notify-bark", "", "Bark push URL for alerts, e.g. https://api.day.app/KEY")
+	flag.StringVar(&cfg.ServerChanKey, "notify-serverchan", cfg.ServerChanKey, "ServerChan SendKey for alert pushes (env OCI_AGENT_SERVERCHAN_KEY)")
+	flag.StringVar(&cfg.NotifyTemplate, "notify-template", defaultNotifyTemplate, "Go text/template for alert messages; fields: Rule, Severity, Status, Metric, Value, Threshold, Condition, From, To, Hostname, AgentID, StartedAt, At")
+	flag.Var((*stringsFlag)(&cfg.TaskAllow), "task-allow", "allow a task as type[=target]: reboot, restart_service=nginx, run_script=/usr/local/bin/backup.sh, speedtest; a missing target allows any (repeatable, nothing is allowed by default)")
+	flag.Var((*stringsFlag)(&cfg.TerminalAllow), "terminal-allow", "let this control server user open a web terminal (a login shell on a PTY) over a wss:// -ws-url, or * for any signed-in user; open messages must be signed with -signing-secret; the terminal is off unless set (repeatable, Linux only)")
+	flag.StringVar(&cfg.TerminalShell, "terminal-shell", "", "shell started for web terminals (default $SHELL, /bin/bash or /bin/sh)")
+	flag.DurationVar(&cfg.TerminalIdleTimeout, "terminal-idle-timeout", cfg.TerminalIdleTimeout, "close a web terminal that has had no input for this long")
+	flag.StringVar(&cfg.TerminalAuditLog, "terminal-audit-log", "", "append a JSON line to this file whenever a web terminal is opened or closed, with the user, duration and bytes transferred")
+	flag.String("config", "", "YAML config file whose keys are these flag names (env OCI_AGENT_CONFIG, default "+defaultConfigFile+" if present)")
+
+	flag.Usage = usage
+	cmd, args, err := splitSubcommand(os.Args[1:])
+	if err != nil {
+		return err
+	}
+	subcommand, subcommandArgs = cmd, args
+
+	if path, explicit := configFilePath(args); path != "" {
+		if err := applyConfigFile(path); err != nil && (explicit || !os.IsNotExist(err)) {
+			return fmt.Errorf("config: %w", err)
+		}
+	}
+	if err := applyEnv(); err != nil {
+		return err
+	}
+	flag.CommandLine.Parse(args)
+	switch subcommand {
+	case cmdOnce:
+		cfg.Once = true
+	case cmdVersion:
+		cfg.ShowVersion = true
+	}
+	if cfg.ShowVersion {
+		return nil
+	}
+
+	if err := setupLogger(); err != nil {
+		return err
+	}
+	applyHostPaths()
+
+	if cfg.Interval <= 0 {
+		return fmt.Errorf("interval must be greater than 0, got %s", cfg.Interval)
+	}
+	if cfg.HeartbeatInterval < 0 {
+		return fmt.Errorf("heartbeat-interval must not be negative, got %s", cfg.HeartbeatInterval)
+	}
+	if cfg.Jitter < 0 || cfg.Jitter >= 1 {
+		return fmt.Errorf("jitter must be at least 0 and below 1, got %v", cfg.Jitter)
+	}
+	if cfg.Splay < 0 {
+		return fmt.Errorf("splay must not be negative, got %s", cfg.Splay)
+	}
+	if cfg.SampleWindow <= 0 {
+		return fmt.Errorf("sample-window must be greater than 0, got %s", cfg.SampleWindow)
+	}
+	sampleWindow = cfg.SampleWindow
+	if cfg.CollectorTimeout <= cfg.SampleWindow {
+		return fmt.Errorf("collector-timeout (%s) must be longer than sample-window (%s)", cfg.CollectorTimeout, cfg.SampleWindow)
+	}
+	if cfg.RetryMaxAttempts < 1 {
+		return fmt.Errorf("retry-max-attempts must be at least 1, got %d", cfg.RetryMaxAttempts)
+	}
+	if cfg.RetryMaxBackoff <= 0 {
+		return fmt.Errorf("retry-max-backoff must be greater than 0, got %s", cfg.RetryMaxBackoff)
+	}
+	if cfg.HTTPTimeout <= 0 {
+		return fmt.Errorf("http-timeout must be greater than 0, got %s", cfg.HTTPTimeout)
+	}
+	tlsConf, err := loadTLSConfig(cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSCAFile, cfg.TLSServerName, cfg.TLSInsecureSkipVerify)
+	if err != nil {
+		return fmt.Errorf("tls: %w", err)
+	}
+	if cfg.TLSInsecureSkipVerify {
+		slog.Warn("TLS certificate verification is disabled", "component", "tls")
+	}
+	tlsConfig = tlsConf
+	if cfg.Proxy != "" {
+		if httpProxy, err = parseProxy(cfg.Proxy); err != nil {
+			return fmt.Errorf("proxy: %w", err)
+		}
+	}
+	if cfg.EncryptionKey != "" {
+		// 加密只作用于 HTTP 上报和心跳的请求体，其他通道会以明文发送同样的数据
+		for _, other := range []struct{ flag, value string }{
+			{"ws-url", cfg.WebSocketURL},
+			{"mqtt-broker", cfg.MQTTBroker},
+			{"grpc-addr", cfg.GRPCAddr},
+		} {
+			if other.value != "" {
+				return fmt.Errorf("encryption-key only applies to -report-url and -heartbeat-url, it cannot be combined with -%s; use TLS for that channel instead", other.flag)
+			}
+		}
+		key, err := parseEncryptionKey(cfg.EncryptionKey)
+		if err != nil {
+			return fmt.Errorf("encryption-key: %w", err)
+		}
+		if payloadCipher, err = newPayloadCipher(key); err != nil {
+			return fmt.Errorf("encryption-key: %w", err)
+		}
+	}
+	httpClient = newHTTPClient(cfg.HTTPTimeout, tlsConfig)
+	notifyClient = newHTTPClient(10*time.Second, nil)
+	if cfg.SMARTInterval <= 0 {
+		return fmt.Errorf("smart-interval must be greater than 0, got %s", cfg.SMARTInterval)
+	}
+	if cfg.PprofAddr != "" {
+		if err := checkLoopbackAddr(cfg.PprofAddr); err != nil {
+			return fmt.Errorf("pprof-listen: %w", err)
+		}
+	}
+	if cfg.NTPInterval <= 0 {
+		return fmt.Errorf("ntp-interval must be greater than 0, got %s", cfg.NTPInterval)
+	}
+	if cfg.KubernetesRefresh <= 0 {
+		return fmt.Errorf("kubernetes-refresh must be greater than 0, got %s", cfg.KubernetesRefresh)
+	}
+	if cfg.OCIMetadataRefresh <= 0 {
+		return fmt.Errorf("oci-metadata-refresh must be greater than 0, got %s", cfg.OCIMetadataRefresh)
+	}
+	if cfg.TrafficResetDay < 1 || cfg.TrafficResetDay > 31 {
+		return fmt.Errorf("traffic-reset-day must be between 1 and 31, got %d", cfg.TrafficResetDay)
+	}
+	switch cfg.TrafficQuotaDirection {
+	case "sent", "recv", "both":
+	default:
+		return fmt.Errorf("traffic-quota-direction: unknown direction %q, expected sent, recv or both", cfg.TrafficQuotaDirection)
+	}
+	cfg.TrafficQuota = 0
+	if cfg.TrafficQuotaSize != "" {
+		quota, err := parseByteSize(cfg.TrafficQuotaSize)
+		if err != nil {
+			return fmt.Errorf("traffic-quota: %w", err)
+		}
+		cfg.TrafficQuota = quota
+	}
+	if cfg.ProbeInterval <= 0 || cfg.ProbeTimeout <= 0 {
+		return fmt.Errorf("probe-interval and probe-timeout must be greater than 0")
+	}
+	if cfg.ProbeCount < 1 {
+		return fmt.Errorf("probe-count must be at least 1, got %d", cfg.ProbeCount)
+	}
+	if cfg.SpeedtestDuration <= 0 || cfg.SpeedtestMaxBytes <= 0 {
+		return fmt.Errorf("speedtest-duration and speedtest-max-bytes must be greater than 0")
+	}
+	if cfg.SpeedtestInterval < 0 || cfg.SpeedtestInterval > 0 && cfg.SpeedtestInterval < speedtestCooldown {
+		return fmt.Errorf("speedtest-interval must be 0 or at least %s, got %s", speedtestCooldown, cfg.SpeedtestInterval)
+	}
+	if cfg.SpeedtestInterval > 0 && cfg.SpeedtestURL == "" {
+		return fmt.Errorf("speedtest-interval requires -speedtest-url")
+	}
+	if cfg.CheckInterval <= 0 || cfg.CheckTimeout <= 0 || cfg.CheckFailures < 1 {
+		return fmt.Errorf("check-interval and check-timeout must be greater than 0 and check-failures at least 1")
+	}
+	if serviceChecks, err = compileServiceChecks(cfg.Checks, cfg.CheckStatuses); err != nil {
+		return err
+	}
+	targets, err := compileProbeTargets(cfg.Probes)
+	if err != nil {
+		return fmt.Errorf("probe: %w", err)
+	}
+	probeTargets = targets
+	if pluginList, err = compilePlugins(cfg.Plugins, cfg.PluginTimeouts, cfg.PluginIntervals, cfg.Interval); err != nil {
+		return err
+	}
+	if logWatches.watches, err = compileLogWatches(cfg.LogWatches, cfg.LogMatches); err != nil {
+		return err
+	}
+	if logWatches.enabled() && (cfg.LogWatchInterval <= 0 || cfg.LogWatchMaxLines < 1) {
+		return fmt.Errorf("log-watch-interval must be greater than 0 and log-watch-max-lines at least 1")
+	}
+	if cfg.MQTTQoS < 0 || cfg.MQTTQoS > 2 {
+		return fmt.Errorf("mqtt-qos must be 0, 1 or 2, got %d", cfg.MQTTQoS)
+	}
+	if cfg.HistoryWindow < 0 || cfg.HistoryWindow > 0 && (cfg.HistoryResolution <= 0 || cfg.HistoryResolution > cfg.HistoryWindow) {
+		return fmt.Errorf("history-resolution must be between 0 and history-window")
+	}
+	if cfg.HistoryWindow > 0 {
+		history = newMetricHistory(cfg.HistoryMetrics, cfg.HistoryWindow, cfg.HistoryResolution)
+	}
+	if cfg.QueueSize < 1 {
+		return fmt.Errorf("queue-size must be at least 1, got %d", cfg.QueueSize)
+	}
+	if cfg.SpoolMaxBytes <= 0 {
+		return fmt.Errorf("spool-max-bytes must be greater than 0, got %d", cfg.SpoolMaxBytes)
+	}
+	seenURLs := map[string]bool{}
+	for _, u := range cfg.ReportURLs {
+		if seenURLs[u] {
+			return fmt.Errorf("report-url: %s is listed twice", u)
+		}
+		seenURLs[u] = true
+	}
+
+	for label, path := range cfg.RawFiles {
+		if err := validateRawFilePath(path); err != nil {
+			return fmt.Errorf("raw-file %s: %w", label, err)
+		}
+	}
+	conversions, err := compileUnitConversions(cfg.UnitConversions)
+	if err != nil {
+		return fmt.Errorf("convert: %w", err)
+	}
+	unitConversions = conversions
+	thresholds, err := compileTempThresholds(cfg.TempWarn)
+	if err != nil {
+		return fmt.Errorf("temp-warn: %w", err)
+	}
+	tempThresholds = thresholds
+
+	for _, name := range cfg.DisabledCollectors {
+		if !isOptionalCollector(name) {
+			return fmt.Errorf("disable-collector: unknown collector %q", name)
+		}
+	}
+	schedules, err := compileCollectorSchedules(cfg.CollectorSchedules)
+	if err != nil {
+		return fmt.Errorf("collector-schedule: %w", err)
+	}
+	collectorSchedules = schedules
+
+	if cfg.NezhaServer != "" && cfg.NezhaSecret == "" {
+		return fmt.Errorf("nezha-server requires -nezha-secret")
+	}
+	if cfg.TaskPollInterval <= 0 {
+		return fmt.Errorf("task-poll-interval must be greater than 0, got %s", cfg.TaskPollInterval)
+	}
+	if cfg.TaskTimeout <= 0 {
+		return fmt.Errorf("task-timeout must be greater than 0, got %s", cfg.TaskTimeout)
+	}
+	allow, err := compileTaskAllowlist(cfg.TaskAllow)
+	if err != nil {
+		return fmt.Errorf("task-allow: %w", err)
+	}
+	taskAllowlist = allow
+	if jobList, err = compileJobs(cfg.Jobs); err != nil {
+		return err
+	}
+	if cfg.JobTimeout <= 0 {
+		return fmt.Errorf("job-timeout must be greater than 0, got %s", cfg.JobTimeout)
+	}
+	jobs.timeout = cfg.JobTimeout
+	if len(cfg.TerminalAllow) > 0 {
+		// 终端的输入输出就是 shell 会话本身，只允许走 TLS，并且 open 必须由控制端签名
+		if !strings.HasPrefix(strings.ToLower(cfg.WebSocketURL), "wss://") {
+			return fmt.Errorf("terminal-allow requires a wss:// -ws-url")
+		}
+		if cfg.SigningSecret == "" {
+			return fmt.Errorf("terminal-allow requires -signing-secret to verify terminal open messages")
+		}
+	}
+	if cfg.TerminalIdleTimeout <= 0 {
+		return fmt.Errorf("terminal-idle-timeout must be greater than 0, got %s", cfg.TerminalIdleTimeout)
+	}
+	taskExec = newTaskRunner(cfg.TaskURL, cfg.TaskTimeout)
+
+	rules, err := compileAlertRules(cfg.AlertRules)
+	if err != nil {
+		return fmt.Errorf("alert: %w", err)
+	}
+	alerts.setRules(rules)
+	tmpl, err := template.New("notify").Parse(cfg.NotifyTemplate)
+	if err != nil {
+		return fmt.Errorf("notify-template: %w", err)
+	}
+	notifyTemplate = tmpl
+	if notifiers, err = buildNotifiers(); err != nil {
+		return err
+	}
+	selfUpdate = nil
+	if cfg.UpdateURL != "" {
+		if cfg.UpdateInterval <= 0 {
+			return fmt.Errorf("update-interval must be greater than 0, got %s", cfg.UpdateInterval)
+		}
+		if selfUpdate, err = newUpdater(cfg.UpdateURL, cfg.UpdatePublicKey); err != nil {
+			return fmt.Errorf("update-url: %w", err)
+		}
+	}
+
+	switch cfg.ByteUnits {
+	case byteUnitsLegacy, byteUnitsIEC, byteUnitsSI:
+		byteUnits = cfg.ByteUnits
+	default:
+		return fmt.Errorf("byte-units: unknown mode %q, expected legacy, iec or si", cfg.ByteUnits)
+	}
+
+	if cfg.Format != "json" && cfg.Format != "summary" {
+		return fmt.Errorf("format: unknown format %q, expected json or summary", cfg.Format)
+	}
+	if err := validateSummaryFields(summaryFields()); err != nil {
+		return fmt.Errorf("summary-fields: %w", err)
+	}
+	return nil
+}
+
+func summaryFields() []string {
+	var fields []string
+	for _, f := range strings.Split(cfg.SummaryFields, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
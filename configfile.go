@@ -0,0 +1,113 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultConfigFile = "/etc/oci-agent/config.yaml"
+
+// configFilePath 在解析参数之前找出配置文件路径：-config 参数优先，其次 OCI_AGENT_CONFIG，
+// 都没有时默认文件存在才使用
+func configFilePath(args []string) (path string, explicit bool) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		name := strings.TrimLeft(arg, "-")
+		if name == arg {
+			continue
+		}
+		if name == "config" && i+1 < len(args) {
+			return args[i+1], true
+		}
+		if strings.HasPrefix(name, "config=") {
+			return strings.TrimPrefix(name, "config="), true
+		}
+	}
+	if v := os.Getenv("OCI_AGENT_CONFIG"); v != "" {
+		return v, true
+	}
+	if _, err := os.Stat(defaultConfigFile); err == nil {
+		return defaultConfigFile, false
+	}
+	return "", false
+}
+
+// applyConfigFile 读取 YAML 配置，键名与命令行参数同名，例如 report-url: https://...
+// 列表对应可重复的参数，映射对应 key=value 形式的参数
+func applyConfigFile(path string) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(content, &values); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		f := flag.Lookup(key)
+		if f == nil || key == "config" {
+			return fmt.Errorf("%s: unknown setting %q", path, key)
+		}
+		if err := setFlagFromConfig(f, values[key]); err != nil {
+			return fmt.Errorf("%s: %s: %w", path, key, err)
+		}
+	}
+	return nil
+}
+
+func setFlagFromConfig(f *flag.Flag, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fmt.Sprint(item)
+		}
+		// listFlag 每次 Set 整体替换，需要一次传入全部元素
+		if _, ok := f.Value.(*listFlag); ok {
+			return f.Value.Set(strings.Join(items, ","))
+		}
+		for _, item := range items {
+			if err := f.Value.Set(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			items := []interface{}{v[k]}
+			if list, ok := v[k].([]interface{}); ok {
+				items = list
+			}
+			for _, item := range items {
+				if err := f.Value.Set(fmt.Sprintf("%s=%v", k, item)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	default:
+		return f.Value.Set(fmt.Sprint(v))
+	}
+}
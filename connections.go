@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	psnet "github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// listener 是一个监听中的端口，同一地址被多个进程（如 nginx worker）共享时只保留一条
+type listener struct {
+	proto   string
+	address string
+	port    uint32
+	pid     int32
+}
+
+// isPublicBind 判断监听地址是否可能从外部访问：通配地址或非回环地址
+func isPublicBind(address string) bool {
+	ip := net.ParseIP(address)
+	return ip == nil || !ip.IsLoopback()
+}
+
+// readConntrack 读取 nf_conntrack 的当前条目数和上限，未加载 nf_conntrack 模块时返回 nil
+func readConntrack() map[string]interface{} {
+	read := func(name string) (uint64, bool) {
+		content, err := ioutil.ReadFile(hostProc("sys/net/netfilter", name))
+		if err != nil {
+			return 0, false
+		}
+		v, err := strconv.ParseUint(strings.TrimSpace(string(content)), 10, 64)
+		return v, err == nil
+	}
+	count, ok1 := read("nf_conntrack_count")
+	max, ok2 := read("nf_conntrack_max")
+	if !ok1 || !ok2 {
+		return nil
+	}
+	return map[string]interface{}{"count": count, "max": max, "percent": percentOf(count, max)}
+}
+
+// getConnections 统计 TCP 各状态的连接数和 UDP socket 数，列出监听端口及其进程；
+// 查看其他用户进程的归属需要 root，否则 process 为空
+func getConnections() map[string]interface{} {
+	conns, err := psnet.ConnectionsWithoutUids("inet")
+	if err != nil {
+		return nil
+	}
+	// 常用状态即使为 0 也输出，便于 -alert 引用
+	tcpStates := map[string]interface{}{"established": 0, "time_wait": 0, "listen": 0, "syn_recv": 0, "close_wait": 0}
+	udp := 0
+	seen := map[string]bool{}
+	var listeners []listener
+	for _, c := range conns {
+		proto := "tcp"
+		if c.Type == syscall.SOCK_DGRAM {
+			proto = "udp"
+		}
+		if c.Family == syscall.AF_INET6 {
+			proto += "6"
+		}
+		isListener := false
+		if c.Type == syscall.SOCK_STREAM {
+			state := strings.ToLower(c.Status)
+			n, _ := tcpStates[state].(int)
+			tcpStates[state] = n + 1
+			isListener = c.Status == "LISTEN"
+		} else {
+			udp++
+			// 没有对端地址的 UDP socket 视为监听
+			isListener = c.Raddr.IP == "" || c.Raddr.Port == 0
+		}
+		if !isListener {
+			continue
+		}
+		key := fmt.Sprintf("%s %s:%d", proto, c.Laddr.IP, c.Laddr.Port)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		listeners = append(listeners, listener{proto, c.Laddr.IP, c.Laddr.Port, c.Pid})
+	}
+	sort.Slice(listeners, func(i, j int) bool {
+		if listeners[i].port != listeners[j].port {
+			return listeners[i].port < listeners[j].port
+		}
+		if listeners[i].proto != listeners[j].proto {
+			return listeners[i].proto < listeners[j].proto
+		}
+		return listeners[i].address < listeners[j].address
+	})
+
+	names := map[int32]string{}
+	listening := make([]interface{}, 0, len(listeners))
+	public := 0
+	for _, l := range listeners {
+		entry := map[string]interface{}{"proto": l.proto, "address": l.address, "port": l.port}
+		if l.pid > 0 {
+			name, ok := names[l.pid]
+			if !ok {
+				if p, err := process.NewProcess(l.pid); err == nil {
+					name, _ = p.Name()
+				}
+				names[l.pid] = name
+			}
+			entry["pid"] = l.pid
+			if name != "" {
+				entry["process"] = name
+			}
+		}
+		if isPublicBind(l.address) {
+			entry["public"] = true
+			public++
+		}
+		listening = append(listening, entry)
+	}
+
+	section := map[string]interface{}{
+		"tcp":              tcpStates,
+		"udp_sockets":      udp,
+		"listening":        listening,
+		"public_listeners": public,
+	}
+	if ct := readConntrack(); ct != nil {
+		section["conntrack"] = ct
+	}
+	return section
+}
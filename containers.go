@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Docker Engine API 的最小客户端，通过 unix socket 访问，Podman 的兼容 socket 同样可用；
+// containerd 原生只提供 gRPC 接口，由其托管的 Docker 容器同样通过这里获取
+func dockerClient(socket string) *http.Client {
+	return &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+}
+
+func dockerGet(client *http.Client, path string, v interface{}) error {
+	resp, err := client.Get("http://docker" + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("docker %s returned %d", path, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+type dockerContainer struct {
+	ID     string   `json:"Id"`
+	Names  []string `json:"Names"`
+	Image  string   `json:"Image"`
+	State  string   `json:"State"`
+	Status string   `json:"Status"`
+}
+
+type dockerCPUStats struct {
+	CPUUsage struct {
+		TotalUsage uint64 `json:"total_usage"`
+	} `json:"cpu_usage"`
+	SystemUsage uint64 `json:"system_cpu_usage"`
+	OnlineCPUs  uint64 `json:"online_cpus"`
+}
+
+type dockerStats struct {
+	CPUStats    dockerCPUStats `json:"cpu_stats"`
+	PreCPUStats dockerCPUStats `json:"precpu_stats"`
+	MemoryStats struct {
+		Usage uint64            `json:"usage"`
+		Limit uint64            `json:"limit"`
+		Stats map[string]uint64 `json:"stats"`
+	} `json:"memory_stats"`
+	Networks map[string]struct {
+		RxBytes uint64 `json:"rx_bytes"`
+		TxBytes uint64 `json:"tx_bytes"`
+	} `json:"networks"`
+}
+
+// cpuPercent 与 docker stats 的算法一致：容器 CPU 增量占整机增量的比例乘以 CPU 数
+func (s *dockerStats) cpuPercent() float64 {
+	cpuDelta := float64(s.CPUStats.CPUUsage.TotalUsage) - float64(s.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(s.CPUStats.SystemUsage) - float64(s.PreCPUStats.SystemUsage)
+	if cpuDelta <= 0 || systemDelta <= 0 {
+		return 0
+	}
+	cpus := float64(s.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = 1
+	}
+	return cpuDelta / systemDelta * cpus * 100
+}
+
+// memoryUsed 扣除页缓存，cgroup v2 为 inactive_file，v1 为 total_inactive_file 或 cache
+func (s *dockerStats) memoryUsed() uint64 {
+	usage := s.MemoryStats.Usage
+	for _, key := range []string{"inactive_file", "total_inactive_file", "cache"} {
+		if v, ok := s.MemoryStats.Stats[key]; ok {
+			if v < usage {
+				return usage - v
+			}
+			return usage
+		}
+	}
+	return usage
+}
+
+// getContainers 列出运行中的容器及其资源使用，socket 不存在时返回 nil 不输出该字段
+func getContainers(socket string) map[string]interface{} {
+	if _, err := os.Stat(socket); err != nil {
+		return nil
+	}
+	client := dockerClient(socket)
+	var list []dockerContainer
+	if err := dockerGet(client, "/containers/json", &list); err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+
+	var (
+		mu         sync.Mutex
+		wg         sync.WaitGroup
+		containers = make(map[string]interface{}, len(list))
+	)
+	for _, c := range list {
+		c := c
+		name := c.ID
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		entry := map[string]interface{}{
+			"id":     c.ID[:min(12, len(c.ID))],
+			"image":  c.Image,
+			"state":  c.State,
+			"status": c.Status,
+		}
+		wg.Add(1)
+		// stream=false 时 Docker 会等待一个采样周期填充 precpu_stats，各容器并发获取
+		go func() {
+			defer wg.Done()
+			var stats dockerStats
+			if err := dockerGet(client, "/containers/"+c.ID+"/stats?stream=false", &stats); err == nil {
+				var rx, tx uint64
+				for _, n := range stats.Networks {
+					rx += n.RxBytes
+					tx += n.TxBytes
+				}
+				entry["cpu_percent"] = math.Round(stats.cpuPercent()*100) / 100
+				entry["mem_used_bytes"] = stats.memoryUsed()
+				entry["mem_limit_bytes"] = stats.MemoryStats.Limit
+				entry["net_rx_bytes"] = rx
+				entry["net_tx_bytes"] = tx
+			}
+			mu.Lock()
+			containers[name] = entry
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return containers
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+)
+
+// cpuTimesTotal 与 gopsutil 的 Percent 使用相同的口径，guest 时间已包含在 user 中，不重复计入
+func cpuTimesTotal(t cpu.TimesStat) float64 {
+	return t.User + t.System + t.Idle + t.Nice + t.Iowait + t.Irq + t.Softirq + t.Steal
+}
+
+// cpuBreakdown 根据两次 cpu.Times 的差值计算各类时间占比，percent 为除 idle 和 iowait 外的忙碌占比
+func cpuBreakdown(before, after cpu.TimesStat) map[string]interface{} {
+	total := cpuTimesTotal(after) - cpuTimesTotal(before)
+	pct := func(a, b float64) float64 {
+		if total <= 0 || b < a {
+			return 0
+		}
+		return math.Round((b-a)/total*10000) / 100
+	}
+	idle := pct(before.Idle, after.Idle)
+	iowait := pct(before.Iowait, after.Iowait)
+	busy := 0.0
+	if total > 0 {
+		busy = math.Max(0, math.Round((100-idle-iowait)*100)/100)
+	}
+	return map[string]interface{}{
+		"percent": busy,
+		"user":    pct(before.User, after.User),
+		"system":  pct(before.System, after.System),
+		"nice":    pct(before.Nice, after.Nice),
+		"idle":    idle,
+		"iowait":  iowait,
+		"irq":     pct(before.Irq, after.Irq),
+		"softirq": pct(before.Softirq, after.Softirq),
+		// steal 是被宿主机上其他虚拟机占用的时间，超售的 AMD micro 规格上尤其明显
+		"steal": pct(before.Steal, after.Steal),
+	}
+}
+
+// sampleCPUTimes 在 interval 内采样汇总和各核的 CPU 时间；平台不支持按核读取时 cores 为空
+func sampleCPUTimes(interval time.Duration) (aggregate map[string]interface{}, cores map[string]interface{}, err error) {
+	totalBefore, err := cpu.Times(false)
+	if err != nil || len(totalBefore) == 0 {
+		return nil, nil, err
+	}
+	coresBefore, coreErr := cpu.Times(true)
+	time.Sleep(interval)
+	totalAfter, err := cpu.Times(false)
+	if err != nil || len(totalAfter) == 0 {
+		return nil, nil, err
+	}
+	aggregate = cpuBreakdown(totalBefore[0], totalAfter[0])
+	if coreErr != nil {
+		return aggregate, nil, nil
+	}
+	coresAfter, err := cpu.Times(true)
+	if err != nil {
+		return aggregate, nil, nil
+	}
+	previous := make(map[string]cpu.TimesStat, len(coresBefore))
+	for _, t := range coresBefore {
+		previous[t.CPU] = t
+	}
+	cores = make(map[string]interface{}, len(coresAfter))
+	for _, t := range coresAfter {
+		if p, ok := previous[t.CPU]; ok {
+			b := cpuBreakdown(p, t)
+			cores[t.CPU] = map[string]interface{}{
+				"percent": b["percent"],
+				"user":    b["user"],
+				"system":  b["system"],
+				"iowait":  b["iowait"],
+				"steal":   b["steal"],
+			}
+		}
+	}
+	return aggregate, cores, nil
+}
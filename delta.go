@@ -0,0 +1,75 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// staticFields 是几乎不会变化的字段，delta 模式下只在首次上报、发生变化或定期全量时发送
+var staticFields = []string{
+	"hostname", "architecture", "platform", "platform_version", "distribution", "virtualization",
+	"cpu.model", "cpu.count",
+	"memory.total", "memory.total_bytes",
+	"swap.total", "swap.total_bytes",
+	"disk.total", "disk.total_bytes",
+	"addresses",
+}
+
+// deltaState 按目的地记录上次全量发送的静态字段，每个 -report-url 各有一份
+type deltaState struct {
+	mu       sync.Mutex
+	sent     map[string]interface{}
+	lastFull time.Time
+}
+
+func lookupPath(data map[string]interface{}, path string) (interface{}, bool) {
+	parts := strings.Split(path, ".")
+	for _, part := range parts[:len(parts)-1] {
+		sub, ok := data[part].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		data = sub
+	}
+	v, ok := data[parts[len(parts)-1]]
+	return v, ok
+}
+
+func deletePath(data map[string]interface{}, path string) {
+	parts := strings.Split(path, ".")
+	for _, part := range parts[:len(parts)-1] {
+		sub, ok := data[part].(map[string]interface{})
+		if !ok {
+			return
+		}
+		data = sub
+	}
+	delete(data, parts[len(parts)-1])
+}
+
+// apply 返回本次应发送的数据：静态字段与上次全量一致时去掉它们并标记 "delta": true。
+// forceFull 用于上一次投递失败的情况，服务端可能没收到全量数据，需要重新发送
+func (d *deltaState) apply(data map[string]interface{}, now time.Time, forceFull bool) map[string]interface{} {
+	current := make(map[string]interface{}, len(staticFields))
+	for _, path := range staticFields {
+		if v, ok := lookupPath(data, path); ok {
+			current[path] = v
+		}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	full := forceFull || d.sent == nil || !reflect.DeepEqual(current, d.sent) ||
+		(cfg.DeltaFullInterval > 0 && now.Sub(d.lastFull) >= cfg.DeltaFullInterval)
+	if full {
+		d.sent, d.lastFull = current, now
+		return data
+	}
+	out := copyPayload(data)
+	for path := range current {
+		deletePath(out, path)
+	}
+	out["delta"] = true
+	return out
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+type diskIORates struct {
+	readSpeed, writeSpeed float64
+	readIOPS, writeIOPS   float64
+	utilPercent           float64 // 设备忙碌时间占采样间隔的比例
+	awaitMs               float64 // 每次 I/O 的平均等待时间（含排队）
+}
+
+func (r diskIORates) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"read_speed":                formatBytes(uint64(r.readSpeed)),
+		"write_speed":               formatBytes(uint64(r.writeSpeed)),
+		"read_speed_bytes_per_sec":  uint64(r.readSpeed),
+		"write_speed_bytes_per_sec": uint64(r.writeSpeed),
+		"read_iops":                 math.Round(r.readIOPS*100) / 100,
+		"write_iops":                math.Round(r.writeIOPS*100) / 100,
+		"util_percent":              math.Round(r.utilPercent*100) / 100,
+		"await_ms":                  math.Round(r.awaitMs*100) / 100,
+	}
+}
+
+// isWholeDisk 只统计整块磁盘，分区的计数已包含在所属磁盘中，汇总时计入会重复；
+// 没有 /sys/block 的平台上 IOCounters 本身只返回磁盘
+func isWholeDisk(name string) bool {
+	for _, prefix := range virtualBlockPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return false
+		}
+	}
+	if _, err := os.Stat(hostSys("block")); err != nil {
+		return true
+	}
+	_, err := os.Stat(hostSys("block", name))
+	return err == nil
+}
+
+// computeDiskIORates 根据前后两次计数器计算各磁盘及汇总的吞吐、IOPS、利用率和平均等待，
+// 汇总的利用率取各磁盘的最大值，更能反映是否有卷已经饱和
+func computeDiskIORates(before, after map[string]disk.IOCountersStat, interval time.Duration) (aggregate diskIORates, perDevice map[string]diskIORates) {
+	perDevice = make(map[string]diskIORates, len(after))
+	var ops, waitMs float64
+	for name, c := range after {
+		p, ok := before[name]
+		if !ok || !isWholeDisk(name) {
+			continue
+		}
+		r := diskIORates{
+			readSpeed:  counterRate(p.ReadBytes, c.ReadBytes, interval),
+			writeSpeed: counterRate(p.WriteBytes, c.WriteBytes, interval),
+			readIOPS:   counterRate(p.ReadCount, c.ReadCount, interval),
+			writeIOPS:  counterRate(p.WriteCount, c.WriteCount, interval),
+		}
+		if interval > 0 && c.IoTime >= p.IoTime {
+			r.utilPercent = math.Min(float64(c.IoTime-p.IoTime)/float64(interval.Milliseconds())*100, 100)
+		}
+		if n := (r.readIOPS + r.writeIOPS) * interval.Seconds(); n > 0 && c.ReadTime+c.WriteTime >= p.ReadTime+p.WriteTime {
+			wait := float64(c.ReadTime + c.WriteTime - p.ReadTime - p.WriteTime)
+			r.awaitMs = wait / n
+			ops += n
+			waitMs += wait
+		}
+		perDevice[name] = r
+
+		aggregate.readSpeed += r.readSpeed
+		aggregate.writeSpeed += r.writeSpeed
+		aggregate.readIOPS += r.readIOPS
+		aggregate.writeIOPS += r.writeIOPS
+		aggregate.utilPercent = math.Max(aggregate.utilPercent, r.utilPercent)
+	}
+	if ops > 0 {
+		aggregate.awaitMs = waitMs / ops
+	}
+	return aggregate, perDevice
+}
+
+// diskSampler 与 netSampler 一样由后台协程按采样窗口持续读取计数器，上报时直接取最近一次结果
+type diskSampler struct {
+	mu        sync.RWMutex
+	aggregate diskIORates
+	perDevice map[string]diskIORates
+	at        time.Time
+}
+
+var backgroundDisk = &diskSampler{}
+
+func (s *diskSampler) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	before, err := disk.IOCounters()
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		after, aerr := disk.IOCounters()
+		now := time.Now()
+		if aerr != nil {
+			slog.Debug("disk counters unavailable", "component", "disk-sampler", "err", aerr)
+			continue
+		}
+		if err == nil {
+			aggregate, perDevice := computeDiskIORates(before, after, now.Sub(last))
+			s.mu.Lock()
+			s.aggregate, s.perDevice, s.at = aggregate, perDevice, now
+			s.mu.Unlock()
+		}
+		before, err, last = after, nil, now
+	}
+}
+
+func (s *diskSampler) latest(maxAge time.Duration) (diskIORates, map[string]diskIORates, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.at.IsZero() || time.Since(s.at) > maxAge {
+		return diskIORates{}, nil, false
+	}
+	return s.aggregate, s.perDevice, true
+}
+
+// sampleDiskIO 后台采样器有新鲜数据时直接返回，否则（如 -once）现场采样一个间隔
+func sampleDiskIO(interval time.Duration) (aggregate diskIORates, perDevice map[string]diskIORates, err error) {
+	if aggregate, perDevice, ok := backgroundDisk.latest(2 * interval); ok {
+		return aggregate, perDevice, nil
+	}
+	before, err := disk.IOCounters()
+	if err != nil {
+		return aggregate, nil, err
+	}
+	start := time.Now()
+	time.Sleep(interval)
+	after, err := disk.IOCounters()
+	if err != nil {
+		return aggregate, nil, err
+	}
+	aggregate, perDevice = computeDiskIORates(before, after, time.Since(start))
+	return aggregate, perDevice, nil
+}
+
+// getDiskIO 返回 disk 段中的 io 字段：汇总值加上按磁盘展开的 devices
+func getDiskIO(interval time.Duration) map[string]interface{} {
+	aggregate, perDevice, err := sampleDiskIO(interval)
+	if err != nil {
+		slog.Debug("disk io unavailable", "component", "collector", "err", err)
+		return nil
+	}
+	devices := make(map[string]interface{}, len(perDevice))
+	for name, r := range perDevice {
+		devices[name] = r.toMap()
+	}
+	io := aggregate.toMap()
+	io["devices"] = devices
+	return io
+}
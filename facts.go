@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/host"
+)
+
+// hostFacts 缓存运行期间基本不变的主机信息和分区列表，每轮采集只读缓存；
+// 启动时采集一次，之后只在 SIGHUP、POST /refresh 或 -facts-refresh 到期时重新采集
+type hostFacts struct {
+	load       func() map[string]interface{} // 采集静态信息，测试中可替换以统计调用次数
+	mu         sync.Mutex
+	static     map[string]interface{}
+	parts      []disk.PartitionStat
+	partsErr   error
+	refreshing sync.Mutex // 保证同一时间只有一次重新采集
+}
+
+var facts = &hostFacts{load: loadStaticInfo}
+
+func loadStaticInfo() map[string]interface{} {
+	static := map[string]interface{}{
+		"platform":       runtime.GOOS,
+		"architecture":   runtime.GOARCH,
+		"agent_version":  version,
+		"distribution":   getOSVersion(),
+		"virtualization": getVirtualizationType(),
+		"cpu": map[string]interface{}{
+			"model": getCPUModel(),
+			"count": runtime.NumCPU(),
+		},
+	}
+	if _, _, platformVersion, err := host.PlatformInformation(); err == nil {
+		static["platform_version"] = platformVersion
+	} else {
+		slog.Debug("platform information unavailable", "component", "collector", "err", err)
+	}
+	if bootTime, err := host.BootTime(); err == nil {
+		static["boot_time"] = time.Unix(int64(bootTime), 0).Format("2006-01-02 15:04:05")
+	} else {
+		slog.Debug("boot time unavailable", "component", "collector", "err", err)
+	}
+	return static
+}
+
+// refresh 重新执行 lscpu、systemd-detect-virt 等并重新读取分区列表，采集期间仍返回旧的缓存
+func (f *hostFacts) refresh() {
+	f.refreshing.Lock()
+	defer f.refreshing.Unlock()
+	static := f.load()
+	parts, err := disk.Partitions(true) // true获取所有，包括逻辑分区
+	f.mu.Lock()
+	f.static, f.parts, f.partsErr = static, parts, err
+	f.mu.Unlock()
+}
+
+func (f *hostFacts) ensure() {
+	f.mu.Lock()
+	loaded := f.static != nil
+	f.mu.Unlock()
+	if !loaded {
+		f.refresh()
+	}
+}
+
+// collectStaticInfo 返回缓存的静态信息，调用方不能修改返回的 map
+func collectStaticInfo() map[string]interface{} {
+	facts.ensure()
+	facts.mu.Lock()
+	defer facts.mu.Unlock()
+	return facts.static
+}
+
+func (f *hostFacts) partitions() ([]disk.PartitionStat, error) {
+	f.ensure()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.parts, f.partsErr
+}
+
+// run 处理 SIGHUP 和定期刷新，interval 为 0 时只响应 SIGHUP
+func (f *hostFacts) run(ctx context.Context, interval time.Duration, hup <-chan os.Signal) {
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			slog.Info("refreshing host facts", "component", "facts", "trigger", "signal")
+		case <-tick:
+			slog.Debug("refreshing host facts", "component", "facts", "trigger", "interval")
+		}
+		f.refresh()
+	}
+}
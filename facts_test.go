@@ -0,0 +1,40 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestStaticInfoCollectedOnce(t *testing.T) {
+	savedFacts, savedWindow := facts, sampleWindow
+	defer func() { facts, sampleWindow = savedFacts, savedWindow }()
+	sampleWindow = 10 * time.Millisecond
+
+	// 替换掉 lscpu、systemd-detect-virt 等外部命令和 os-release 的读取，只统计调用次数
+	calls := 0
+	facts = &hostFacts{load: func() map[string]interface{} {
+		calls++
+		return map[string]interface{}{"architecture": runtime.GOARCH, "cpu": map[string]interface{}{"model": "test"}}
+	}}
+
+	for i := 0; i < 3; i++ {
+		info := getSystemInfo()
+		if info["architecture"] != runtime.GOARCH {
+			t.Fatalf("snapshot %d: architecture = %v, want %s", i, info["architecture"], runtime.GOARCH)
+		}
+		if _, ok := info["memory"]; !ok {
+			t.Fatalf("snapshot %d: dynamic memory section missing", i)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("static info collected %d times across 3 snapshots, want 1", calls)
+	}
+
+	// 显式刷新（SIGHUP、POST /refresh）才会重新采集
+	facts.refresh()
+	getSystemInfo()
+	if calls != 2 {
+		t.Errorf("static info collected %d times after refresh, want 2", calls)
+	}
+}
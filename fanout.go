@@ -0,0 +1,66 @@
+package main
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// fanout 把每次采集结果并发投递给所有 Reporter。每个目的地同一时间只有一次投递在进行，
+// 上一次还没返回（目标很慢或连接挂起）时跳过本次样本，不拖慢其他目的地和采集周期
+type fanout struct {
+	mu      sync.Mutex
+	busy    map[string]bool
+	pending sync.WaitGroup
+}
+
+var sinks = &fanout{busy: map[string]bool{}}
+
+func (f *fanout) acquire(name string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.busy[name] {
+		return false
+	}
+	f.busy[name] = true
+	return true
+}
+
+func (f *fanout) release(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.busy, name)
+}
+
+func (f *fanout) dispatch(reporters []Reporter, info map[string]interface{}) {
+	for _, r := range reporters {
+		name := r.Name()
+		if !f.acquire(name) {
+			slog.Warn("previous report still in flight, skipping sample", "component", "reporter", "url", name)
+			continue
+		}
+		f.pending.Add(1)
+		go func(r Reporter) {
+			defer f.pending.Done()
+			defer f.release(name)
+			err := r.Report(info)
+			if err != nil {
+				logReportError("reporter", name, err)
+			}
+			health.record(name, err)
+		}(r)
+	}
+}
+
+// wait 退出前等待进行中的投递，最多等待 timeout，之后再关闭各 Reporter
+func (f *fanout) wait(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		f.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
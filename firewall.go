@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// firewallRefresh 是重新读取防火墙规则的间隔，规则很少变动，而 Docker 主机上的 iptables-save 输出可能很长
+const firewallRefresh = time.Minute
+
+// parseUFWStatus 解析 ufw status verbose，规则表在 "--" 分隔线之后，每条规则一行
+func parseUFWStatus(out string) map[string]interface{} {
+	section := map[string]interface{}{"active": false}
+	rules, inTable := 0, false
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "Status:"):
+			section["active"] = strings.TrimSpace(strings.TrimPrefix(line, "Status:")) == "active"
+		case strings.HasPrefix(line, "Default:"):
+			// Default: deny (incoming), allow (outgoing), disabled (routed)
+			for _, part := range strings.Split(strings.TrimPrefix(line, "Default:"), ",") {
+				fields := strings.Fields(part)
+				if len(fields) == 2 {
+					section["default_"+strings.Trim(fields[1], "()")] = fields[0]
+				}
+			}
+		case strings.HasPrefix(line, "--"):
+			inTable = true
+		case inTable && line != "":
+			rules++
+		}
+	}
+	section["rules"] = rules
+	return section
+}
+
+// parseIPTablesSave 解析 iptables-save，只统计 filter 表内置链的默认策略，规则数包含所有表
+func parseIPTablesSave(out string) map[string]interface{} {
+	section := map[string]interface{}{}
+	table, rules := "", 0
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, "*"):
+			table = line[1:]
+		case strings.HasPrefix(line, "-A "):
+			rules++
+		case table == "filter" && strings.HasPrefix(line, ":"):
+			// :INPUT ACCEPT [0:0]，自定义链的策略为 -
+			fields := strings.Fields(line[1:])
+			if len(fields) >= 2 && fields[1] != "-" {
+				section[strings.ToLower(fields[0])+"_policy"] = fields[1]
+			}
+		}
+	}
+	section["rules"] = rules
+	return section
+}
+
+// parseNftRuleset 解析 nft -j list ruleset，input 钩子的默认策略取 filter 类型的基础链
+func parseNftRuleset(out []byte) (map[string]interface{}, error) {
+	var ruleset struct {
+		Nftables []struct {
+			Table *struct{} `json:"table"`
+			Chain *struct {
+				Type   string `json:"type"`
+				Hook   string `json:"hook"`
+				Policy string `json:"policy"`
+			} `json:"chain"`
+			Rule *struct{} `json:"rule"`
+		} `json:"nftables"`
+	}
+	if err := json.Unmarshal(out, &ruleset); err != nil {
+		return nil, err
+	}
+	tables, chains, rules := 0, 0, 0
+	section := map[string]interface{}{}
+	for _, obj := range ruleset.Nftables {
+		switch {
+		case obj.Table != nil:
+			tables++
+		case obj.Chain != nil:
+			chains++
+			if obj.Chain.Type == "filter" && obj.Chain.Hook != "" && obj.Chain.Policy != "" {
+				section[obj.Chain.Hook+"_policy"] = obj.Chain.Policy
+			}
+		case obj.Rule != nil:
+			rules++
+		}
+	}
+	section["tables"], section["chains"], section["rules"] = tables, chains, rules
+	return section, nil
+}
+
+// readFirewall 依次读取已安装的 ufw、iptables 和 nftables，都需要 root；命令不存在或执行失败时省略对应字段。
+// iptables-nft 的规则同时出现在 iptables 和 nftables 中
+func readFirewall() map[string]interface{} {
+	section := map[string]interface{}{}
+	if _, err := exec.LookPath("ufw"); err == nil {
+		if out, err := exec.Command("ufw", "status", "verbose").Output(); err == nil {
+			section["ufw"] = parseUFWStatus(string(out))
+		}
+	}
+	if _, err := exec.LookPath("iptables-save"); err == nil {
+		if out, err := exec.Command("iptables-save").Output(); err == nil {
+			section["iptables"] = parseIPTablesSave(string(out))
+		}
+	}
+	if _, err := exec.LookPath("nft"); err == nil {
+		if out, err := exec.Command("nft", "-j", "list", "ruleset").Output(); err == nil {
+			if nft, err := parseNftRuleset(out); err == nil {
+				section["nftables"] = nft
+			}
+		}
+	}
+	if len(section) == 0 {
+		return nil
+	}
+	return section
+}
+
+type firewallCache struct {
+	mu      sync.Mutex
+	fetched time.Time
+	data    map[string]interface{}
+}
+
+var firewallState = &firewallCache{}
+
+func (c *firewallCache) get(refresh time.Duration) map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fetched.IsZero() || time.Since(c.fetched) >= refresh {
+		c.data, c.fetched = readFirewall(), time.Now()
+	}
+	return c.data
+}
+
+// getFirewall 返回防火墙状态，未安装任何防火墙工具或没有权限时返回 nil
+func getFirewall() map[string]interface{} {
+	return firewallState.get(firewallRefresh)
+}
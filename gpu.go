@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"math"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// nvidiaQueryFields 是 nvidia-smi --query-gpu 的字段，顺序与 getNvidiaGPUs 的解析一致；显存单位为 MiB
+const nvidiaQueryFields = "index,name,uuid,utilization.gpu,memory.used,memory.total,temperature.gpu,power.draw,power.limit"
+
+// gpuFloat 解析 nvidia-smi 的数值，不支持的项输出为 [N/A] 或 [Not Supported]
+func gpuFloat(s string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return v, err == nil
+}
+
+// gpuMemoryFields 写入显存用量，格式与 memory 段一致
+func gpuMemoryFields(g map[string]interface{}, used, total uint64) {
+	g["memory_used"] = formatBytes(used)
+	g["memory_total"] = formatBytes(total)
+	g["memory_used_bytes"] = used
+	g["memory_total_bytes"] = total
+	g["memory_percent"] = percentOf(used, total)
+}
+
+// getNvidiaGPUs 调用 nvidia-smi 读取各 GPU 的状态，未安装驱动时返回 nil
+func getNvidiaGPUs() map[string]interface{} {
+	bin, err := exec.LookPath("nvidia-smi")
+	if err != nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, bin, "--query-gpu="+nvidiaQueryFields, "--format=csv,noheader,nounits").Output()
+	if err != nil {
+		return nil
+	}
+	r := csv.NewReader(strings.NewReader(string(out)))
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil
+	}
+	gpus := make(map[string]interface{})
+	for _, rec := range records {
+		if len(rec) != 9 {
+			continue
+		}
+		g := map[string]interface{}{"vendor": "nvidia", "name": rec[1], "uuid": rec[2]}
+		if v, ok := gpuFloat(rec[3]); ok {
+			g["utilization_percent"] = v
+		}
+		used, ok1 := gpuFloat(rec[4])
+		total, ok2 := gpuFloat(rec[5])
+		if ok1 && ok2 {
+			gpuMemoryFields(g, uint64(used)<<20, uint64(total)<<20)
+		}
+		if v, ok := gpuFloat(rec[6]); ok {
+			g["temperature_celsius"] = v
+		}
+		if v, ok := gpuFloat(rec[7]); ok {
+			g["power_watts"] = math.Round(v*100) / 100
+		}
+		if v, ok := gpuFloat(rec[8]); ok {
+			g["power_limit_watts"] = math.Round(v*100) / 100
+		}
+		gpus["gpu"+rec[0]] = g
+	}
+	return gpus
+}
+
+func readSysfsUint(path string) (uint64, bool) {
+	s, ok := readSysValue(path)
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(s, 10, 64)
+	return v, err == nil
+}
+
+// getAMDGPUs 从 amdgpu 驱动的 sysfs 读取各显卡状态，温度和功耗来自显卡自己的 hwmon
+func getAMDGPUs() map[string]interface{} {
+	cards, _ := filepath.Glob(hostSys("class/drm/card[0-9]*"))
+	gpus := make(map[string]interface{})
+	for _, card := range cards {
+		name := filepath.Base(card)
+		// card0-DP-1 之类是显示接口而不是显卡
+		if strings.Contains(name, "-") {
+			continue
+		}
+		dev := filepath.Join(card, "device")
+		if vendor, _ := readSysValue(filepath.Join(dev, "vendor")); vendor != "0x1002" {
+			continue
+		}
+		g := map[string]interface{}{"vendor": "amd", "name": "amdgpu"}
+		if product, _ := readSysValue(filepath.Join(dev, "product_name")); product != "" {
+			g["name"] = product
+		}
+		if v, ok := readSysfsUint(filepath.Join(dev, "gpu_busy_percent")); ok {
+			g["utilization_percent"] = float64(v)
+		}
+		used, ok1 := readSysfsUint(filepath.Join(dev, "mem_info_vram_used"))
+		total, ok2 := readSysfsUint(filepath.Join(dev, "mem_info_vram_total"))
+		if ok1 && ok2 {
+			gpuMemoryFields(g, used, total)
+		}
+		if hwmons, _ := filepath.Glob(filepath.Join(dev, "hwmon", "hwmon*")); len(hwmons) > 0 {
+			// temp1 为 edge 温度，单位为毫摄氏度；功耗单位为微瓦
+			if v, ok := readSysfsUint(filepath.Join(hwmons[0], "temp1_input")); ok {
+				g["temperature_celsius"] = float64(v) / 1000
+			}
+			power, ok := readSysfsUint(filepath.Join(hwmons[0], "power1_average"))
+			if !ok {
+				power, ok = readSysfsUint(filepath.Join(hwmons[0], "power1_input"))
+			}
+			if ok {
+				g["power_watts"] = math.Round(float64(power)/1e4) / 100
+			}
+			if v, ok := readSysfsUint(filepath.Join(hwmons[0], "power1_cap")); ok {
+				g["power_limit_watts"] = math.Round(float64(v)/1e4) / 100
+			}
+		}
+		gpus[name] = g
+	}
+	return gpus
+}
+
+// getGPUs 汇总 NVIDIA 和 AMD GPU，没有 GPU 的实例返回 nil
+func getGPUs() map[string]interface{} {
+	gpus := getNvidiaGPUs()
+	if gpus == nil {
+		gpus = make(map[string]interface{})
+	}
+	for name, g := range getAMDGPUs() {
+		gpus[name] = g
+	}
+	if len(gpus) == 0 {
+		return nil
+	}
+	return gpus
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// agentHealth 记录 agent 自身的上报状态，与采集到的主机指标无关
+type agentHealth struct {
+	mu                  sync.Mutex
+	started             time.Time
+	lastSuccess         time.Time
+	lastError           string
+	consecutiveFailures map[string]int // 按目的地分别计数，一个目的地恢复不会掩盖另一个持续失败
+	spools              []*spool
+	queues              []*queuedReporter
+}
+
+var health = &agentHealth{started: time.Now(), consecutiveFailures: map[string]int{}}
+
+func (h *agentHealth) record(destination string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err != nil {
+		h.consecutiveFailures[destination]++
+		h.lastError = err.Error()
+		return
+	}
+	delete(h.consecutiveFailures, destination)
+	if len(h.consecutiveFailures) == 0 {
+		h.lastError = ""
+	}
+	h.lastSuccess = time.Now()
+}
+
+// worstFailures 返回失败次数最多的目的地的连续失败次数
+func (h *agentHealth) worstFailures() int {
+	worst := 0
+	for _, n := range h.consecutiveFailures {
+		if n > worst {
+			worst = n
+		}
+	}
+	return worst
+}
+
+func (h *agentHealth) watchSpool(s *spool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.spools = append(h.spools, s)
+}
+
+func (h *agentHealth) watchQueue(q *queuedReporter) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.queues = append(h.queues, q)
+}
+
+// snapshot 返回 /healthz 的响应内容，连续失败达到 maxFailures 次时视为不健康。
+// spool 和队列的积压在释放 h.mu 之后读取，避免 /healthz 与上报互相等锁
+func (h *agentHealth) snapshot(maxFailures int) (map[string]interface{}, bool) {
+	h.mu.Lock()
+	failures := h.worstFailures()
+	healthy := maxFailures <= 0 || failures < maxFailures
+	status := "ok"
+	if !healthy {
+		status = "failing"
+	}
+	body := map[string]interface{}{
+		"status":               status,
+		"uptime_seconds":       int64(time.Since(h.started).Seconds()),
+		"consecutive_failures": failures,
+		"last_report_failed":   h.lastError != "",
+	}
+	if len(h.consecutiveFailures) > 0 {
+		failing := make(map[string]int, len(h.consecutiveFailures))
+		for name, n := range h.consecutiveFailures {
+			failing[name] = n
+		}
+		body["failing_destinations"] = failing
+	}
+	if !h.lastSuccess.IsZero() {
+		body["last_success"] = h.lastSuccess.Format(time.RFC3339)
+	}
+	if h.lastError != "" {
+		body["last_error"] = h.lastError
+	}
+	spools := append([]*spool(nil), h.spools...)
+	queues := append([]*queuedReporter(nil), h.queues...)
+	h.mu.Unlock()
+
+	if len(spools) > 0 {
+		backlog := 0
+		for _, s := range spools {
+			backlog += s.size()
+		}
+		body["spool_backlog"] = backlog
+	}
+	if len(queues) > 0 {
+		pending := 0
+		for _, q := range queues {
+			pending += q.pending()
+		}
+		body["queue_backlog"] = pending
+	}
+	return body, healthy
+}
+
+func healthzHandler(w http.ResponseWriter, r *http.Request) {
+	body, healthy := health.snapshot(cfg.HealthMaxFailures)
+	w.Header().Set("Content-Type", "application/json")
+	if !healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(body)
+}
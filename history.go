@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultHistoryMetrics 是 -history-metric 的默认值，均为 payload 中的数值字段路径
+var defaultHistoryMetrics = []string{
+	"cpu.percent", "memory.percent", "swap.percent", "disk.percent", "load_average.1min",
+	"network.upload_speed_bytes_per_sec", "network.download_speed_bytes_per_sec",
+}
+
+// historySaveInterval 是 -history-file 定期落盘的间隔，退出时也会保存一次
+const historySaveInterval = 5 * time.Minute
+
+// historySample 是一个 resolution 时间段内各指标的平均值，缺失的指标为 NaN
+type historySample struct {
+	at     int64
+	values []float64
+}
+
+// metricHistory 是固定容量的环形缓冲，容量为 window / resolution；同一时间段内的多次采集取平均
+type metricHistory struct {
+	mu         sync.Mutex
+	metrics    []string
+	resolution time.Duration
+	samples    []historySample
+	next       int // 下一个写入位置
+	full       bool
+
+	bucket int64 // 当前正在累计的时间段起点
+	sums   []float64
+	counts []int
+
+	lastSave time.Time
+}
+
+var history *metricHistory
+
+func newMetricHistory(metrics []string, window, resolution time.Duration) *metricHistory {
+	capacity := int(window / resolution)
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &metricHistory{
+		metrics:    metrics,
+		resolution: resolution,
+		samples:    make([]historySample, capacity),
+		sums:       make([]float64, len(metrics)),
+		counts:     make([]int, len(metrics)),
+	}
+}
+
+func (h *metricHistory) enabled() bool { return h != nil }
+
+// push 写入一个样本，必须持有锁
+func (h *metricHistory) push(s historySample) {
+	h.samples[h.next] = s
+	h.next = (h.next + 1) % len(h.samples)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// ordered 按时间顺序返回缓冲中的样本，必须持有锁
+func (h *metricHistory) ordered() []historySample {
+	if !h.full {
+		return h.samples[:h.next]
+	}
+	return append(append([]historySample(nil), h.samples[h.next:]...), h.samples[:h.next]...)
+}
+
+// flush 把当前时间段的平均值写入缓冲，必须持有锁
+func (h *metricHistory) flush() {
+	if h.bucket == 0 {
+		return
+	}
+	values := make([]float64, len(h.metrics))
+	any := false
+	for i := range values {
+		values[i] = math.NaN()
+		if h.counts[i] > 0 {
+			values[i] = math.Round(h.sums[i]/float64(h.counts[i])*100) / 100
+			any = true
+		}
+		h.sums[i], h.counts[i] = 0, 0
+	}
+	if any {
+		h.push(historySample{at: h.bucket, values: values})
+	}
+}
+
+// record 从一次采集结果中取出各指标，进入新的时间段时先把上一段写入缓冲
+func (h *metricHistory) record(info map[string]interface{}, now time.Time) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	bucket := now.Truncate(h.resolution).Unix()
+	if bucket != h.bucket {
+		h.flush()
+		h.bucket = bucket
+	}
+	for i, path := range h.metrics {
+		values := make(map[string]float64)
+		alertValues(info, strings.Split(path, "."), "", values)
+		if v, ok := values[path]; ok && !math.IsNaN(v) && !math.IsInf(v, 0) {
+			h.sums[i] += v
+			h.counts[i]++
+		}
+	}
+	save := cfg.HistoryFile != "" && now.Sub(h.lastSave) >= historySaveInterval
+	h.mu.Unlock()
+	if save {
+		h.save()
+	}
+}
+
+// match 返回请求的指标在 metrics 中的下标：完整路径精确匹配，或如 cpu 匹配 cpu.* 下的全部指标
+func (h *metricHistory) match(metric string) []int {
+	var idx []int
+	for i, m := range h.metrics {
+		if m == metric || strings.HasPrefix(m, metric+".") {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// query 返回 since 之后的各指标序列，点为 [unix 秒, 值]，缺失的点不输出
+func (h *metricHistory) query(idx []int, since time.Time) map[string]interface{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	series := make(map[string][][2]float64, len(idx))
+	for _, i := range idx {
+		series[h.metrics[i]] = [][2]float64{}
+	}
+	for _, s := range h.ordered() {
+		if s.at < since.Unix() {
+			continue
+		}
+		for _, i := range idx {
+			if i < len(s.values) && !math.IsNaN(s.values[i]) {
+				series[h.metrics[i]] = append(series[h.metrics[i]], [2]float64{float64(s.at), s.values[i]})
+			}
+		}
+	}
+	out := make(map[string]interface{}, len(series))
+	for k, v := range series {
+		out[k] = v
+	}
+	return out
+}
+
+// historyFile 是 -history-file 的内容；按指标名保存，指标列表调整后旧文件仍能加载对应的列
+type historyFile struct {
+	Resolution string                   `json:"resolution"`
+	Metrics    []string                 `json:"metrics"`
+	Samples    []map[string]interface{} `json:"samples"` // {"t": unix 秒, "v": [值或 null]}
+}
+
+func (h *metricHistory) save() {
+	h.mu.Lock()
+	file := historyFile{Resolution: h.resolution.String(), Metrics: h.metrics}
+	for _, s := range h.ordered() {
+		values := make([]interface{}, len(s.values))
+		for i, v := range s.values {
+			if !math.IsNaN(v) {
+				values[i] = v
+			}
+		}
+		file.Samples = append(file.Samples, map[string]interface{}{"t": s.at, "v": values})
+	}
+	h.lastSave = time.Now()
+	h.mu.Unlock()
+	body, err := json.Marshal(file)
+	if err == nil {
+		err = writeStateFile(cfg.HistoryFile, "history.json", string(body), 0644)
+	}
+	if err != nil {
+		slog.Warn("persist history failed", "component", "history", "err", err)
+	}
+}
+
+// load 读取 -history-file，丢弃超出窗口的样本；分辨率不同的旧数据直接放弃
+func (h *metricHistory) load(window time.Duration) {
+	content := readStateFile(cfg.HistoryFile, "history.json")
+	if content == "" {
+		return
+	}
+	var file historyFile
+	if err := json.Unmarshal([]byte(content), &file); err != nil {
+		slog.Warn("ignoring unreadable history file", "component", "history", "err", err)
+		return
+	}
+	if file.Resolution != h.resolution.String() {
+		slog.Info("history resolution changed, discarding saved samples", "component", "history", "saved", file.Resolution)
+		return
+	}
+	columns := make([]int, len(h.metrics))
+	for i, m := range h.metrics {
+		columns[i] = -1
+		for j, saved := range file.Metrics {
+			if saved == m {
+				columns[i] = j
+			}
+		}
+	}
+	cutoff := time.Now().Add(-window).Unix()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	loaded := 0
+	for _, raw := range file.Samples {
+		at, _ := toFloat(raw["t"])
+		saved, _ := raw["v"].([]interface{})
+		if int64(at) < cutoff {
+			continue
+		}
+		values := make([]float64, len(h.metrics))
+		for i, col := range columns {
+			values[i] = math.NaN()
+			if col >= 0 && col < len(saved) {
+				if v, ok := toFloat(saved[col]); ok {
+					values[i] = v
+				}
+			}
+		}
+		h.push(historySample{at: int64(at), values: values})
+		loaded++
+	}
+	slog.Info("history loaded", "component", "history", "samples", loaded)
+}
+
+// historyHandler 处理 GET /history?metric=cpu&range=1h，metric 可以是完整的字段路径或其第一段，
+// 省略时返回全部指标；range 默认为整个 -history-window
+func historyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !history.enabled() {
+		http.Error(w, "history is disabled, set -history-window", http.StatusNotFound)
+		return
+	}
+	q := r.URL.Query()
+	span := cfg.HistoryWindow
+	if v := q.Get("range"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			http.Error(w, fmt.Sprintf("invalid range %q, expected a duration such as 1h", v), http.StatusBadRequest)
+			return
+		}
+		span = d
+	}
+	var idx []int
+	if metric := q.Get("metric"); metric != "" {
+		if idx = history.match(metric); len(idx) == 0 {
+			http.Error(w, fmt.Sprintf("unknown metric %q, recorded: %s", metric, strings.Join(history.metrics, ", ")), http.StatusBadRequest)
+			return
+		}
+	} else {
+		for i := range history.metrics {
+			idx = append(idx, i)
+		}
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"resolution": history.resolution.String(),
+		"range":      span.String(),
+		"series":     history.query(idx, time.Now().Add(-span)),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
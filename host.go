@@ -0,0 +1,82 @@
+package main
+
+import (
+	"runtime"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/host"
+)
+
+// getCPUModel、getVirtualizationType、getOSVersion 按平台实现，见 host_<os>.go
+
+// cpuModelFromGopsutil 返回 gopsutil 的跨平台 CPU 型号，取不到时返回空字符串
+func cpuModelFromGopsutil() string {
+	if cpus, err := cpu.Info(); err == nil && len(cpus) > 0 {
+		return strings.TrimSpace(cpus[0].ModelName)
+	}
+	return ""
+}
+
+// virtualizationFromGopsutil 只有识别为 guest 时才返回虚拟化类型，否则视为物理机
+func virtualizationFromGopsutil() string {
+	system, role, err := host.Virtualization()
+	if err == nil && role == "guest" && system != "" {
+		return strings.ToUpper(system)
+	}
+	return "Physical"
+}
+
+// osVersionFromGopsutil 用 gopsutil 的平台名和版本号拼出发行版描述
+func osVersionFromGopsutil() string {
+	platform, _, version, err := host.PlatformInformation()
+	if err != nil || platform == "" {
+		return runtime.GOOS
+	}
+	if version == "" {
+		return platform
+	}
+	return platform + " " + version
+}
+
+// parseOSRelease 解析 os-release 的 KEY=value 行，值可以带单引号或双引号，忽略注释和格式错误的行
+func parseOSRelease(content string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		eq := strings.Index(line, "=")
+		if eq <= 0 {
+			continue
+		}
+		key := strings.TrimSpace(line[:eq])
+		value := strings.TrimSpace(line[eq+1:])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+			if line[eq+1] == '"' {
+				value = strings.NewReplacer(`\"`, `"`, `\\`, `\`, "\\$", "$", "\\`", "`").Replace(value)
+			}
+		}
+		fields[key] = strings.TrimSpace(value)
+	}
+	return fields
+}
+
+// osVersionFromRelease 优先使用 PRETTY_NAME，否则拼接 ID 与 VERSION_ID，缺少任一部分时不输出多余的 "-"
+func osVersionFromRelease(fields map[string]string) string {
+	if name := fields["PRETTY_NAME"]; name != "" {
+		return name
+	}
+	id, version := fields["ID"], fields["VERSION_ID"]
+	switch {
+	case id != "" && version != "":
+		return id + "-" + version
+	case id != "":
+		return id
+	case fields["NAME"] != "":
+		return fields["NAME"]
+	}
+	return runtime.GOOS
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+func sysctlString(name string) string {
+	out, err := exec.Command("sysctl", "-n", name).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// getCPUModel 优先使用 gopsutil，取不到时读取 sysctl machdep.cpu.brand_string
+func getCPUModel() string {
+	if model := cpuModelFromGopsutil(); model != "" {
+		return model
+	}
+	if model := sysctlString("machdep.cpu.brand_string"); model != "" {
+		return model
+	}
+	return runtime.GOARCH
+}
+
+// getVirtualizationType 在 Hypervisor.framework 虚拟机中 kern.hv_vmm_present 为 1
+func getVirtualizationType() string {
+	if sysctlString("kern.hv_vmm_present") == "1" {
+		return "VM"
+	}
+	return virtualizationFromGopsutil()
+}
+
+// getOSVersion 使用 sw_vers，例如 "macOS 14.2.1"
+func getOSVersion() string {
+	name, err1 := exec.Command("sw_vers", "-productName").Output()
+	version, err2 := exec.Command("sw_vers", "-productVersion").Output()
+	if err1 != nil || err2 != nil {
+		return osVersionFromGopsutil()
+	}
+	return strings.TrimSpace(string(name)) + " " + strings.TrimSpace(string(version))
+}
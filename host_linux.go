@@ -0,0 +1,45 @@
+package main
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// getCPUModel 优先使用 gopsutil 的跨平台结果，取不到时再尝试 lscpu，最后退回 GOARCH
+func getCPUModel() string {
+	if model := cpuModelFromGopsutil(); model != "" {
+		return model
+	}
+	out, err := exec.Command("sh", "-c", "lscpu | grep 'Model name'").Output()
+	if err != nil {
+		return runtime.GOARCH
+	}
+	parts := strings.SplitN(string(out), ":", 2)
+	if len(parts) == 2 {
+		return strings.TrimSpace(parts[1])
+	}
+	return runtime.GOARCH
+}
+
+func getVirtualizationType() string {
+	out, err := exec.Command("systemd-detect-virt").Output()
+	if err == nil {
+		vtype := strings.TrimSpace(string(out))
+		if vtype != "none" {
+			return strings.ToUpper(vtype)
+		}
+		return "Physical"
+	}
+	// 没有 systemd 的系统（如 Alpine）退回到 gopsutil 的检测
+	return virtualizationFromGopsutil()
+}
+
+func getOSVersion() string {
+	content, err := ioutil.ReadFile(hostEtc("os-release"))
+	if err != nil {
+		return runtime.GOOS
+	}
+	return osVersionFromRelease(parseOSRelease(string(content)))
+}
@@ -0,0 +1,20 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+import "runtime"
+
+func getCPUModel() string {
+	if model := cpuModelFromGopsutil(); model != "" {
+		return model
+	}
+	return runtime.GOARCH
+}
+
+func getVirtualizationType() string {
+	return virtualizationFromGopsutil()
+}
+
+func getOSVersion() string {
+	return osVersionFromGopsutil()
+}
@@ -0,0 +1,76 @@
+package main
+
+import "testing"
+
+func TestOSVersionFromRelease(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		id      string
+	}{
+		{
+			name: "ubuntu",
+			content: `PRETTY_NAME="Ubuntu 22.04.4 LTS"
+NAME="Ubuntu"
+VERSION_ID="22.04"
+VERSION="22.04.4 LTS (Jammy Jellyfish)"
+ID=ubuntu
+ID_LIKE=debian
+`,
+			want: "Ubuntu 22.04.4 LTS",
+			id:   "ubuntu",
+		},
+		{
+			name: "alpine",
+			content: `NAME="Alpine Linux"
+ID=alpine
+VERSION_ID=3.19.1
+PRETTY_NAME="Alpine Linux v3.19"
+HOME_URL="https://alpinelinux.org/"
+`,
+			want: "Alpine Linux v3.19",
+			id:   "alpine",
+		},
+		{
+			// Arch 是滚动发行版，没有 VERSION_ID
+			name: "arch",
+			content: `NAME="Arch Linux"
+PRETTY_NAME="Arch Linux"
+ID=arch
+BUILD_ID=rolling
+`,
+			want: "Arch Linux",
+			id:   "arch",
+		},
+		{
+			name: "alpine without pretty name",
+			content: `# 精简镜像只保留了部分字段
+ID='alpine'
+VERSION_ID=3.19.1
+`,
+			want: "alpine-3.19.1",
+			id:   "alpine",
+		},
+		{
+			name:    "missing version id",
+			content: "ID=debian\nmalformed line\n",
+			want:    "debian",
+			id:      "debian",
+		},
+		{
+			name:    "name only",
+			content: `NAME="Custom \"Linux\""`,
+			want:    `Custom "Linux"`,
+		},
+	}
+	for _, tt := range tests {
+		fields := parseOSRelease(tt.content)
+		if got := osVersionFromRelease(fields); got != tt.want {
+			t.Errorf("%s: osVersionFromRelease = %q, want %q", tt.name, got, tt.want)
+		}
+		if fields["ID"] != tt.id {
+			t.Errorf("%s: ID = %q, want %q", tt.name, fields["ID"], tt.id)
+		}
+	}
+}
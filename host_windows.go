@@ -0,0 +1,73 @@
+package main
+
+import (
+	"runtime"
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+func registryString(path, name string) string {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, path, registry.QUERY_VALUE)
+	if err != nil {
+		return ""
+	}
+	defer k.Close()
+	v, _, err := k.GetStringValue(name)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(v)
+}
+
+// getCPUModel 优先使用 gopsutil（WMI），取不到时读取注册表中的 ProcessorNameString
+func getCPUModel() string {
+	if model := cpuModelFromGopsutil(); model != "" {
+		return model
+	}
+	if model := registryString(`HARDWARE\DESCRIPTION\System\CentralProcessor\0`, "ProcessorNameString"); model != "" {
+		return model
+	}
+	return runtime.GOARCH
+}
+
+// windowsHypervisors 是 BIOS 厂商或型号中常见的虚拟化标识
+var windowsHypervisors = []struct{ marker, name string }{
+	{"vmware", "VMWARE"},
+	{"virtualbox", "ORACLE"},
+	{"kvm", "KVM"},
+	{"qemu", "QEMU"},
+	{"xen", "XEN"},
+	{"amazon ec2", "AMAZON"},
+	{"google compute engine", "GOOGLE"},
+	{"oraclecloud", "KVM"},
+	{"virtual machine", "MICROSOFT"}, // Hyper-V 与 Azure
+}
+
+// getVirtualizationType 根据注册表中的 BIOS 厂商和型号判断是否运行在虚拟机中
+func getVirtualizationType() string {
+	bios := `HARDWARE\DESCRIPTION\System\BIOS`
+	ident := strings.ToLower(registryString(bios, "SystemManufacturer") + " " + registryString(bios, "SystemProductName"))
+	for _, h := range windowsHypervisors {
+		if strings.Contains(ident, h.marker) {
+			return h.name
+		}
+	}
+	return "Physical"
+}
+
+// getOSVersion 从注册表读取产品名和版本，例如 "Windows Server 2022 Datacenter 21H2 (20348)"
+func getOSVersion() string {
+	key := `SOFTWARE\Microsoft\Windows NT\CurrentVersion`
+	name := registryString(key, "ProductName")
+	if name == "" {
+		return osVersionFromGopsutil()
+	}
+	if display := registryString(key, "DisplayVersion"); display != "" {
+		name += " " + display
+	}
+	if build := registryString(key, "CurrentBuild"); build != "" {
+		name += " (" + build + ")"
+	}
+	return name
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 以 DaemonSet 或容器方式运行时，宿主机的 /proc、/sys 等目录挂载在其他位置（如 /host/proc），
+// gopsutil 通过 HOST_PROC、HOST_SYS、HOST_ETC、HOST_VAR、HOST_RUN 环境变量定位这些目录，
+// agent 自己直接读取的文件也使用同样的变量
+func hostPath(env, def string, elem ...string) string {
+	root := os.Getenv(env)
+	if root == "" {
+		root = def
+	}
+	return filepath.Join(append([]string{root}, elem...)...)
+}
+
+func hostProc(elem ...string) string { return hostPath("HOST_PROC", "/proc", elem...) }
+func hostSys(elem ...string) string  { return hostPath("HOST_SYS", "/sys", elem...) }
+func hostEtc(elem ...string) string  { return hostPath("HOST_ETC", "/etc", elem...) }
+func hostVar(elem ...string) string  { return hostPath("HOST_VAR", "/var", elem...) }
+func hostRun(elem ...string) string  { return hostPath("HOST_RUN", "/run", elem...) }
+
+// hostFile 把宿主机上的绝对路径（如 /var/log/secure）映射到对应的 HOST_* 挂载点下
+func hostFile(path string) string {
+	for _, m := range []struct {
+		prefix string
+		join   func(...string) string
+	}{{"/proc/", hostProc}, {"/sys/", hostSys}, {"/etc/", hostEtc}, {"/var/", hostVar}, {"/run/", hostRun}} {
+		if strings.HasPrefix(path, m.prefix) {
+			return m.join(path[len(m.prefix):])
+		}
+	}
+	return path
+}
+
+// applyHostPaths 把 -host-* 参数写入环境变量，必须在第一次调用 gopsutil 之前执行；
+// 未设置的参数保留已有的 HOST_* 环境变量
+func applyHostPaths() {
+	for env, dir := range map[string]string{
+		"HOST_PROC": cfg.HostProc,
+		"HOST_SYS":  cfg.HostSys,
+		"HOST_ETC":  cfg.HostEtc,
+		"HOST_VAR":  cfg.HostVar,
+		"HOST_RUN":  cfg.HostRun,
+	} {
+		if dir != "" {
+			os.Setenv(env, dir)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// httpClient 由上报和心跳共用，newHTTPClient 在启动时按配置替换
+var httpClient = newHTTPClient(10*time.Second, nil)
+
+// httpProxy 是 HTTP 请求和 WebSocket 使用的代理，默认读取 HTTPS_PROXY、HTTP_PROXY 和 NO_PROXY，
+// 设置 -proxy 后由 parseProxy 替换
+var httpProxy = http.ProxyFromEnvironment
+
+// parseProxy 解析 -proxy：http(s)://、socks5:// 或 socks5h://（由代理解析域名）的代理地址，
+// direct 表示忽略环境变量直接连接
+func parseProxy(raw string) (func(*http.Request) (*url.URL, error), error) {
+	if raw == "direct" {
+		return nil, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q, expected scheme://host:port", raw)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q, expected http, https, socks5 or socks5h", u.Scheme)
+	}
+	return http.ProxyURL(u), nil
+}
+
+// tlsConfig 为 nil 时使用系统默认配置，HTTP 上报和 WebSocket 共用
+var tlsConfig *tls.Config
+
+// loadTLSConfig 加载客户端证书与自定义 CA，用于要求双向 TLS 或使用私有 PKI 的采集端；
+// serverName 覆盖 SNI 和证书校验使用的主机名，insecure 跳过证书校验，仅用于调试
+func loadTLSConfig(certFile, keyFile, caFile, serverName string, insecure bool) (*tls.Config, error) {
+	if certFile == "" && keyFile == "" && caFile == "" && serverName == "" && !insecure {
+		return nil, nil
+	}
+	if (certFile == "") != (keyFile == "") {
+		return nil, fmt.Errorf("tls-cert and tls-key must be set together")
+	}
+	conf := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         serverName,
+		InsecureSkipVerify: insecure,
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+	if caFile != "" {
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", caFile)
+		}
+		conf.RootCAs = pool
+	}
+	return conf, nil
+}
+
+func newHTTPClient(timeout time.Duration, tlsConf *tls.Config) *http.Client {
+	connectTimeout := 5 * time.Second
+	if timeout < connectTimeout {
+		connectTimeout = timeout
+	}
+	transport := &http.Transport{
+		Proxy: httpProxy,
+		DialContext: (&net.Dialer{
+			Timeout:   connectTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:       tlsConf,
+		TLSHandshakeTimeout:   connectTimeout,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          10,
+		MaxIdleConnsPerHost:   2,
+		IdleConnTimeout:       90 * time.Second,
+		// 自定义了 DialContext 和 TLSClientConfig 时标准库不会自动启用 HTTP/2
+		ForceAttemptHTTP2: true,
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHTTPClientTimeout(t *testing.T) {
+	// 服务端只接收请求、从不响应，直到测试结束
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	client := newHTTPClient(200*time.Millisecond, nil)
+	start := time.Now()
+	resp, err := client.Get(srv.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("expected a timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("request took %s, want close to the 200ms timeout", elapsed)
+	}
+}
+
+// writeCert 生成一张证书并把证书和私钥以 PEM 写入 dir，parent 为 nil 时自签名
+func writeCert(t *testing.T, dir, name string, tmpl *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, filepath.Join(dir, name+".crt"), "CERTIFICATE", der)
+	writePEM(t, filepath.Join(dir, name+".key"), "EC PRIVATE KEY", keyDER)
+	return cert, key
+}
+
+func writePEM(t *testing.T, path, kind string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMutualTLSClient(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	ca, caKey := writeCert(t, dir, "client-ca", &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test client CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+	writeCert(t, dir, "client", &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "oci-agent"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+	// 测试服务器的证书作为客户端信任的 CA
+	writePEM(t, filepath.Join(dir, "server-ca.crt"), "CERTIFICATE", srv.Certificate().Raw)
+
+	serverCA := filepath.Join(dir, "server-ca.crt")
+	conf, err := loadTLSConfig(filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"), serverCA, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := newHTTPClient(5*time.Second, conf).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request with client certificate: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+
+	// 没有客户端证书时服务端拒绝握手
+	conf, err = loadTLSConfig("", "", serverCA, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := newHTTPClient(5*time.Second, conf).Get(srv.URL); err == nil {
+		resp.Body.Close()
+		t.Error("request without client certificate succeeded")
+	}
+
+	// 证书与私钥不匹配时启动阶段报错
+	if _, err := loadTLSConfig(filepath.Join(dir, "client.crt"), filepath.Join(dir, "client-ca.key"), serverCA, "", false); err == nil {
+		t.Error("loadTLSConfig accepted a certificate with a mismatched key")
+	}
+	if _, err := loadTLSConfig(filepath.Join(dir, "client.crt"), "", "", "", false); err == nil {
+		t.Error("loadTLSConfig accepted tls-cert without tls-key")
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var instanceID string
+
+// reportedHostname 返回上报使用的主机名，-hostname 优先于系统主机名；OCI 实例默认的主机名
+// 往往是 instance-20240101-1234 之类难以辨认的名字
+func reportedHostname() string {
+	if cfg.Hostname != "" {
+		return cfg.Hostname
+	}
+	hostname, _ := os.Hostname()
+	return hostname
+}
+
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// statePaths 返回持久化状态文件的候选路径：未指定路径时优先使用 /var/lib，非 root 运行时退回到用户配置目录
+func statePaths(configured, name string) []string {
+	if configured != "" {
+		return []string{configured}
+	}
+	paths := []string{filepath.Join("/var/lib/oci-agent", name)}
+	if dir, err := os.UserConfigDir(); err == nil {
+		paths = append(paths, filepath.Join(dir, "oci-agent", name))
+	}
+	return paths
+}
+
+// readStateFile 返回第一个存在且非空的状态文件内容
+func readStateFile(configured, name string) string {
+	for _, path := range statePaths(configured, name) {
+		if content, err := ioutil.ReadFile(path); err == nil {
+			if value := strings.TrimSpace(string(content)); value != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// writeStateFile 写入第一个可写的候选路径
+func writeStateFile(configured, name, value string, perm os.FileMode) error {
+	var lastErr error
+	for _, path := range statePaths(configured, name) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			lastErr = err
+			continue
+		}
+		if err := writeFileAtomic(path, []byte(value+"\n"), perm); err != nil {
+			lastErr = err
+			continue
+		}
+		return nil
+	}
+	return lastErr
+}
+
+// writeFileAtomic 先写同目录的临时文件并 fsync，再重命名覆盖，
+// 写到一半时崩溃或断电只会留下旧文件，不会留下截断的状态
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// loadInstanceID 读取持久化的实例 UUID，首次运行时生成并写入，保证重启后标识不变
+func loadInstanceID(configured string) (string, error) {
+	if id := readStateFile(configured, "instance_id"); id != "" {
+		return id, nil
+	}
+	id, err := newUUID()
+	if err != nil {
+		return "", err
+	}
+	if err := writeStateFile(configured, "instance_id", id, 0644); err != nil {
+		return "", fmt.Errorf("persist instance id: %w", err)
+	}
+	return id, nil
+}
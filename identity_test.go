@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteStateFileReplacesAtomically(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "traffic.json")
+	if err := writeStateFile(path, "traffic.json", `{"period":"2026-09"}`, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeStateFile(path, "traffic.json", `{"period":"2026-10"}`, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := readStateFile(path, "traffic.json"); got != `{"period":"2026-10"}` {
+		t.Errorf("state = %q, want the second write", got)
+	}
+	st, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := st.Mode().Perm(); perm != 0600 && runtime.GOOS != "windows" {
+		t.Errorf("mode = %v, want 0600", perm)
+	}
+	// 临时文件在重命名后不应残留
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("directory contains %v, want only traffic.json", names)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+type fileState struct {
+	exists bool
+	hash   string
+	mtime  time.Time
+}
+
+type integrityChecker struct {
+	mu        sync.Mutex
+	baseline  map[string]fileState
+	lastCheck time.Time
+	result    map[string]interface{}
+}
+
+var integrity = &integrityChecker{}
+
+func hashFile(path string) (fileState, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fileState{}, nil
+		}
+		return fileState{}, err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return fileState{}, err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return fileState{}, err
+	}
+	return fileState{exists: true, hash: hex.EncodeToString(h.Sum(nil)), mtime: st.ModTime()}, nil
+}
+
+func formatMtime(s fileState) interface{} {
+	if !s.exists {
+		return nil
+	}
+	return s.mtime.Format("2006-01-02 15:04:05")
+}
+
+// check 按 interval 低频重新计算哈希，两次检查之间直接返回上一次的结果
+func (c *integrityChecker) check(files []string, interval time.Duration) map[string]interface{} {
+	if len(files) == 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.result != nil && time.Since(c.lastCheck) < interval {
+		return c.result
+	}
+
+	first := c.baseline == nil
+	if first {
+		c.baseline = make(map[string]fileState)
+	}
+	anyChanged := false
+	entries := make(map[string]interface{}, len(files))
+	for _, path := range files {
+		current, err := hashFile(path)
+		if err != nil {
+			entries[path] = map[string]interface{}{"error": err.Error()}
+			continue
+		}
+		old, seen := c.baseline[path]
+		changed := !first && seen && (old.exists != current.exists || old.hash != current.hash)
+		if changed {
+			anyChanged = true
+		}
+		entries[path] = map[string]interface{}{
+			"changed":   changed,
+			"exists":    current.exists,
+			"old_mtime": formatMtime(old),
+			"new_mtime": formatMtime(current),
+		}
+		c.baseline[path] = current
+	}
+
+	c.lastCheck = time.Now()
+	c.result = map[string]interface{}{
+		"changed":    anyChanged,
+		"checked_at": c.lastCheck.Format("2006-01-02 15:04:05"),
+		"files":      entries,
+	}
+	return c.result
+}
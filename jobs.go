@@ -0,0 +1,428 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	jobDefaultTimeout = time.Hour
+	jobOutputTail     = 4 << 10 // 每个输出流只保留最后这么多字节，备份脚本之类的任务结尾通常是结论
+	jobMaxPending     = 50      // 两次上报之间最多保留的结果数
+)
+
+// taskJob 是 -job 本地定时任务在 job_results 中的 type，结果与控制端下发的任务格式相同
+const taskJob = "job"
+
+// cronSchedule 是标准的 5 段 cron 表达式（分 时 日 月 周，本地时间），或 @every DURATION；
+// 日和周都不是 * 时任一匹配即可，与 crontab 一致
+type cronSchedule struct {
+	every                         time.Duration
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+// parseCronField 解析一段 cron 表达式，支持 *、a、a-b、逗号列表和 /step，names 为可用的英文缩写
+func parseCronField(field string, min, max int, names map[string]int) (set uint64, any bool, err error) {
+	value := func(s string) (int, error) {
+		if n, ok := names[strings.ToLower(s)]; ok {
+			return n, nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < min || n > max {
+			return 0, fmt.Errorf("%q is not between %d and %d", s, min, max)
+		}
+		return n, nil
+	}
+	for _, item := range strings.Split(field, ",") {
+		rng, step := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			rng = item[:i]
+			if step, err = strconv.Atoi(item[i+1:]); err != nil || step < 1 {
+				return 0, false, fmt.Errorf("invalid step in %q", item)
+			}
+		}
+		lo, hi := min, max
+		switch {
+		case rng == "*":
+			any = any || step == 1
+		case strings.Contains(rng, "-"):
+			parts := strings.SplitN(rng, "-", 2)
+			if lo, err = value(parts[0]); err != nil {
+				return 0, false, err
+			}
+			if hi, err = value(parts[1]); err != nil {
+				return 0, false, err
+			}
+			if lo > hi {
+				return 0, false, fmt.Errorf("invalid range %q", rng)
+			}
+		default:
+			if lo, err = value(rng); err != nil {
+				return 0, false, err
+			}
+			if step == 1 {
+				hi = lo
+			}
+		}
+		for n := lo; n <= hi; n += step {
+			set |= 1 << uint(n)
+		}
+	}
+	return set, any, nil
+}
+
+func parseCronSchedule(spec string) (*cronSchedule, error) {
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil || d < time.Minute {
+			return nil, fmt.Errorf("@every needs a duration of at least 1m, got %q", rest)
+		}
+		return &cronSchedule{every: d}, nil
+	}
+	if macro, ok := cronMacros[spec]; ok {
+		spec = macro
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields (minute hour day month weekday) or @every DURATION, got %q", spec)
+	}
+	dayNames := make(map[string]int, len(weekdayNames))
+	for name, d := range weekdayNames {
+		dayNames[name] = int(d)
+	}
+	s := &cronSchedule{}
+	var err error
+	if s.minute, _, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if s.hour, _, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if s.dom, s.domAny, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if s.month, _, err = parseCronField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if s.dow, s.dowAny, err = parseCronField(fields[4], 0, 7, dayNames); err != nil {
+		return nil, fmt.Errorf("weekday: %w", err)
+	}
+	// 周日可以写成 0 或 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+// next 返回 after 之后的下一次执行时间，找不到（如 2 月 30 日）时返回零值
+func (s *cronSchedule) next(after time.Time) time.Time {
+	if s.every > 0 {
+		return after.Add(s.every)
+	}
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// job 是一个 -job 本地定时任务
+type job struct {
+	name     string
+	spec     string
+	command  string
+	schedule *cronSchedule
+}
+
+// splitJobSpec 把 "0 3 * * * certbot renew" 拆成时间表和命令，与 crontab 的写法相同
+func splitJobSpec(spec string) (schedule, command string, err error) {
+	spec = strings.TrimSpace(spec)
+	n := 5
+	if strings.HasPrefix(spec, "@every ") {
+		n = 2
+	} else if strings.HasPrefix(spec, "@") {
+		n = 1
+	}
+	rest := spec
+	for i := 0; i < n; i++ {
+		rest = strings.TrimLeft(rest, " \t")
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			return "", "", fmt.Errorf("expected a schedule followed by a command, got %q", spec)
+		}
+		rest = rest[end:]
+	}
+	schedule = strings.Join(strings.Fields(spec[:len(spec)-len(rest)]), " ")
+	return schedule, strings.TrimSpace(rest), nil
+}
+
+func compileJobs(specs map[string]string) ([]job, error) {
+	names := make([]string, 0, len(specs))
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	list := make([]job, 0, len(names))
+	for _, name := range names {
+		schedule, command, err := splitJobSpec(specs[name])
+		if err != nil {
+			return nil, fmt.Errorf("job %s: %w", name, err)
+		}
+		s, err := parseCronSchedule(schedule)
+		if err != nil {
+			return nil, fmt.Errorf("job %s: %w", name, err)
+		}
+		if s.every == 0 && s.next(time.Now()).IsZero() {
+			return nil, fmt.Errorf("job %s: schedule %q never fires", name, schedule)
+		}
+		list = append(list, job{name: name, spec: schedule, command: command, schedule: s})
+	}
+	return list, nil
+}
+
+var jobList []job
+
+// tailBuffer 只保留最后 jobOutputTail 字节的输出
+type tailBuffer struct {
+	buf     []byte
+	dropped bool
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if over := len(b.buf) - jobOutputTail; over > 0 {
+		b.buf = append(b.buf[:0], b.buf[over:]...)
+		b.dropped = true
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	if b.dropped {
+		return "[output truncated]\n" + strings.ToValidUTF8(string(b.buf), "")
+	}
+	return string(b.buf)
+}
+
+// jobStatus 是一个任务最近一次执行的汇总，供 jobs 段使用
+type jobStatus struct {
+	next     time.Time
+	last     *taskResult
+	duration time.Duration
+	runs     uint64
+	failures uint64
+}
+
+// jobRunner 按各自的时间表在后台执行 -job，结果在下一次上报中以 job_results 上报一次，失败时发送 job_failed 事件
+type jobRunner struct {
+	mu      sync.Mutex
+	status  map[string]*jobStatus
+	pending []taskResult
+	timeout time.Duration
+}
+
+var jobs = &jobRunner{status: map[string]*jobStatus{}, timeout: jobDefaultTimeout}
+
+// execJob 与插件一样通过 shell 执行，环境变量 OCI_AGENT_JOB 为任务名
+func execJob(j job, timeout time.Duration) (result taskResult, duration time.Duration) {
+	start := time.Now()
+	result = taskResult{ID: j.name + "@" + start.Format(time.RFC3339), AgentID: agentID(), Type: taskJob, ExitCode: -1, StartedAt: start.Format(time.RFC3339)}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", j.command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", j.command)
+	}
+	cmd.Env = append(os.Environ(), "OCI_AGENT_JOB="+j.name)
+	cmd.WaitDelay = time.Second
+	var stdout, stderr tailBuffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	duration = time.Since(start)
+	result.FinishedAt = time.Now().Format(time.RFC3339)
+	result.Stdout, result.Stderr = stdout.String(), stderr.String()
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		result.Error = fmt.Sprintf("timed out after %s", timeout)
+	} else if err != nil {
+		result.Error = err.Error()
+	}
+	result.Result = map[string]interface{}{
+		"job":              j.name,
+		"schedule":         j.spec,
+		"duration_seconds": duration.Seconds(),
+	}
+	return result, duration
+}
+
+func (r *jobRunner) runOne(j job) {
+	slog.Info("running job", "component", "jobs", "job", j.name)
+	result, duration := execJob(j, r.timeout)
+	failed := result.Error != "" || result.ExitCode != 0
+	if failed {
+		slog.Warn("job failed", "component", "jobs", "job", j.name, "exit_code", result.ExitCode, "err", result.Error)
+	} else {
+		slog.Info("job finished", "component", "jobs", "job", j.name, "duration", duration.Round(time.Millisecond))
+	}
+	r.mu.Lock()
+	s := r.status[j.name]
+	s.last, s.duration = &result, duration
+	s.runs++
+	if failed {
+		s.failures++
+	}
+	if len(r.pending) >= jobMaxPending {
+		r.pending = r.pending[1:]
+	}
+	r.pending = append(r.pending, result)
+	r.mu.Unlock()
+	if failed {
+		jobEvent(j, result)
+	}
+}
+
+func jobEvent(j job, result taskResult) {
+	to := fmt.Sprintf("exit code %d", result.ExitCode)
+	if result.Error != "" {
+		to = result.Error
+	}
+	dispatchAlert(alertEvent{
+		Rule:      "job_failed",
+		Severity:  severityWarning,
+		Status:    "changed",
+		Metric:    "jobs." + j.name + ".last_exit_code",
+		Value:     float64(result.ExitCode),
+		To:        to,
+		Hostname:  reportedHostname(),
+		AgentID:   agentID(),
+		StartedAt: result.StartedAt,
+		At:        result.FinishedAt,
+	})
+}
+
+// run 为每个任务启动一个 goroutine；同一任务不会重叠执行，执行超过间隔时错过的时间点直接跳过
+func (r *jobRunner) run(ctx context.Context, list []job) {
+	for _, j := range list {
+		r.mu.Lock()
+		r.status[j.name] = &jobStatus{}
+		r.mu.Unlock()
+		go func(j job) {
+			for {
+				next := j.schedule.next(time.Now())
+				if next.IsZero() {
+					return
+				}
+				r.mu.Lock()
+				r.status[j.name].next = next
+				r.mu.Unlock()
+				timer := time.NewTimer(time.Until(next))
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+				r.runOne(j)
+			}
+		}(j)
+	}
+}
+
+// takeResults 取出尚未上报的执行结果，每个结果只上报一次
+func (r *jobRunner) takeResults() []taskResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	results := r.pending
+	r.pending = nil
+	return results
+}
+
+// getJobs 返回各 -job 的时间表、下次执行时间和最近一次结果
+func getJobs() map[string]interface{} {
+	jobs.mu.Lock()
+	defer jobs.mu.Unlock()
+	if len(jobs.status) == 0 {
+		return nil
+	}
+	out := make(map[string]interface{}, len(jobs.status))
+	for _, j := range jobList {
+		s := jobs.status[j.name]
+		if s == nil {
+			continue
+		}
+		entry := map[string]interface{}{
+			"schedule":       j.spec,
+			"runs_total":     s.runs,
+			"failures_total": s.failures,
+		}
+		if !s.next.IsZero() {
+			entry["next_run"] = s.next.Format(time.RFC3339)
+		}
+		if s.last != nil {
+			entry["last_run"] = s.last.StartedAt
+			entry["last_exit_code"] = s.last.ExitCode
+			entry["last_duration_seconds"] = s.duration.Seconds()
+			entry["last_success"] = s.last.Error == "" && s.last.ExitCode == 0
+			if s.last.Error != "" {
+				entry["last_error"] = s.last.Error
+			}
+		}
+		out[j.name] = entry
+	}
+	return out
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// getFileDescriptors 读取 /proc/sys/fs/file-nr：已分配、已分配但未使用、上限（fs.file-max）。
+// 达到上限后所有进程 open/accept 都会返回 EMFILE/ENFILE，这类故障很难从其他指标看出来
+func getFileDescriptors() map[string]interface{} {
+	s, ok := readSysValue(hostProc("sys/fs/file-nr"))
+	if !ok {
+		return nil
+	}
+	fields := strings.Fields(s)
+	if len(fields) != 3 {
+		return nil
+	}
+	var v [3]uint64
+	for i, f := range fields {
+		n, err := strconv.ParseUint(f, 10, 64)
+		if err != nil {
+			return nil
+		}
+		v[i] = n
+	}
+	used := v[0] - v[1]
+	if v[1] > v[0] {
+		used = 0
+	}
+	return map[string]interface{}{
+		"allocated": v[0],
+		"used":      used,
+		"max":       v[2],
+		"percent":   percentOf(used, v[2]),
+	}
+}
+
+// getEntropy 读取内核熵池的可用量；5.18 起内核改用 BLAKE2s，entropy_avail 恒为 256，
+// 只有旧内核上这个值偏低才意味着读取 /dev/random 的进程会阻塞
+func getEntropy() map[string]interface{} {
+	s, ok := readSysValue(hostProc("sys/kernel/random/entropy_avail"))
+	if !ok {
+		return nil
+	}
+	avail, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return nil
+	}
+	section := map[string]interface{}{"available": avail}
+	if s, ok := readSysValue(hostProc("sys/kernel/random/poolsize")); ok {
+		if size, err := strconv.ParseUint(s, 10, 64); err == nil {
+			section["pool_size"] = size
+			section["percent"] = percentOf(avail, size)
+		}
+	}
+	return section
+}
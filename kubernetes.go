@@ -0,0 +1,264 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// kubelet 的健康检查只监听回环地址，DaemonSet 需要 hostNetwork 才能访问
+const kubeletHealthzURL = "http://127.0.0.1:10248/healthz"
+
+// serviceAccountDir 是 Pod 内挂载的服务账号凭据，令牌会定期轮换，每次请求前重新读取
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// kubernetesRuntimes 按 socket 识别容器运行时，路径相对于宿主机的 /run
+var kubernetesRuntimes = []struct {
+	name   string
+	socket string
+}{
+	{"containerd", "containerd/containerd.sock"},
+	{"cri-o", "crio/crio.sock"},
+	{"cri-dockerd", "cri-dockerd.sock"},
+}
+
+// 与 ociMetadataClient 相同，本机地址不走代理
+var kubeletClient = &http.Client{
+	Timeout:   2 * time.Second,
+	Transport: &http.Transport{Proxy: nil},
+}
+
+// detectKubernetesNode 判断本机是否为 Kubernetes 节点：存在 kubelet 的数据目录，或 agent 本身运行在 Pod 中
+func detectKubernetesNode() (runtime string, ok bool) {
+	for _, r := range kubernetesRuntimes {
+		if _, err := os.Stat(hostRun(r.socket)); err == nil {
+			runtime = r.name
+			break
+		}
+	}
+	if _, err := os.Stat(hostVar("lib/kubelet")); err == nil {
+		return runtime, true
+	}
+	return runtime, os.Getenv("KUBERNETES_SERVICE_HOST") != ""
+}
+
+func kubeletHealthy() (bool, error) {
+	resp, err := kubeletClient.Get(kubeletHealthzURL)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("kubelet healthz returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return true, nil
+}
+
+// kubeAPI 使用服务账号访问 API server，需要对 nodes 的 get 权限和对 pods 的 list 权限
+type kubeAPI struct {
+	base   string
+	client *http.Client
+}
+
+func newInClusterAPI() (*kubeAPI, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in a pod, KUBERNETES_SERVICE_HOST is not set")
+	}
+	ca, err := ioutil.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificates in %s/ca.crt", serviceAccountDir)
+	}
+	// API server 在集群内访问，不使用 -proxy
+	transport := &http.Transport{Proxy: nil, TLSClientConfig: &tls.Config{RootCAs: pool}}
+	return &kubeAPI{
+		base:   "https://" + net.JoinHostPort(host, port),
+		client: &http.Client{Timeout: 5 * time.Second, Transport: transport},
+	}, nil
+}
+
+func (k *kubeAPI) get(path string, v interface{}) error {
+	token, err := ioutil.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodGet, k.base+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	req.Header.Set("Accept", "application/json")
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("GET %s: %w", path, &statusError{code: resp.StatusCode})
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 32<<20)).Decode(v)
+}
+
+// nodeStatus 读取节点的 conditions、可调度的 Pod 上限和 kubelet 版本
+func (k *kubeAPI) nodeStatus(node string, out map[string]interface{}) error {
+	var n struct {
+		Spec struct {
+			Unschedulable bool `json:"unschedulable"`
+		} `json:"spec"`
+		Status struct {
+			Conditions []struct {
+				Type    string `json:"type"`
+				Status  string `json:"status"`
+				Reason  string `json:"reason"`
+				Message string `json:"message"`
+			} `json:"conditions"`
+			Allocatable map[string]string `json:"allocatable"`
+			NodeInfo    struct {
+				KubeletVersion          string `json:"kubeletVersion"`
+				ContainerRuntimeVersion string `json:"containerRuntimeVersion"`
+			} `json:"nodeInfo"`
+		} `json:"status"`
+	}
+	if err := k.get("/api/v1/nodes/"+url.PathEscape(node), &n); err != nil {
+		return err
+	}
+	conditions := make(map[string]interface{}, len(n.Status.Conditions))
+	for _, c := range n.Status.Conditions {
+		cond := map[string]interface{}{"status": c.Status}
+		if c.Reason != "" {
+			cond["reason"] = c.Reason
+		}
+		// 只在异常时附上说明：Ready 不为 True，或 MemoryPressure 等其他 condition 为 True
+		if c.Message != "" && (c.Type == "Ready") != (c.Status == "True") {
+			cond["message"] = c.Message
+		}
+		conditions[c.Type] = cond
+		if c.Type == "Ready" {
+			out["ready"] = c.Status == "True"
+		}
+	}
+	out["conditions"] = conditions
+	out["unschedulable"] = n.Spec.Unschedulable
+	if pods, ok := n.Status.Allocatable["pods"]; ok {
+		if n, err := strconv.Atoi(pods); err == nil {
+			out["pod_capacity"] = n
+		}
+	}
+	out["kubelet_version"] = n.Status.NodeInfo.KubeletVersion
+	out["container_runtime_version"] = n.Status.NodeInfo.ContainerRuntimeVersion
+	return nil
+}
+
+// podCounts 按阶段统计调度到本节点的 Pod，分页读取，每页只解析需要的字段
+func (k *kubeAPI) podCounts(node string) (map[string]interface{}, error) {
+	phases := map[string]interface{}{}
+	total, cont := 0, ""
+	for {
+		q := url.Values{"fieldSelector": {"spec.nodeName=" + node}, "limit": {"500"}}
+		if cont != "" {
+			q.Set("continue", cont)
+		}
+		var list struct {
+			Metadata struct {
+				Continue string `json:"continue"`
+			} `json:"metadata"`
+			Items []struct {
+				Status struct {
+					Phase string `json:"phase"`
+				} `json:"status"`
+			} `json:"items"`
+		}
+		if err := k.get("/api/v1/pods?"+q.Encode(), &list); err != nil {
+			return nil, err
+		}
+		for _, p := range list.Items {
+			phase := strings.ToLower(p.Status.Phase)
+			n, _ := phases[phase].(int)
+			phases[phase] = n + 1
+			total++
+		}
+		if cont = list.Metadata.Continue; cont == "" {
+			break
+		}
+	}
+	return map[string]interface{}{"total": total, "phases": phases}, nil
+}
+
+// kubernetesNodeName 优先使用 -kubernetes-node（DaemonSet 中通常由 downward API 注入 OCI_AGENT_KUBERNETES_NODE），
+// 否则使用上报的主机名，与 kubelet 默认的节点名一致
+func kubernetesNodeName() string {
+	if cfg.KubernetesNode != "" {
+		return cfg.KubernetesNode
+	}
+	return strings.ToLower(reportedHostname())
+}
+
+func readKubernetes() map[string]interface{} {
+	runtime, ok := detectKubernetesNode()
+	if !ok {
+		return nil
+	}
+	node := kubernetesNodeName()
+	section := map[string]interface{}{"node": node, "in_cluster": os.Getenv("KUBERNETES_SERVICE_HOST") != ""}
+	if runtime != "" {
+		section["runtime"] = runtime
+	}
+	healthy, err := kubeletHealthy()
+	section["kubelet_healthy"] = healthy
+	if err != nil {
+		section["kubelet_error"] = err.Error()
+	}
+	api, err := newInClusterAPI()
+	if err != nil {
+		// 以普通服务方式运行在节点上时没有服务账号，只报告 kubelet 健康状态
+		slog.Debug("kubernetes api unavailable", "component", "kubernetes", "err", err)
+		return section
+	}
+	if err := api.nodeStatus(node, section); err != nil {
+		section["api_error"] = err.Error()
+		return section
+	}
+	pods, err := api.podCounts(node)
+	if err != nil {
+		section["api_error"] = err.Error()
+		return section
+	}
+	section["pods"] = pods
+	return section
+}
+
+// kubernetesCache 按 -kubernetes-refresh 缓存节点状态，避免每轮采集都请求 API server
+type kubernetesCache struct {
+	mu      sync.Mutex
+	fetched time.Time
+	value   map[string]interface{}
+}
+
+var kubernetesNode = &kubernetesCache{}
+
+func (c *kubernetesCache) get(refresh time.Duration) map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fetched.IsZero() || time.Since(c.fetched) >= refresh {
+		c.value, c.fetched = readKubernetes(), time.Now()
+	}
+	return c.value
+}
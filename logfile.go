@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatingFile 是按大小和时间轮转的日志文件，轮转后的文件名为 path.20060102-150405，
+// 只保留最近 maxBackups 个
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxAge     time.Duration // 0 表示不按时间轮转
+	maxBackups int
+
+	mu     sync.Mutex
+	f      *os.File
+	size   int64
+	opened time.Time
+}
+
+func openRotatingFile(path string, maxSize int64, maxAge time.Duration, maxBackups int) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, maxSize: maxSize, maxAge: maxAge, maxBackups: maxBackups}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	// 沿用已有文件时按它的修改时间计算存在时长，重启不会推迟按时间轮转
+	r.f, r.size, r.opened = f, info.Size(), time.Now()
+	if info.Size() > 0 {
+		r.opened = info.ModTime()
+	}
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.size > 0 && (r.size+int64(len(p)) > r.maxSize || r.maxAge > 0 && time.Since(r.opened) >= r.maxAge) {
+		if err := r.rotate(); err != nil {
+			// 轮转失败时继续写入原文件，不丢日志
+			fmt.Fprintf(os.Stderr, "log rotation failed: %v\n", err)
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) rotate() error {
+	// Windows 上不能重命名打开着的文件，先关闭
+	r.f.Close()
+	backup := r.path + "." + time.Now().Format("20060102-150405")
+	renameErr := os.Rename(r.path, backup)
+	if err := r.open(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		return renameErr
+	}
+	r.size, r.opened = 0, time.Now()
+	r.prune()
+	return nil
+}
+
+// prune 删除超出 maxBackups 的旧文件，时间戳后缀按字典序即时间顺序
+func (r *rotatingFile) prune() {
+	matches, err := filepath.Glob(r.path + ".*")
+	if err != nil {
+		return
+	}
+	var backups []string
+	for _, m := range matches {
+		if _, err := time.Parse("20060102-150405", strings.TrimPrefix(m, r.path+".")); err == nil {
+			backups = append(backups, m)
+		}
+	}
+	sort.Strings(backups)
+	for len(backups) > r.maxBackups {
+		os.Remove(backups[0])
+		backups = backups[1:]
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+func parseLogLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q, expected debug, info, warn or error", s)
+}
+
+// setupLogger 按 -log-level、-log-format 配置默认 logger，设置了 -log-file 时写入轮转文件而不是 stderr
+func setupLogger() error {
+	l, err := parseLogLevel(cfg.LogLevel)
+	if err != nil {
+		return fmt.Errorf("log-level: %w", err)
+	}
+	var out io.Writer = os.Stderr
+	if cfg.LogFile != "" {
+		maxSize, err := parseByteSize(cfg.LogMaxSize)
+		if err != nil || maxSize == 0 {
+			return fmt.Errorf("log-max-size: invalid size %q", cfg.LogMaxSize)
+		}
+		if cfg.LogMaxBackups < 0 {
+			return fmt.Errorf("log-max-backups must not be negative, got %d", cfg.LogMaxBackups)
+		}
+		f, err := openRotatingFile(cfg.LogFile, int64(maxSize), cfg.LogMaxAge, cfg.LogMaxBackups)
+		if err != nil {
+			return fmt.Errorf("log-file: %w", err)
+		}
+		out = f
+	}
+	opts := &slog.HandlerOptions{Level: l}
+	switch cfg.LogFormat {
+	case "text":
+		slog.SetDefault(slog.New(slog.NewTextHandler(out, opts)))
+	case "json":
+		slog.SetDefault(slog.New(slog.NewJSONHandler(out, opts)))
+	default:
+		return fmt.Errorf("log-format: unknown format %q, expected text or json", cfg.LogFormat)
+	}
+	return nil
+}
+
+// logReportError 记录上报失败，HTTP 状态码错误时额外带上 status
+func logReportError(component, url string, err error) {
+	attrs := []any{"component", component, "url", url, "err", err}
+	var se *statusError
+	if errors.As(err, &se) {
+		attrs = append(attrs, "status", se.code)
+	}
+	slog.Error("report failed", attrs...)
+}
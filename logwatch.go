@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// logWatchDefaultPattern 是 -log-watch 没有对应 -log-match 时使用的模式，匹配常见的错误级别
+const logWatchDefaultPattern = `(?i)\b(error|crit|critical|fatal|panic|emerg|alert)\b`
+
+const (
+	logWatchMaxRead     = 1 << 20 // 单个文件每轮最多读取的字节数
+	logWatchMaxLineLen  = 1024    // 上报的单行长度上限，超出部分截断
+	logWatchMaxPending  = 500     // 两次上报之间每个 watch 最多保留的行数，上报失败或间隔较长时不会无限增长
+	logWatchNotifyLines = 3       // 通知中附带的行数
+)
+
+// logWatch 是一个 -log-watch：一组文件 glob 和匹配行的正则，任一正则匹配即转发该行
+type logWatch struct {
+	name     string
+	globs    []string
+	patterns []*regexp.Regexp
+
+	files   map[string]*fileTail
+	scanned bool
+}
+
+// logBatch 是一个 watch 尚未上报的匹配行；suppressed 是超过速率限制而只计数不转发的行
+type logBatch struct {
+	matched    int
+	suppressed int
+	lines      []interface{}
+}
+
+// logWatcher 定期读取各 watch 匹配到的文件，把命中的行攒到下一次上报的 log_events 中
+type logWatcher struct {
+	mu      sync.Mutex
+	watches []*logWatch
+	pending map[string]*logBatch
+}
+
+var logWatches = &logWatcher{pending: map[string]*logBatch{}}
+
+func compileLogWatches(globs, patterns map[string][]string) ([]*logWatch, error) {
+	for name := range patterns {
+		if _, ok := globs[name]; !ok {
+			return nil, fmt.Errorf("log-match: no -log-watch named %q", name)
+		}
+	}
+	names := make([]string, 0, len(globs))
+	for name := range globs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	watches := make([]*logWatch, 0, len(names))
+	for _, name := range names {
+		w := &logWatch{name: name, files: map[string]*fileTail{}}
+		for _, g := range globs[name] {
+			if g == "" {
+				return nil, fmt.Errorf("log-watch %s: empty path", name)
+			}
+			if _, err := filepath.Match(g, ""); err != nil {
+				return nil, fmt.Errorf("log-watch %s: invalid glob %q: %w", name, g, err)
+			}
+			w.globs = append(w.globs, g)
+		}
+		exprs := patterns[name]
+		if len(exprs) == 0 {
+			exprs = []string{logWatchDefaultPattern}
+		}
+		for _, expr := range exprs {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("log-match %s: %w", name, err)
+			}
+			w.patterns = append(w.patterns, re)
+		}
+		watches = append(watches, w)
+	}
+	return watches, nil
+}
+
+// expand 返回 glob 当前匹配到的文件，目录被忽略
+func (w *logWatch) expand() []string {
+	seen := map[string]bool{}
+	var paths []string
+	for _, g := range w.globs {
+		matches, _ := filepath.Glob(g)
+		for _, p := range matches {
+			if st, err := os.Stat(p); err != nil || st.IsDir() || seen[p] {
+				continue
+			}
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// refresh 按 glob 的最新结果增删要读取的文件：第一次扫描到的文件从末尾开始，之后新出现的文件从头读取；
+// 轮转改名后的旧文件（如 app.log 变成 app.log.1）沿用原来的读取位置，避免重复转发
+func (w *logWatch) refresh() {
+	paths := w.expand()
+	byInode := map[uint64]*fileTail{}
+	for _, t := range w.files {
+		if t.inode != 0 {
+			byInode[t.inode] = t
+		}
+	}
+	current := make(map[string]*fileTail, len(paths))
+	for _, p := range paths {
+		if t, ok := w.files[p]; ok {
+			current[p] = t
+			continue
+		}
+		t := &fileTail{path: p, maxRead: logWatchMaxRead, started: w.scanned}
+		if st, err := os.Stat(p); err == nil && w.scanned {
+			if prev, ok := byInode[fileInode(st)]; ok {
+				t.offset, t.inode = prev.offset, prev.inode
+			}
+		}
+		current[p] = t
+	}
+	w.files = current
+	w.scanned = true
+}
+
+func (w *logWatch) match(line string) bool {
+	for _, re := range w.patterns {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// scan 读取各文件新增的行，返回本轮命中的行；超过 maxLines 的部分只计入 suppressed
+func (w *logWatch) scan(maxLines int, now time.Time) *logBatch {
+	w.refresh()
+	batch := &logBatch{}
+	paths := make([]string, 0, len(w.files))
+	for p := range w.files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		data, err := w.files[p].read()
+		if err != nil {
+			slog.Debug("read watched log failed", "component", "logwatch", "watch", w.name, "path", p, "err", err)
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimRight(line, "\r")
+			if line == "" || !w.match(line) {
+				continue
+			}
+			batch.matched++
+			if len(batch.lines) >= maxLines {
+				batch.suppressed++
+				continue
+			}
+			if len(line) > logWatchMaxLineLen {
+				line = strings.ToValidUTF8(line[:logWatchMaxLineLen], "")
+			}
+			batch.lines = append(batch.lines, map[string]interface{}{"file": p, "line": line, "at": now.Format(time.RFC3339)})
+		}
+	}
+	return batch
+}
+
+func (l *logWatcher) enabled() bool {
+	return len(l.watches) > 0
+}
+
+// round 扫描所有 watch 一次，把结果并入待上报的批次，开启 -log-watch-notify 时每个 watch 每轮最多发一条通知
+func (l *logWatcher) round(maxLines int, notify bool) {
+	now := time.Now()
+	for _, w := range l.watches {
+		batch := w.scan(maxLines, now)
+		if batch.matched == 0 {
+			continue
+		}
+		l.mu.Lock()
+		p := l.pending[w.name]
+		if p == nil {
+			p = &logBatch{}
+			l.pending[w.name] = p
+		}
+		p.matched += batch.matched
+		p.suppressed += batch.suppressed
+		for _, line := range batch.lines {
+			if len(p.lines) >= logWatchMaxPending {
+				p.suppressed++
+				continue
+			}
+			p.lines = append(p.lines, line)
+		}
+		l.mu.Unlock()
+		if notify {
+			logWatchEvent(w.name, batch, now)
+		}
+	}
+}
+
+func (l *logWatcher) run(ctx context.Context, interval time.Duration, maxLines int, notify bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		l.round(maxLines, notify)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// takeEvents 取出尚未上报的匹配行，每行只上报一次；没有新的匹配时返回 nil
+func (l *logWatcher) takeEvents() map[string]interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.pending) == 0 {
+		return nil
+	}
+	out := make(map[string]interface{}, len(l.pending))
+	for name, p := range l.pending {
+		out[name] = map[string]interface{}{
+			"matched":    p.matched,
+			"suppressed": p.suppressed,
+			"lines":      p.lines,
+		}
+	}
+	l.pending = map[string]*logBatch{}
+	return out
+}
+
+// logWatchEvent 把一轮的匹配汇总成一条 log_matched 事件，To 为前几行内容
+func logWatchEvent(name string, batch *logBatch, now time.Time) {
+	var lines []string
+	for _, l := range batch.lines {
+		if len(lines) == logWatchNotifyLines {
+			break
+		}
+		lines = append(lines, l.(map[string]interface{})["line"].(string))
+	}
+	to := strings.Join(lines, "\n")
+	if more := batch.matched - len(lines); more > 0 {
+		to += fmt.Sprintf("\n(%d more)", more)
+	}
+	dispatchAlert(alertEvent{
+		Rule:      "log_matched",
+		Severity:  severityWarning,
+		Status:    "changed",
+		Metric:    "log_events." + name,
+		Value:     float64(batch.matched),
+		To:        to,
+		Hostname:  reportedHostname(),
+		AgentID:   agentID(),
+		StartedAt: now.Format(time.RFC3339),
+		At:        now.Format(time.RFC3339),
+	})
+}
@@ -1,98 +1,99 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"github.com/shirou/gopsutil/v3/cpu"
 	"github.com/shirou/gopsutil/v3/disk"
 	"github.com/shirou/gopsutil/v3/host"
 	"github.com/shirou/gopsutil/v3/load"
 	"github.com/shirou/gopsutil/v3/mem"
-	"github.com/shirou/gopsutil/v3/net"
-	"io/ioutil"
+	"github.com/shirou/gopsutil/v3/process"
+	"log/slog"
 	"math"
-	"net/http"
-	"os/exec"
-	"runtime"
+	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
+// 字节单位格式：legacy 为原有的 1024 进制加 K/M/G 后缀，iec 为 1024 进制 KiB/MiB，si 为 1000 进制 KB/MB
+const (
+	byteUnitsLegacy = "legacy"
+	byteUnitsIEC    = "iec"
+	byteUnitsSI     = "si"
+)
+
+var byteUnits = byteUnitsLegacy
+
 func formatBytes(b uint64) string {
-	const unit = 1024
+	return formatBytesUnits(b, byteUnits)
+}
+
+func formatBytesUnits(b uint64, mode string) string {
+	unit, suffix := uint64(1024), ""
+	switch mode {
+	case byteUnitsIEC:
+		suffix = "iB"
+	case byteUnitsSI:
+		unit, suffix = 1000, "B"
+	}
 	if b < unit {
 		return fmt.Sprintf("%dB", b)
 	}
-	div, exp := uint64(unit), 0
+	div, exp := unit, 0
 	for n := b / unit; n >= unit; n /= unit {
 		div *= unit
 		exp++
 	}
-	return fmt.Sprintf("%.2f%s", float64(b)/float64(div), "KMGTPE"[exp:exp+1])
+	return fmt.Sprintf("%.2f%s%s", float64(b)/float64(div), "KMGTPE"[exp:exp+1], suffix)
 }
 
 func formatUptime(seconds int64) string {
-	if seconds >= 86400 {
-		days := seconds / 86400
-		return fmt.Sprintf("%d天", days)
-	}
-	hours := seconds / 3600
+	days := seconds / 86400
+	hours := (seconds % 86400) / 3600
 	minutes := (seconds % 3600) / 60
 	secs := seconds % 60
-	return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, secs)
-}
-
-func getCPUModel() string {
-	out, err := exec.Command("sh", "-c", "lscpu | grep 'Model name'").Output()
-	if err != nil {
-		return runtime.GOARCH
-	}
-	parts := strings.SplitN(string(out), ":", 2)
-	if len(parts) == 2 {
-		return strings.TrimSpace(parts[1])
+	if days > 0 {
+		return fmt.Sprintf("%d天 %02d:%02d:%02d", days, hours, minutes, secs)
 	}
-	return runtime.GOARCH
+	return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, secs)
 }
 
-func getVirtualizationType() string {
-	out, err := exec.Command("systemd-detect-virt").Output()
-	if err == nil {
-		vtype := strings.TrimSpace(string(out))
-		if vtype != "none" {
-			return strings.ToUpper(vtype)
-		}
-	}
-	return "Physical"
-}
+// loadAvg 可在测试中替换，模拟不支持 load average 的平台
+var loadAvg = load.Avg
 
-func getOSVersion() string {
-	content, err := ioutil.ReadFile("/etc/os-release")
+// getLoadAverage 在不支持 load average 的平台上返回错误，由调用方决定省略该字段
+func getLoadAverage() (map[string]float64, error) {
+	avg, err := loadAvg()
 	if err != nil {
-		return runtime.GOOS
-	}
-	lines := strings.Split(string(content), "\n")
-	var id, version string
-	for _, line := range lines {
-		if strings.HasPrefix(line, "ID=") {
-			id = strings.Trim(line[3:], "\"")
-		} else if strings.HasPrefix(line, "VERSION_ID=") {
-			version = strings.Trim(line[11:], "\"")
-		}
-	}
-	return fmt.Sprintf("%s-%s", id, version)
-}
-
-func getLoadAverage() map[string]float64 {
-	avg, err := load.Avg()
-	if err != nil {
-		return map[string]float64{"error": -1}
+		return nil, err
 	}
 	return map[string]float64{
 		"1min":  avg.Load1,
 		"5min":  avg.Load5,
 		"15min": avg.Load15,
+	}, nil
+}
+
+// hostValues 返回运行时间、进程数和 load average，不支持 load average 时省略该字段
+func hostValues() map[string]interface{} {
+	values := map[string]interface{}{}
+	if uptimeSeconds, err := host.Uptime(); err == nil {
+		values["uptime"] = formatUptime(int64(uptimeSeconds))
+	}
+	if pids, err := process.Pids(); err == nil {
+		values["process_count"] = len(pids)
 	}
+	if avg, err := getLoadAverage(); err == nil {
+		values["load_average"] = avg
+	} else {
+		slog.Debug("load average unavailable", "component", "collector", "err", err)
+	}
+	return values
 }
 
 func getDiskUsage() map[string]interface{} {
@@ -107,137 +108,592 @@ func getDiskUsage() map[string]interface{} {
 	}
 }
 
-func getNetworkSpeed(interval time.Duration) (upload, download float64) {
-	before, _ := net.IOCounters(false)
-	time.Sleep(interval)
-	after, _ := net.IOCounters(false)
-	if len(before) > 0 && len(after) > 0 {
-		upload = float64(after[0].BytesSent-before[0].BytesSent) / interval.Seconds()
-		download = float64(after[0].BytesRecv-before[0].BytesRecv) / interval.Seconds()
-	}
-	return
+type partitionUsage struct {
+	disk.PartitionStat
+	usage *disk.UsageStat
 }
 
-func getAllDisksUsage() (map[string]interface{}, error) {
-	partitions, err := disk.Partitions(true) // true获取所有，包括逻辑分区
-	if err != nil {
-		return nil, err
-	}
+// isBlockDevice 判断分区是否来自真实的块设备，zfs 的设备名是数据集名而不是 /dev 路径
+func isBlockDevice(p disk.PartitionStat) bool {
+	return strings.HasPrefix(p.Device, "/dev/") || p.Fstype == "zfs"
+}
 
-	var total uint64 = 0
-	var used uint64 = 0
+// partitionUsages 返回需要统计的分区：只保留真实块设备，跳过 skipFstypes 中的文件系统和
+// 匹配 skipMountpoints 的挂载点，同一设备（bind mount 等）只保留第一个挂载点
+func partitionUsages(partitions []disk.PartitionStat, usageOf func(string) (*disk.UsageStat, error), skipFstypes, skipMountpoints []string) []partitionUsage {
+	skip := make(map[string]bool, len(skipFstypes))
+	for _, fstype := range skipFstypes {
+		skip[fstype] = true
+	}
+	counted := make(map[string]bool)
 
+	var result []partitionUsage
 	for _, p := range partitions {
-		usage, err := disk.Usage(p.Mountpoint)
+		if skip[p.Fstype] || counted[p.Device] || !isBlockDevice(p) || mountpointExcluded(p.Mountpoint, skipMountpoints) {
+			continue
+		}
+		usage, err := usageOf(p.Mountpoint)
 		if err != nil {
 			// 有些盘可能无法访问，跳过
 			continue
 		}
-		total += usage.Total
-		used += usage.Used
+		counted[p.Device] = true
+		result = append(result, partitionUsage{p, usage})
+	}
+	return result
+}
+
+// mountpointExcluded 按 glob 规则（如 /snap/*、/var/lib/docker/*）过滤挂载点
+func mountpointExcluded(mountpoint string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, mountpoint); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func sumPartitionsUsage(partitions []partitionUsage) (total, used uint64) {
+	for _, p := range partitions {
+		total += p.usage.Total
+		used += p.usage.Used
+	}
+	return total, used
+}
+
+func diskPartitions() ([]partitionUsage, error) {
+	partitions, err := facts.partitions()
+	if err != nil {
+		return nil, err
+	}
+	return partitionUsages(partitions, disk.Usage, cfg.DiskSkipFstypes, cfg.DiskSkipMountpoints), nil
+}
+
+func sumDisksUsage() (total, used uint64, err error) {
+	partitions, err := diskPartitions()
+	if err != nil {
+		return 0, 0, err
 	}
+	total, used = sumPartitionsUsage(partitions)
+	return total, used, nil
+}
 
-	var percent float64 = 0
-	if total > 0 {
-		percent = math.Round(float64(used)*10000/float64(total)) / 100 // 保留2位小数的百分比
+func getAllDisksUsage() (map[string]interface{}, error) {
+	partitions, err := diskPartitions()
+	if err != nil {
+		return nil, err
+	}
+	total, used := sumPartitionsUsage(partitions)
+
+	// 按挂载点展开，汇总值与之使用同一份过滤后的分区列表
+	perMount := make(map[string]interface{}, len(partitions))
+	for _, p := range partitions {
+		m := map[string]interface{}{
+			"device":      p.Device,
+			"fstype":      p.Fstype,
+			"total":       formatBytes(p.usage.Total),
+			"used":        formatBytes(p.usage.Used),
+			"percent":     percentOf(p.usage.Used, p.usage.Total),
+			"total_bytes": p.usage.Total,
+			"used_bytes":  p.usage.Used,
+			"free_bytes":  p.usage.Free,
+		}
+		// 小启动卷上的大量小文件会先耗尽 inode，此时磁盘空间看起来仍然充足；
+		// btrfs 等动态分配 inode 的文件系统报告为 0，不输出
+		if p.usage.InodesTotal > 0 {
+			m["inodes_total"] = p.usage.InodesTotal
+			m["inodes_used"] = p.usage.InodesUsed
+			m["inodes_percent"] = percentOf(p.usage.InodesUsed, p.usage.InodesTotal)
+		}
+		perMount[p.Mountpoint] = m
 	}
 
 	diskInfo := map[string]interface{}{
-		"total":   formatBytes(total),
-		"used":    formatBytes(used),
-		"percent": percent,
+		"total":       formatBytes(total),
+		"used":        formatBytes(used),
+		"percent":     percentOf(used, total),
+		"total_bytes": total,
+		"used_bytes":  used,
+		"partitions":  perMount,
 	}
 	return diskInfo, nil
 }
 
-func getSystemInfo() map[string]interface{} {
-	vmem, _ := mem.VirtualMemory()
-	swap, _ := mem.SwapMemory()
-	cpus, _ := cpu.Info()
-	cpuPercent, _ := cpu.Percent(1*time.Second, false)
-	hostInfo, _ := host.Info()
-	netStats, _ := net.IOCounters(false)
-	upload, download := getNetworkSpeed(1 * time.Second)
-	uptimeSeconds, _ := host.Uptime()
-	diskInfo, _ := getAllDisksUsage()
+// percentOf 返回保留两位小数的百分比，total 为 0 时返回 0 而不是 NaN
+func percentOf(part, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*10000/float64(total)) / 100
+}
+
+func memorySection(vmem *mem.VirtualMemoryStat) map[string]interface{} {
+	section := map[string]interface{}{
+		"total":       formatBytes(vmem.Total),
+		"used":        formatBytes(vmem.Used),
+		"percent":     percentOf(vmem.Used, vmem.Total),
+		"total_bytes": vmem.Total,
+		"used_bytes":  vmem.Used,
+		// Linux 上 used 会包含部分缓存，available 才是真正可回收的内存
+		"available_bytes":   vmem.Available,
+		"available":         formatBytes(vmem.Available),
+		"cached_bytes":      vmem.Cached,
+		"buffers_bytes":     vmem.Buffers,
+		"shared_bytes":      vmem.Shared,
+		"available_percent": percentOf(vmem.Available, vmem.Total),
+	}
+	// 在容器内运行时 total 是宿主机的内存，真正的上限来自 cgroup
+	if limits := cgroupMemory(vmem.Total); limits != nil {
+		section["cgroup"] = limits
+	}
+	return section
+}
 
+func swapSection(swap *mem.SwapMemoryStat) map[string]interface{} {
 	return map[string]interface{}{
-		"platform":         runtime.GOOS,
-		"platform_version": hostInfo.PlatformVersion,
-		"distribution":     getOSVersion(),
-		"virtualization":   getVirtualizationType(),
-		"architecture":     runtime.GOARCH,
-		"cpu": map[string]interface{}{
-			"model":   cpus[0].ModelName,
-			"count":   runtime.NumCPU(),
-			"percent": math.Round(cpuPercent[0]*100) / 100,
-		},
-		"memory": map[string]interface{}{
-			"total":   formatBytes(vmem.Total),
-			"used":    formatBytes(vmem.Used),
-			"percent": math.Round(float64(vmem.Used)*10000/float64(vmem.Total)) / 100,
-		},
-		"swap": map[string]interface{}{
-			"total":   formatBytes(swap.Total),
-			"used":    formatBytes(swap.Used),
-			"percent": math.Round(swap.UsedPercent*100) / 100,
-		},
-		"disk": diskInfo,
-		"network": map[string]interface{}{
-			"upload_speed":   formatBytes(uint64(upload)),
-			"download_speed": formatBytes(uint64(download)),
-			"upload_total":   formatBytes(netStats[0].BytesSent),
-			"download_total": formatBytes(netStats[0].BytesRecv),
-		},
-		"load_average":  getLoadAverage(),
-		"uptime":        formatUptime(int64(uptimeSeconds)),
-		"boot_time":     time.Unix(int64(hostInfo.BootTime), 0).Format("2006-01-02 15:04:05"),
-		"current_time":  time.Now().Format("2006-01-02 15:04:05"),
-		"process_count": hostInfo.Procs,
-	}
-}
-
-func reportToServer(data map[string]interface{}, url string) {
-	body, _ := json.Marshal(data)
-	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
-	if err != nil {
-		fmt.Println("Error reporting:", err)
-		return
+		"total":       formatBytes(swap.Total),
+		"used":        formatBytes(swap.Used),
+		"percent":     percentOf(swap.Used, swap.Total),
+		"total_bytes": swap.Total,
+		"used_bytes":  swap.Used,
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode == 200 {
-		fmt.Println("Reported successfully.")
-	} else {
-		fmt.Println("Server returned status:", resp.StatusCode)
+}
+
+// sampleWindow 是 CPU 使用率、网速等速率类指标共用的采样窗口，由 -sample-window 设置
+var sampleWindow = 1 * time.Second
+
+// getSystemInfo 合并缓存的静态信息与本轮采集的动态指标，同名的子对象（如 cpu）按字段合并
+func getSystemInfo() map[string]interface{} {
+	info := copyPayload(collectStaticInfo())
+	for k, v := range collectDynamicInfo() {
+		sub, ok := v.(map[string]interface{})
+		if existing, isMap := info[k].(map[string]interface{}); ok && isMap {
+			for sk, sv := range sub {
+				existing[sk] = sv
+			}
+			continue
+		}
+		info[k] = v
 	}
+	return info
 }
 
-func sendHeartbeat(url string) {
-	heartbeat := map[string]interface{}{
-		"status":    "online",
-		"timestamp": time.Now().Unix(),
+// collectorsInFlight 记录上一轮超时后仍未返回的采集项（如卡在失效 NFS 挂载上的 disk.Usage），
+// 在其返回之前不再启动新的协程，避免每轮都泄漏一个
+var collectorsInFlight = struct {
+	sync.Mutex
+	names map[string]bool
+}{names: map[string]bool{}}
+
+func collectDynamicInfo() map[string]interface{} {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		info     = map[string]interface{}{}
+		started  []string
+		finished = map[string]bool{}
+		expired  bool
+	)
+	set := func(values map[string]interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		if expired {
+			return
+		}
+		for k, v := range values {
+			info[k] = v
+		}
+	}
+	// 各项采集互不依赖，并发执行，CPU、网速与磁盘 I/O 的采样窗口重叠，总耗时约为一个 sampleWindow；
+	// 超过 -collector-timeout 仍未返回的采集项被放弃，只输出已完成的部分
+	run := func(name string, fn func()) {
+		collectorsInFlight.Lock()
+		busy := collectorsInFlight.names[name]
+		collectorsInFlight.names[name] = true
+		collectorsInFlight.Unlock()
+		started = append(started, name)
+		if busy {
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn()
+			collectorsInFlight.Lock()
+			delete(collectorsInFlight.names, name)
+			collectorsInFlight.Unlock()
+			mu.Lock()
+			finished[name] = true
+			mu.Unlock()
+		}()
+	}
+
+	run("cpu", func() {
+		section := map[string]interface{}{"effective_cpus": getEffectiveCPUs()}
+		if aggregate, cores, err := sampleCPUTimes(sampleWindow); err == nil && aggregate != nil {
+			section["percent"] = aggregate["percent"]
+			delete(aggregate, "percent")
+			section["times"] = aggregate
+			if len(cores) > 0 {
+				section["cores"] = cores
+			}
+		} else if cpuPercent, err := cpu.Percent(sampleWindow, false); err == nil && len(cpuPercent) > 0 {
+			// 部分平台读不到 CPU 时间，退回只有总使用率
+			section["percent"] = math.Round(cpuPercent[0]*100) / 100
+		} else {
+			section["percent"] = 0.0
+			slog.Debug("cpu percent unavailable", "component", "collector", "err", err)
+		}
+		set(map[string]interface{}{"cpu": section})
+	})
+	run("network", func() {
+		publicIPs := make(chan map[string]interface{}, 1)
+		go func() { publicIPs <- publicIP.get(cfg.PublicIPEchoURLs, cfg.PublicIPRefresh) }()
+
+		aggregate, perInterface, err := sampleNetwork(sampleWindow)
+		if err != nil {
+			return
+		}
+		interfaces := make(map[string]interface{}, len(perInterface))
+		for name, r := range perInterface {
+			if interfaceIncluded(name, cfg.NetInclude, cfg.NetExclude) {
+				interfaces[name] = r.interfaceMap(name)
+			}
+		}
+		network := aggregate.toMap()
+		network["public_ip"] = <-publicIPs
+		set(map[string]interface{}{
+			"network":            network,
+			"network_interfaces": interfaces,
+		})
+	})
+	run("memory", func() {
+		vmem, err := mem.VirtualMemory()
+		if err != nil {
+			slog.Debug("virtual memory unavailable", "component", "collector", "err", err)
+			vmem = &mem.VirtualMemoryStat{}
+		}
+		swap, err := mem.SwapMemory()
+		if err != nil {
+			slog.Debug("swap memory unavailable", "component", "collector", "err", err)
+			swap = &mem.SwapMemoryStat{}
+		}
+		set(map[string]interface{}{
+			"memory": memorySection(vmem),
+			"swap":   swapSection(swap),
+		})
+	})
+	run("disk", func() {
+		diskInfo, _ := getAllDisksUsage()
+		if diskInfo != nil {
+			if io := getDiskIO(sampleWindow); io != nil {
+				diskInfo["io"] = io
+			}
+		}
+		set(map[string]interface{}{"disk": diskInfo})
+	})
+	run("host", func() { set(hostValues()) })
+
+	now := time.Now()
+	for _, c := range optionalCollectors {
+		if !collectorActive(c.name, now) {
+			continue
+		}
+		c := c
+		run(c.name, func() {
+			if section := c.collect(); section != nil {
+				set(map[string]interface{}{c.name: section})
+			}
+		})
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(cfg.CollectorTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+	}
+	mu.Lock()
+	expired = true
+	var timedOut []string
+	for _, name := range started {
+		if !finished[name] {
+			timedOut = append(timedOut, name)
+		}
+	}
+	mu.Unlock()
+	if len(timedOut) > 0 {
+		slog.Warn("collectors timed out, reporting partial results", "component", "collector", "collectors", timedOut, "timeout", cfg.CollectorTimeout)
+		info["timed_out_collectors"] = timedOut
 	}
-	reportToServer(heartbeat, url)
+
+	info["instance_id"] = agentID()
+	info["hostname"] = reportedHostname()
+	if len(cfg.Labels) > 0 {
+		info["labels"] = cfg.Labels
+	}
+	info["current_time"] = time.Now().Format("2006-01-02 15:04:05")
+	return info
 }
 
 func main() {
-	info := getSystemInfo()
+	if err := parseFlags(); err != nil {
+		slog.Error("invalid configuration", "err", err)
+		os.Exit(2)
+	}
+	if cfg.ShowVersion {
+		fmt.Println(versionString())
+		return
+	}
+	switch subcommand {
+	case cmdInstall:
+		if err := installService(subcommandArgs); err != nil {
+			slog.Error("install failed", "component", "service", "err", err)
+			os.Exit(1)
+		}
+		return
+	case cmdUninstall:
+		if err := uninstallService(); err != nil {
+			slog.Error("uninstall failed", "component", "service", "err", err)
+			os.Exit(1)
+		}
+		return
+	case cmdUpdate:
+		if selfUpdate == nil {
+			slog.Error("update requires -update-url", "component", "updater")
+			os.Exit(2)
+		}
+		if _, err := selfUpdate.check(context.Background()); err != nil {
+			slog.Error("update failed", "component", "updater", "url", cfg.UpdateURL, "err", err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	// 将 info 转为 JSON 字符串
-	jsonBytes, err := json.MarshalIndent(info, "", "  ")
+	if cfg.TestCollectorAddr != "" {
+		if err := serveTestCollector(cfg.TestCollectorAddr); err != nil {
+			slog.Error("test collector stopped", "component", "test-collector", "addr", cfg.TestCollectorAddr, "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	id, err := loadInstanceID(cfg.InstanceIDFile)
 	if err != nil {
-		fmt.Println("JSON encode error:", err)
+		slog.Warn("cannot load instance id, falling back to machine id", "err", err)
+	}
+	instanceID = id
+	collectStaticInfo()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if cfg.RegisterURL != "" {
+		if err := ensureRegistered(ctx, cfg.RegisterURL); err != nil {
+			slog.Error("registration failed", "component", "registration", "url", cfg.RegisterURL, "err", err)
+			os.Exit(1)
+		}
+	}
+
+	reporters, err := buildReporters()
+	if err != nil {
+		slog.Error("invalid configuration", "err", err)
+		os.Exit(2)
+	}
+
+	if cfg.Once {
+		os.Exit(runOnce(reporters))
+	}
+
+	if cfg.Format == "json" {
+		printJSON(getSystemInfo())
+	}
+
+	if cfg.ListenAddr != "" {
+		go func() {
+			if err := serveHTTP(cfg.ListenAddr); err != nil {
+				slog.Error("HTTP server stopped", "component", "http-server", "addr", cfg.ListenAddr, "err", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	if cfg.PprofAddr != "" {
+		go func() {
+			if err := servePprof(cfg.PprofAddr); err != nil {
+				slog.Error("pprof server stopped", "component", "pprof", "addr", cfg.PprofAddr, "err", err)
+			}
+		}()
+	}
+
+	for _, r := range reporters {
+		if q, ok := r.(*queuedReporter); ok {
+			q.start()
+		}
+	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go facts.run(ctx, cfg.FactsRefresh, hup)
+	if history.enabled() && cfg.HistoryFile != "" {
+		history.load(cfg.HistoryWindow)
+	}
+	go backgroundNet.run(ctx, sampleWindow)
+	go backgroundDisk.run(ctx, sampleWindow)
+	if len(probeTargets) > 0 {
+		go probes.run(ctx, probeTargets, cfg.ProbeInterval, cfg.ProbeCount)
+	}
+	if len(serviceChecks) > 0 {
+		go checks.run(ctx, serviceChecks, cfg.CheckInterval)
+	}
+	if len(pluginList) > 0 {
+		plugins.run(ctx, pluginList)
+	}
+	if len(jobList) > 0 {
+		jobs.run(ctx, jobList)
+	}
+	if logWatches.enabled() {
+		go logWatches.run(ctx, cfg.LogWatchInterval, cfg.LogWatchMaxLines, cfg.LogWatchNotify)
+	}
+	if cfg.SpeedtestInterval > 0 {
+		go speedtests.schedule(ctx, cfg.SpeedtestInterval)
+	}
+	heartbeatDone := make(chan struct{})
+	if heartbeatsEnabled(reporters) && cfg.HeartbeatInterval > 0 {
+		go func() {
+			defer close(heartbeatDone)
+			heartbeatLoop(ctx, reporters, cfg.HeartbeatInterval)
+		}()
 	} else {
-		fmt.Println(string(jsonBytes))
+		close(heartbeatDone)
+	}
+	if cfg.TaskURL != "" {
+		go taskExec.run(ctx, cfg.TaskPollInterval)
 	}
+	if selfUpdate != nil {
+		var shutdownForUpdate context.CancelFunc
+		ctx, shutdownForUpdate = context.WithCancel(ctx)
+		defer shutdownForUpdate()
+		go selfUpdate.run(ctx, cfg.UpdateInterval, shutdownForUpdate)
+	}
+
+	schedule(ctx, cfg.Interval, func() { runCycle(reporters) })
+
+	// 恢复默认的信号处理，收尾卡住时再按一次 Ctrl-C 即可立即退出
+	stop()
+	slog.Info("shutting down", "timeout", cfg.ShutdownTimeout)
+	shutdown(reporters, heartbeatDone)
+	slog.Info("shutdown complete")
+	if selfUpdate != nil && selfUpdate.restart.Load() {
+		if err := restartSelf(); err != nil {
+			// 退出码非 0，由 systemd 的 Restart=always 拉起新版本
+			slog.Error("restart after update failed", "component", "updater", "err", err)
+			os.Exit(1)
+		}
+	}
+}
 
-	for {
-		upload, download := getNetworkSpeed(1 * time.Second)
+func runCycle(reporters []Reporter) {
+	if len(reporters) > 0 || cfg.Format == "summary" || alerts.enabled() || history.enabled() {
+		info := getSystemInfo()
+		history.record(info, time.Now())
+		if result := speedtests.takeResult(); result != nil {
+			info["speedtest"] = result
+		}
+		if results := jobs.takeResults(); len(results) > 0 {
+			info["job_results"] = results
+		}
+		if events := logWatches.takeEvents(); events != nil {
+			info["log_events"] = events
+		}
+		evaluateAlerts(info)
+		if cfg.Format == "summary" {
+			fmt.Println(formatSummary(info, summaryFields()))
+		}
+		sinks.dispatch(reporters, info)
+	} else {
+		upload, download := getNetworkSpeed(sampleWindow)
 
 		fmt.Printf("Upload: %s , Download: %s\n", formatBytes(uint64(upload)), formatBytes(uint64(download)))
+	}
+
+	// 配置了独立的心跳间隔时由 heartbeatLoop 发送
+	if heartbeatsEnabled(reporters) && cfg.HeartbeatInterval == 0 {
+		sendHeartbeats(reporters, "online")
+	}
+}
 
-		//reportToServer(info, "http://your-java-server-url/report")
-		//sendHeartbeat("http://your-java-server-url/heartbeat")
-		time.Sleep(1 * time.Second)
+func printJSON(info map[string]interface{}) bool {
+	// 将 info 转为 JSON 字符串
+	jsonBytes, err := marshalPayload(info, true)
+	if err != nil {
+		slog.Error("JSON encode failed", "err", err)
+		return false
 	}
+	fmt.Println(string(jsonBytes))
+	return true
+}
+
+// runOnce 采集并上报一次，返回进程退出码：任一上报失败时返回 1，便于 cron 或探针判断
+func runOnce(reporters []Reporter) int {
+	probes.round(probeTargets, cfg.ProbeCount)
+	checks.round(serviceChecks)
+	plugins.round(pluginList)
+	info := getSystemInfo()
+	evaluateAlerts(info)
+	code := 0
+	for _, r := range reporters {
+		err := r.Report(info)
+		// 队列 Reporter 只负责入队，这里同步投递一次，未送达的数据在 Close 时写入 spool
+		if q, ok := r.(*queuedReporter); ok {
+			if err == nil {
+				err = q.flushRetry(context.Background())
+			}
+			q.Close()
+		}
+		if err != nil {
+			logReportError("reporter", r.Name(), err)
+			code = 1
+		}
+	}
+	if cfg.Format == "summary" {
+		fmt.Println(formatSummary(info, summaryFields()))
+	} else if !printJSON(info) {
+		code = 1
+	}
+	waitNotifications(cfg.HTTPTimeout)
+	return code
+}
+
+// shutdown 依次等待进行中的上报和心跳、发送离线心跳、关闭各 Reporter（队列会先尝试投递剩余数据），
+// 控制端据离线心跳区分正常停止和崩溃
+func shutdown(reporters []Reporter, heartbeatDone <-chan struct{}) {
+	sinks.wait(cfg.HTTPTimeout)
+	// 避免周期心跳在离线心跳之后才送达，把状态又改回 online
+	select {
+	case <-heartbeatDone:
+	case <-time.After(cfg.HTTPTimeout):
+	}
+	// 先发离线心跳再关闭连接，WebSocket 上的心跳才能送达
+	if heartbeatsEnabled(reporters) && cfg.OfflineHeartbeat {
+		sendHeartbeats(reporters, "offline")
+	}
+	for _, r := range reporters {
+		if c, ok := r.(interface{ Close() }); ok {
+			c.Close()
+		}
+	}
+	waitNotifications(cfg.HTTPTimeout)
+	if history.enabled() && cfg.HistoryFile != "" {
+		history.save()
+	}
+}
+
+// heartbeatLoop 与上报循环各自独立调度，splay 的随机延迟也各自独立
+func heartbeatLoop(ctx context.Context, reporters []Reporter, interval time.Duration) {
+	schedule(ctx, interval, func() { sendHeartbeats(reporters, "online") })
 }
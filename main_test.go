@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/load"
+	"github.com/shirou/gopsutil/v3/mem"
+)
+
+func TestFormatUptime(t *testing.T) {
+	tests := []struct {
+		seconds int64
+		want    string
+	}{
+		{0, "00:00:00"},
+		{59, "00:00:59"},
+		{3661, "01:01:01"},
+		{86400, "1天 00:00:00"},
+		{90061, "1天 01:01:01"},
+		{37*86400 + 2*3600 + 14*60 + 9, "37天 02:14:09"},
+	}
+	for _, tt := range tests {
+		if got := formatUptime(tt.seconds); got != tt.want {
+			t.Errorf("formatUptime(%d) = %q, want %q", tt.seconds, got, tt.want)
+		}
+	}
+}
+
+const benchWindow = 200 * time.Millisecond
+
+// BenchmarkGetSystemInfo 中 CPU、网速和磁盘 I/O 并发采样，每次耗时约为一个采样窗口
+func BenchmarkGetSystemInfo(b *testing.B) {
+	saved := sampleWindow
+	sampleWindow = benchWindow
+	defer func() { sampleWindow = saved }()
+	getSystemInfo()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		getSystemInfo()
+	}
+}
+
+// BenchmarkSerialSampling 是串行采样 CPU 和网速的对照，每次耗时约为两个采样窗口
+func BenchmarkSerialSampling(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		sampleCPUTimes(benchWindow)
+		sampleNetwork(benchWindow)
+	}
+}
+
+func TestPartitionUsagesSkipsBindMountsAndVirtualFilesystems(t *testing.T) {
+	partitions := []disk.PartitionStat{
+		{Device: "/dev/sda1", Mountpoint: "/", Fstype: "ext4"},
+		{Device: "tmpfs", Mountpoint: "/dev/shm", Fstype: "tmpfs"},
+		// bind mount 与 / 是同一个设备
+		{Device: "/dev/sda1", Mountpoint: "/srv/data", Fstype: "ext4"},
+		{Device: "/dev/sdb1", Mountpoint: "/data", Fstype: "xfs"},
+		{Device: "overlay", Mountpoint: "/var/lib/docker/overlay2/x/merged", Fstype: "overlay"},
+		{Device: "/dev/loop0", Mountpoint: "/snap/core/1", Fstype: "squashfs"},
+	}
+	sizes := map[string]uint64{"/": 100, "/dev/shm": 1000, "/srv/data": 100, "/data": 50}
+	usageOf := func(mountpoint string) (*disk.UsageStat, error) {
+		size, ok := sizes[mountpoint]
+		if !ok {
+			return nil, fmt.Errorf("unexpected usage call for %s", mountpoint)
+		}
+		return &disk.UsageStat{Path: mountpoint, Total: size, Used: size / 2}, nil
+	}
+
+	got := partitionUsages(partitions, usageOf, []string{"tmpfs", "overlay", "squashfs"}, []string{"/snap/*"})
+	var mounts []string
+	for _, p := range got {
+		mounts = append(mounts, p.Mountpoint)
+	}
+	if fmt.Sprint(mounts) != "[/ /data]" {
+		t.Fatalf("mountpoints = %v, want [/ /data]", mounts)
+	}
+	if total, used := sumPartitionsUsage(got); total != 150 || used != 75 {
+		t.Errorf("sumPartitionsUsage = %d/%d, want 150/75", total, used)
+	}
+}
+
+func TestMemorySectionsWithZeroTotal(t *testing.T) {
+	// 采集失败时 getSystemInfo 用零值代替，没有配置 swap 时 total 同样为 0
+	sections := map[string]map[string]interface{}{
+		"memory": memorySection(&mem.VirtualMemoryStat{}),
+		"swap":   swapSection(&mem.SwapMemoryStat{}),
+	}
+	for name, section := range sections {
+		for _, key := range []string{"percent", "available_percent"} {
+			v, ok := section[key]
+			if !ok {
+				continue
+			}
+			if p := v.(float64); p != 0 {
+				t.Errorf("%s.%s = %v, want 0", name, key, p)
+			}
+		}
+		if section["total_bytes"] != uint64(0) {
+			t.Errorf("%s.total_bytes = %v, want 0", name, section["total_bytes"])
+		}
+	}
+	if got := percentOf(5, 0); got != 0 {
+		t.Errorf("percentOf(5, 0) = %v, want 0", got)
+	}
+}
+
+func TestLoadAverageOmittedWhenUnsupported(t *testing.T) {
+	saved := loadAvg
+	defer func() { loadAvg = saved }()
+
+	// 与 gopsutil 在 Windows 等平台返回的 ErrNotImplementedError 相同
+	loadAvg = func() (*load.AvgStat, error) { return nil, errors.New("not implemented yet") }
+	values := hostValues()
+	if v, ok := values["load_average"]; ok {
+		t.Errorf("load_average = %v, want field absent", v)
+	}
+
+	loadAvg = func() (*load.AvgStat, error) { return &load.AvgStat{Load1: 0.5, Load5: 0.25, Load15: 0.1}, nil }
+	avg, ok := hostValues()["load_average"].(map[string]float64)
+	if !ok || avg["1min"] != 0.5 || avg["15min"] != 0.1 {
+		t.Errorf("load_average = %v, want 1min 0.5 and 15min 0.1", avg)
+	}
+}
+
+func TestFormatBytesUnits(t *testing.T) {
+	tests := []struct {
+		b               uint64
+		iec, si, legacy string
+	}{
+		{999, "999B", "999B", "999B"},
+		{1000, "1000B", "1.00KB", "1000B"},
+		{1023, "1023B", "1.02KB", "1023B"},
+		{1024, "1.00KiB", "1.02KB", "1.00K"},
+		{1500000, "1.43MiB", "1.50MB", "1.43M"},
+	}
+	for _, tt := range tests {
+		for mode, want := range map[string]string{byteUnitsIEC: tt.iec, byteUnitsSI: tt.si, byteUnitsLegacy: tt.legacy} {
+			if got := formatBytesUnits(tt.b, mode); got != want {
+				t.Errorf("formatBytesUnits(%d, %q) = %q, want %q", tt.b, mode, got, want)
+			}
+		}
+	}
+}
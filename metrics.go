@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/load"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+type promWriter struct {
+	w io.Writer
+}
+
+func (p promWriter) metric(name, typ, help string, value float64) {
+	fmt.Fprintf(p.w, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", name, help, name, typ, name, value)
+}
+
+// header 只输出 HELP/TYPE，同一指标的多个带标签样本随后用 sample 写出
+func (p promWriter) header(name, typ, help string) {
+	fmt.Fprintf(p.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+var promLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// sample 写出一个样本，labels 为 name, value 交替排列
+func (p promWriter) sample(name string, value float64, labels ...string) {
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, labels[i], promLabelEscaper.Replace(labels[i+1])))
+	}
+	fmt.Fprintf(p.w, "%s{%s} %v\n", name, strings.Join(pairs, ","), value)
+}
+
+// writePrometheusMetrics 以 Prometheus 文本格式输出原始数值，不经过 formatBytes
+func writePrometheusMetrics(w io.Writer) {
+	p := promWriter{w}
+
+	if percent, err := cpu.Percent(sampleWindow, false); err == nil && len(percent) > 0 {
+		p.metric("oci_agent_cpu_percent", "gauge", "CPU usage percent.", percent[0])
+	}
+	p.metric("oci_agent_cpu_count", "gauge", "Number of logical CPUs.", float64(runtime.NumCPU()))
+	p.metric("oci_agent_cpu_effective", "gauge", "CPUs available after cgroup quota.", getEffectiveCPUs())
+
+	if vmem, err := mem.VirtualMemory(); err == nil {
+		p.metric("oci_agent_mem_total_bytes", "gauge", "Total memory in bytes.", float64(vmem.Total))
+		p.metric("oci_agent_mem_used_bytes", "gauge", "Used memory in bytes.", float64(vmem.Used))
+	}
+	if swap, err := mem.SwapMemory(); err == nil {
+		p.metric("oci_agent_swap_total_bytes", "gauge", "Total swap in bytes.", float64(swap.Total))
+		p.metric("oci_agent_swap_used_bytes", "gauge", "Used swap in bytes.", float64(swap.Used))
+	}
+	if total, used, err := sumDisksUsage(); err == nil {
+		p.metric("oci_agent_disk_total_bytes", "gauge", "Total disk space in bytes.", float64(total))
+		p.metric("oci_agent_disk_used_bytes", "gauge", "Used disk space in bytes.", float64(used))
+		if total > 0 {
+			p.metric("oci_agent_disk_used_percent", "gauge", "Used disk space percent.", float64(used)*100/float64(total))
+		}
+	}
+	if partitions, err := diskPartitions(); err == nil {
+		writeFilesystemMetrics(p, partitions)
+	}
+	if counters, err := disk.IOCounters(); err == nil {
+		writeDiskIOMetrics(p, counters)
+	}
+	if counters, err := net.IOCounters(false); err == nil && len(counters) > 0 {
+		p.metric("oci_agent_net_upload_bytes_total", "counter", "Bytes sent on all interfaces.", float64(counters[0].BytesSent))
+		p.metric("oci_agent_net_download_bytes_total", "counter", "Bytes received on all interfaces.", float64(counters[0].BytesRecv))
+	}
+	if counters, err := net.IOCounters(true); err == nil {
+		var included []net.IOCountersStat
+		for _, c := range counters {
+			if interfaceIncluded(c.Name, cfg.NetInclude, cfg.NetExclude) {
+				included = append(included, c)
+			}
+		}
+		writeInterfaceMetrics(p, included)
+	}
+	if avg, err := load.Avg(); err == nil {
+		p.metric("oci_agent_load1", "gauge", "1-minute load average.", avg.Load1)
+		p.metric("oci_agent_load5", "gauge", "5-minute load average.", avg.Load5)
+		p.metric("oci_agent_load15", "gauge", "15-minute load average.", avg.Load15)
+	}
+	if hostInfo, err := host.Info(); err == nil {
+		p.metric("oci_agent_uptime_seconds", "gauge", "Host uptime in seconds.", float64(hostInfo.Uptime))
+		p.metric("oci_agent_process_count", "gauge", "Number of processes.", float64(hostInfo.Procs))
+	}
+}
+
+func writeFilesystemMetrics(p promWriter, partitions []partitionUsage) {
+	if len(partitions) == 0 {
+		return
+	}
+	families := []struct {
+		name, help string
+		value      func(*disk.UsageStat) float64
+	}{
+		{"oci_agent_filesystem_size_bytes", "Filesystem size in bytes.", func(u *disk.UsageStat) float64 { return float64(u.Total) }},
+		{"oci_agent_filesystem_used_bytes", "Filesystem used space in bytes.", func(u *disk.UsageStat) float64 { return float64(u.Used) }},
+		{"oci_agent_filesystem_free_bytes", "Filesystem space available to unprivileged users in bytes.", func(u *disk.UsageStat) float64 { return float64(u.Free) }},
+		{"oci_agent_filesystem_inodes_total", "Total inodes.", func(u *disk.UsageStat) float64 { return float64(u.InodesTotal) }},
+		{"oci_agent_filesystem_inodes_used", "Used inodes.", func(u *disk.UsageStat) float64 { return float64(u.InodesUsed) }},
+	}
+	for _, f := range families {
+		p.header(f.name, "gauge", f.help)
+		for _, part := range partitions {
+			p.sample(f.name, f.value(part.usage), "device", part.Device, "mountpoint", part.Mountpoint, "fstype", part.Fstype)
+		}
+	}
+}
+
+// writeDiskIOMetrics 输出整块磁盘的原始计数器，速率和利用率交给 Prometheus 用 rate() 计算
+func writeDiskIOMetrics(p promWriter, counters map[string]disk.IOCountersStat) {
+	var names []string
+	for name := range counters {
+		if isWholeDisk(name) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	sort.Strings(names)
+	families := []struct {
+		name, help string
+		value      func(disk.IOCountersStat) float64
+	}{
+		{"oci_agent_disk_read_bytes_total", "Bytes read.", func(c disk.IOCountersStat) float64 { return float64(c.ReadBytes) }},
+		{"oci_agent_disk_written_bytes_total", "Bytes written.", func(c disk.IOCountersStat) float64 { return float64(c.WriteBytes) }},
+		{"oci_agent_disk_reads_completed_total", "Reads completed.", func(c disk.IOCountersStat) float64 { return float64(c.ReadCount) }},
+		{"oci_agent_disk_writes_completed_total", "Writes completed.", func(c disk.IOCountersStat) float64 { return float64(c.WriteCount) }},
+		{"oci_agent_disk_io_time_seconds_total", "Seconds spent doing I/Os.", func(c disk.IOCountersStat) float64 { return float64(c.IoTime) / 1000 }},
+		{"oci_agent_disk_io_wait_seconds_total", "Seconds spent by reads and writes, including queueing.", func(c disk.IOCountersStat) float64 {
+			return float64(c.ReadTime+c.WriteTime) / 1000
+		}},
+	}
+	for _, f := range families {
+		p.header(f.name, "counter", f.help)
+		for _, name := range names {
+			p.sample(f.name, f.value(counters[name]), "device", name)
+		}
+	}
+}
+
+func writeInterfaceMetrics(p promWriter, counters []net.IOCountersStat) {
+	if len(counters) == 0 {
+		return
+	}
+	families := []struct {
+		name, help string
+		value      func(net.IOCountersStat) uint64
+	}{
+		{"oci_agent_network_receive_bytes_total", "Bytes received.", func(c net.IOCountersStat) uint64 { return c.BytesRecv }},
+		{"oci_agent_network_transmit_bytes_total", "Bytes sent.", func(c net.IOCountersStat) uint64 { return c.BytesSent }},
+		{"oci_agent_network_receive_packets_total", "Packets received.", func(c net.IOCountersStat) uint64 { return c.PacketsRecv }},
+		{"oci_agent_network_transmit_packets_total", "Packets sent.", func(c net.IOCountersStat) uint64 { return c.PacketsSent }},
+		{"oci_agent_network_receive_errors_total", "Receive errors.", func(c net.IOCountersStat) uint64 { return c.Errin }},
+		{"oci_agent_network_transmit_errors_total", "Transmit errors.", func(c net.IOCountersStat) uint64 { return c.Errout }},
+		{"oci_agent_network_receive_drop_total", "Received packets dropped.", func(c net.IOCountersStat) uint64 { return c.Dropin }},
+		{"oci_agent_network_transmit_drop_total", "Sent packets dropped.", func(c net.IOCountersStat) uint64 { return c.Dropout }},
+	}
+	for _, f := range families {
+		p.header(f.name, "counter", f.help)
+		for _, c := range counters {
+			p.sample(f.name, float64(f.value(c)), "interface", c.Name)
+		}
+	}
+}
+
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	writePrometheusMetrics(w)
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"log/slog"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+// interfaceExcluded 按 glob 规则（如 lo、docker*、veth*）过滤网卡
+func interfaceExcluded(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// interfaceIncluded 判断网卡是否输出：设置了 include 时必须匹配其一，且不能匹配 exclude
+func interfaceIncluded(name string, include, exclude []string) bool {
+	if len(include) > 0 && !interfaceExcluded(name, include) {
+		return false
+	}
+	return !interfaceExcluded(name, exclude)
+}
+
+// linkSpeedMbps 读取网卡协商速率，虚拟网卡或未连接时内核返回 -1 或读取失败，此时返回 0
+func linkSpeedMbps(name string) int64 {
+	content, err := ioutil.ReadFile(hostSys("class/net", name, "speed"))
+	if err != nil {
+		return 0
+	}
+	speed, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64)
+	if err != nil || speed < 0 {
+		return 0
+	}
+	return speed
+}
+
+type netRates struct {
+	upload, download     float64
+	sentTotal, recvTotal uint64
+	counters             net.IOCountersStat
+}
+
+func (r netRates) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"upload_speed":                 formatBytes(uint64(r.upload)),
+		"download_speed":               formatBytes(uint64(r.download)),
+		"upload_total":                 formatBytes(r.sentTotal),
+		"download_total":               formatBytes(r.recvTotal),
+		"upload_speed_bytes_per_sec":   uint64(r.upload),
+		"download_speed_bytes_per_sec": uint64(r.download),
+		"upload_total_bytes":           r.sentTotal,
+		"download_total_bytes":         r.recvTotal,
+	}
+}
+
+// interfaceMap 在速率之外补充单块网卡的包数、错误、丢包和协商速率
+func (r netRates) interfaceMap(name string) map[string]interface{} {
+	m := r.toMap()
+	m["packets_sent"] = r.counters.PacketsSent
+	m["packets_recv"] = r.counters.PacketsRecv
+	m["errors_in"] = r.counters.Errin
+	m["errors_out"] = r.counters.Errout
+	m["drops_in"] = r.counters.Dropin
+	m["drops_out"] = r.counters.Dropout
+	if speed := linkSpeedMbps(name); speed > 0 {
+		m["link_speed_mbps"] = speed
+	}
+	return m
+}
+
+// counterRate 计算两次采样之间的速率，计数器回绕或网卡重置导致 after < before 时返回 0
+func counterRate(before, after uint64, interval time.Duration) float64 {
+	if after < before || interval <= 0 {
+		return 0
+	}
+	return float64(after-before) / interval.Seconds()
+}
+
+// computeNetRates 根据前后两次按网卡的计数器计算各网卡及汇总的速率，
+// 汇总值为各网卡之和，单块网卡重置不会影响其他网卡
+func computeNetRates(before, after []net.IOCountersStat, interval time.Duration) (aggregate netRates, perInterface map[string]netRates) {
+	previous := make(map[string]net.IOCountersStat, len(before))
+	for _, c := range before {
+		previous[c.Name] = c
+	}
+	perInterface = make(map[string]netRates, len(after))
+	for _, c := range after {
+		r := netRates{sentTotal: c.BytesSent, recvTotal: c.BytesRecv, counters: c}
+		if p, ok := previous[c.Name]; ok {
+			r.upload = counterRate(p.BytesSent, c.BytesSent, interval)
+			r.download = counterRate(p.BytesRecv, c.BytesRecv, interval)
+		}
+		perInterface[c.Name] = r
+
+		aggregate.upload += r.upload
+		aggregate.download += r.download
+		aggregate.sentTotal += r.sentTotal
+		aggregate.recvTotal += r.recvTotal
+	}
+	return aggregate, perInterface
+}
+
+// netSampler 在后台持续读取网卡计数器并保存最近一个间隔的速率，上报和 API 读取时无需等待采样窗口
+type netSampler struct {
+	mu           sync.RWMutex
+	aggregate    netRates
+	perInterface map[string]netRates
+	at           time.Time
+}
+
+var backgroundNet = &netSampler{}
+
+func (s *netSampler) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	before, err := net.IOCounters(true)
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		after, aerr := net.IOCounters(true)
+		now := time.Now()
+		if aerr != nil {
+			slog.Debug("network counters unavailable", "component", "net-sampler", "err", aerr)
+			continue
+		}
+		// 按实际经过的时间计算，ticker 被阻塞时也不会高估速率
+		if err == nil {
+			aggregate, perInterface := computeNetRates(before, after, now.Sub(last))
+			s.mu.Lock()
+			s.aggregate, s.perInterface, s.at = aggregate, perInterface, now
+			s.mu.Unlock()
+		}
+		before, err, last = after, nil, now
+	}
+}
+
+// latest 返回不超过 maxAge 的最近一次采样，采样器未运行或已停滞时返回 false
+func (s *netSampler) latest(maxAge time.Duration) (netRates, map[string]netRates, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.at.IsZero() || time.Since(s.at) > maxAge {
+		return netRates{}, nil, false
+	}
+	return s.aggregate, s.perInterface, true
+}
+
+// sampleNetwork 一次采样同时得到汇总和按网卡的速率，后台采样器有新鲜数据时直接返回
+func sampleNetwork(interval time.Duration) (aggregate netRates, perInterface map[string]netRates, err error) {
+	if aggregate, perInterface, ok := backgroundNet.latest(2 * interval); ok {
+		return aggregate, perInterface, nil
+	}
+	before, err := net.IOCounters(true)
+	if err != nil {
+		return aggregate, nil, err
+	}
+	time.Sleep(interval)
+	after, err := net.IOCounters(true)
+	if err != nil {
+		return aggregate, nil, err
+	}
+	aggregate, perInterface = computeNetRates(before, after, interval)
+	return aggregate, perInterface, nil
+}
+
+func getNetworkSpeed(interval time.Duration) (upload, download float64) {
+	aggregate, _, err := sampleNetwork(interval)
+	if err != nil {
+		return 0, 0
+	}
+	return aggregate.upload, aggregate.download
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+func TestComputeNetRates(t *testing.T) {
+	before := []net.IOCountersStat{
+		{Name: "eth0", BytesSent: 1000, BytesRecv: 5000},
+		// eth1 在两次采样之间被重置，计数器归零后重新累计
+		{Name: "eth1", BytesSent: 1 << 40, BytesRecv: 1 << 40},
+	}
+	after := []net.IOCountersStat{
+		{Name: "eth0", BytesSent: 3000, BytesRecv: 9000},
+		{Name: "eth1", BytesSent: 10, BytesRecv: 20},
+		// 新出现的网卡没有上一次的计数，速率为 0
+		{Name: "eth2", BytesSent: 500, BytesRecv: 500},
+	}
+	aggregate, perInterface := computeNetRates(before, after, 2*time.Second)
+
+	tests := []struct {
+		name             string
+		upload, download float64
+	}{
+		{"eth0", 1000, 2000},
+		{"eth1", 0, 0},
+		{"eth2", 0, 0},
+	}
+	for _, tt := range tests {
+		r := perInterface[tt.name]
+		if r.upload != tt.upload || r.download != tt.download {
+			t.Errorf("%s: upload/download = %v/%v, want %v/%v", tt.name, r.upload, r.download, tt.upload, tt.download)
+		}
+	}
+	if aggregate.upload != 1000 || aggregate.download != 2000 {
+		t.Errorf("aggregate upload/download = %v/%v, want 1000/2000", aggregate.upload, aggregate.download)
+	}
+	if m := perInterface["eth1"].toMap(); m["upload_speed_bytes_per_sec"] != uint64(0) {
+		t.Errorf("reset interface upload_speed_bytes_per_sec = %v, want 0", m["upload_speed_bytes_per_sec"])
+	}
+}
+
+func TestCounterRate(t *testing.T) {
+	tests := []struct {
+		before, after uint64
+		interval      time.Duration
+		want          float64
+	}{
+		{100, 300, time.Second, 200},
+		{100, 300, 2 * time.Second, 100},
+		{300, 100, time.Second, 0},
+		{1<<64 - 1, 0, time.Second, 0},
+		{100, 300, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := counterRate(tt.before, tt.after, tt.interval); got != tt.want {
+			t.Errorf("counterRate(%d, %d, %s) = %v, want %v", tt.before, tt.after, tt.interval, got, tt.want)
+		}
+	}
+}
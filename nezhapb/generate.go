@@ -0,0 +1,4 @@
+// Package nezhapb 是哪吒监控 agent gRPC 协议的 protobuf 定义及生成代码
+package nezhapb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative nezha.proto
@@ -0,0 +1,522 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: nezha.proto
+
+// 与哪吒监控（nezhahq/agent v1）的 proto/nezha.proto 保持一致，包名和字段编号不能改动，
+// 只保留 agent 用到的消息和方法
+
+package nezhapb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Host struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Platform        string                 `protobuf:"bytes,1,opt,name=platform,proto3" json:"platform,omitempty"`
+	PlatformVersion string                 `protobuf:"bytes,2,opt,name=platform_version,json=platformVersion,proto3" json:"platform_version,omitempty"`
+	Cpu             []string               `protobuf:"bytes,3,rep,name=cpu,proto3" json:"cpu,omitempty"`
+	MemTotal        uint64                 `protobuf:"varint,4,opt,name=mem_total,json=memTotal,proto3" json:"mem_total,omitempty"`
+	DiskTotal       uint64                 `protobuf:"varint,5,opt,name=disk_total,json=diskTotal,proto3" json:"disk_total,omitempty"`
+	SwapTotal       uint64                 `protobuf:"varint,6,opt,name=swap_total,json=swapTotal,proto3" json:"swap_total,omitempty"`
+	Arch            string                 `protobuf:"bytes,7,opt,name=arch,proto3" json:"arch,omitempty"`
+	Virtualization  string                 `protobuf:"bytes,8,opt,name=virtualization,proto3" json:"virtualization,omitempty"`
+	BootTime        uint64                 `protobuf:"varint,9,opt,name=boot_time,json=bootTime,proto3" json:"boot_time,omitempty"`
+	Version         string                 `protobuf:"bytes,12,opt,name=version,proto3" json:"version,omitempty"`
+	Gpu             []string               `protobuf:"bytes,13,rep,name=gpu,proto3" json:"gpu,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Host) Reset() {
+	*x = Host{}
+	mi := &file_nezha_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Host) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Host) ProtoMessage() {}
+
+func (x *Host) ProtoReflect() protoreflect.Message {
+	mi := &file_nezha_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Host.ProtoReflect.Descriptor instead.
+func (*Host) Descriptor() ([]byte, []int) {
+	return file_nezha_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Host) GetPlatform() string {
+	if x != nil {
+		return x.Platform
+	}
+	return ""
+}
+
+func (x *Host) GetPlatformVersion() string {
+	if x != nil {
+		return x.PlatformVersion
+	}
+	return ""
+}
+
+func (x *Host) GetCpu() []string {
+	if x != nil {
+		return x.Cpu
+	}
+	return nil
+}
+
+func (x *Host) GetMemTotal() uint64 {
+	if x != nil {
+		return x.MemTotal
+	}
+	return 0
+}
+
+func (x *Host) GetDiskTotal() uint64 {
+	if x != nil {
+		return x.DiskTotal
+	}
+	return 0
+}
+
+func (x *Host) GetSwapTotal() uint64 {
+	if x != nil {
+		return x.SwapTotal
+	}
+	return 0
+}
+
+func (x *Host) GetArch() string {
+	if x != nil {
+		return x.Arch
+	}
+	return ""
+}
+
+func (x *Host) GetVirtualization() string {
+	if x != nil {
+		return x.Virtualization
+	}
+	return ""
+}
+
+func (x *Host) GetBootTime() uint64 {
+	if x != nil {
+		return x.BootTime
+	}
+	return 0
+}
+
+func (x *Host) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *Host) GetGpu() []string {
+	if x != nil {
+		return x.Gpu
+	}
+	return nil
+}
+
+type State struct {
+	state          protoimpl.MessageState     `protogen:"open.v1"`
+	Cpu            float64                    `protobuf:"fixed64,1,opt,name=cpu,proto3" json:"cpu,omitempty"`
+	MemUsed        uint64                     `protobuf:"varint,3,opt,name=mem_used,json=memUsed,proto3" json:"mem_used,omitempty"`
+	SwapUsed       uint64                     `protobuf:"varint,4,opt,name=swap_used,json=swapUsed,proto3" json:"swap_used,omitempty"`
+	DiskUsed       uint64                     `protobuf:"varint,5,opt,name=disk_used,json=diskUsed,proto3" json:"disk_used,omitempty"`
+	NetInTransfer  uint64                     `protobuf:"varint,6,opt,name=net_in_transfer,json=netInTransfer,proto3" json:"net_in_transfer,omitempty"`
+	NetOutTransfer uint64                     `protobuf:"varint,7,opt,name=net_out_transfer,json=netOutTransfer,proto3" json:"net_out_transfer,omitempty"`
+	NetInSpeed     uint64                     `protobuf:"varint,8,opt,name=net_in_speed,json=netInSpeed,proto3" json:"net_in_speed,omitempty"`
+	NetOutSpeed    uint64                     `protobuf:"varint,9,opt,name=net_out_speed,json=netOutSpeed,proto3" json:"net_out_speed,omitempty"`
+	Uptime         uint64                     `protobuf:"varint,10,opt,name=uptime,proto3" json:"uptime,omitempty"`
+	Load1          float64                    `protobuf:"fixed64,11,opt,name=load1,proto3" json:"load1,omitempty"`
+	Load5          float64                    `protobuf:"fixed64,12,opt,name=load5,proto3" json:"load5,omitempty"`
+	Load15         float64                    `protobuf:"fixed64,13,opt,name=load15,proto3" json:"load15,omitempty"`
+	TcpConnCount   uint64                     `protobuf:"varint,14,opt,name=tcp_conn_count,json=tcpConnCount,proto3" json:"tcp_conn_count,omitempty"`
+	UdpConnCount   uint64                     `protobuf:"varint,15,opt,name=udp_conn_count,json=udpConnCount,proto3" json:"udp_conn_count,omitempty"`
+	ProcessCount   uint64                     `protobuf:"varint,16,opt,name=process_count,json=processCount,proto3" json:"process_count,omitempty"`
+	Temperatures   []*State_SensorTemperature `protobuf:"bytes,17,rep,name=temperatures,proto3" json:"temperatures,omitempty"`
+	Gpu            []float64                  `protobuf:"fixed64,18,rep,packed,name=gpu,proto3" json:"gpu,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *State) Reset() {
+	*x = State{}
+	mi := &file_nezha_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *State) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*State) ProtoMessage() {}
+
+func (x *State) ProtoReflect() protoreflect.Message {
+	mi := &file_nezha_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use State.ProtoReflect.Descriptor instead.
+func (*State) Descriptor() ([]byte, []int) {
+	return file_nezha_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *State) GetCpu() float64 {
+	if x != nil {
+		return x.Cpu
+	}
+	return 0
+}
+
+func (x *State) GetMemUsed() uint64 {
+	if x != nil {
+		return x.MemUsed
+	}
+	return 0
+}
+
+func (x *State) GetSwapUsed() uint64 {
+	if x != nil {
+		return x.SwapUsed
+	}
+	return 0
+}
+
+func (x *State) GetDiskUsed() uint64 {
+	if x != nil {
+		return x.DiskUsed
+	}
+	return 0
+}
+
+func (x *State) GetNetInTransfer() uint64 {
+	if x != nil {
+		return x.NetInTransfer
+	}
+	return 0
+}
+
+func (x *State) GetNetOutTransfer() uint64 {
+	if x != nil {
+		return x.NetOutTransfer
+	}
+	return 0
+}
+
+func (x *State) GetNetInSpeed() uint64 {
+	if x != nil {
+		return x.NetInSpeed
+	}
+	return 0
+}
+
+func (x *State) GetNetOutSpeed() uint64 {
+	if x != nil {
+		return x.NetOutSpeed
+	}
+	return 0
+}
+
+func (x *State) GetUptime() uint64 {
+	if x != nil {
+		return x.Uptime
+	}
+	return 0
+}
+
+func (x *State) GetLoad1() float64 {
+	if x != nil {
+		return x.Load1
+	}
+	return 0
+}
+
+func (x *State) GetLoad5() float64 {
+	if x != nil {
+		return x.Load5
+	}
+	return 0
+}
+
+func (x *State) GetLoad15() float64 {
+	if x != nil {
+		return x.Load15
+	}
+	return 0
+}
+
+func (x *State) GetTcpConnCount() uint64 {
+	if x != nil {
+		return x.TcpConnCount
+	}
+	return 0
+}
+
+func (x *State) GetUdpConnCount() uint64 {
+	if x != nil {
+		return x.UdpConnCount
+	}
+	return 0
+}
+
+func (x *State) GetProcessCount() uint64 {
+	if x != nil {
+		return x.ProcessCount
+	}
+	return 0
+}
+
+func (x *State) GetTemperatures() []*State_SensorTemperature {
+	if x != nil {
+		return x.Temperatures
+	}
+	return nil
+}
+
+func (x *State) GetGpu() []float64 {
+	if x != nil {
+		return x.Gpu
+	}
+	return nil
+}
+
+type State_SensorTemperature struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Temperature   float64                `protobuf:"fixed64,2,opt,name=temperature,proto3" json:"temperature,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *State_SensorTemperature) Reset() {
+	*x = State_SensorTemperature{}
+	mi := &file_nezha_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *State_SensorTemperature) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*State_SensorTemperature) ProtoMessage() {}
+
+func (x *State_SensorTemperature) ProtoReflect() protoreflect.Message {
+	mi := &file_nezha_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use State_SensorTemperature.ProtoReflect.Descriptor instead.
+func (*State_SensorTemperature) Descriptor() ([]byte, []int) {
+	return file_nezha_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *State_SensorTemperature) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *State_SensorTemperature) GetTemperature() float64 {
+	if x != nil {
+		return x.Temperature
+	}
+	return 0
+}
+
+type Receipt struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Proced        bool                   `protobuf:"varint,1,opt,name=proced,proto3" json:"proced,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Receipt) Reset() {
+	*x = Receipt{}
+	mi := &file_nezha_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Receipt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Receipt) ProtoMessage() {}
+
+func (x *Receipt) ProtoReflect() protoreflect.Message {
+	mi := &file_nezha_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Receipt.ProtoReflect.Descriptor instead.
+func (*Receipt) Descriptor() ([]byte, []int) {
+	return file_nezha_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Receipt) GetProced() bool {
+	if x != nil {
+		return x.Proced
+	}
+	return false
+}
+
+var File_nezha_proto protoreflect.FileDescriptor
+
+const file_nezha_proto_rawDesc = "" +
+	"\n" +
+	"\vnezha.proto\x12\x05proto\"\xcb\x02\n" +
+	"\x04Host\x12\x1a\n" +
+	"\bplatform\x18\x01 \x01(\tR\bplatform\x12)\n" +
+	"\x10platform_version\x18\x02 \x01(\tR\x0fplatformVersion\x12\x10\n" +
+	"\x03cpu\x18\x03 \x03(\tR\x03cpu\x12\x1b\n" +
+	"\tmem_total\x18\x04 \x01(\x04R\bmemTotal\x12\x1d\n" +
+	"\n" +
+	"disk_total\x18\x05 \x01(\x04R\tdiskTotal\x12\x1d\n" +
+	"\n" +
+	"swap_total\x18\x06 \x01(\x04R\tswapTotal\x12\x12\n" +
+	"\x04arch\x18\a \x01(\tR\x04arch\x12&\n" +
+	"\x0evirtualization\x18\b \x01(\tR\x0evirtualization\x12\x1b\n" +
+	"\tboot_time\x18\t \x01(\x04R\bbootTime\x12\x18\n" +
+	"\aversion\x18\f \x01(\tR\aversion\x12\x10\n" +
+	"\x03gpu\x18\r \x03(\tR\x03gpuJ\x04\b\n" +
+	"\x10\vJ\x04\b\v\x10\f\"\xaf\x04\n" +
+	"\x05State\x12\x10\n" +
+	"\x03cpu\x18\x01 \x01(\x01R\x03cpu\x12\x19\n" +
+	"\bmem_used\x18\x03 \x01(\x04R\amemUsed\x12\x1b\n" +
+	"\tswap_used\x18\x04 \x01(\x04R\bswapUsed\x12\x1b\n" +
+	"\tdisk_used\x18\x05 \x01(\x04R\bdiskUsed\x12&\n" +
+	"\x0fnet_in_transfer\x18\x06 \x01(\x04R\rnetInTransfer\x12(\n" +
+	"\x10net_out_transfer\x18\a \x01(\x04R\x0enetOutTransfer\x12 \n" +
+	"\fnet_in_speed\x18\b \x01(\x04R\n" +
+	"netInSpeed\x12\"\n" +
+	"\rnet_out_speed\x18\t \x01(\x04R\vnetOutSpeed\x12\x16\n" +
+	"\x06uptime\x18\n" +
+	" \x01(\x04R\x06uptime\x12\x14\n" +
+	"\x05load1\x18\v \x01(\x01R\x05load1\x12\x14\n" +
+	"\x05load5\x18\f \x01(\x01R\x05load5\x12\x16\n" +
+	"\x06load15\x18\r \x01(\x01R\x06load15\x12$\n" +
+	"\x0etcp_conn_count\x18\x0e \x01(\x04R\ftcpConnCount\x12$\n" +
+	"\x0eudp_conn_count\x18\x0f \x01(\x04R\fudpConnCount\x12#\n" +
+	"\rprocess_count\x18\x10 \x01(\x04R\fprocessCount\x12B\n" +
+	"\ftemperatures\x18\x11 \x03(\v2\x1e.proto.State_SensorTemperatureR\ftemperatures\x12\x10\n" +
+	"\x03gpu\x18\x12 \x03(\x01R\x03gpuJ\x04\b\x02\x10\x03\"O\n" +
+	"\x17State_SensorTemperature\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vtemperature\x18\x02 \x01(\x01R\vtemperature\"!\n" +
+	"\aReceipt\x12\x16\n" +
+	"\x06proced\x18\x01 \x01(\bR\x06proced2z\n" +
+	"\fNezhaService\x127\n" +
+	"\x11ReportSystemState\x12\f.proto.State\x1a\x0e.proto.Receipt\"\x00(\x010\x01\x121\n" +
+	"\x10ReportSystemInfo\x12\v.proto.Host\x1a\x0e.proto.Receipt\"\x00B\x13Z\x11oci-agent/nezhapbb\x06proto3"
+
+var (
+	file_nezha_proto_rawDescOnce sync.Once
+	file_nezha_proto_rawDescData []byte
+)
+
+func file_nezha_proto_rawDescGZIP() []byte {
+	file_nezha_proto_rawDescOnce.Do(func() {
+		file_nezha_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_nezha_proto_rawDesc), len(file_nezha_proto_rawDesc)))
+	})
+	return file_nezha_proto_rawDescData
+}
+
+var file_nezha_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_nezha_proto_goTypes = []any{
+	(*Host)(nil),                    // 0: proto.Host
+	(*State)(nil),                   // 1: proto.State
+	(*State_SensorTemperature)(nil), // 2: proto.State_SensorTemperature
+	(*Receipt)(nil),                 // 3: proto.Receipt
+}
+var file_nezha_proto_depIdxs = []int32{
+	2, // 0: proto.State.temperatures:type_name -> proto.State_SensorTemperature
+	1, // 1: proto.NezhaService.ReportSystemState:input_type -> proto.State
+	0, // 2: proto.NezhaService.ReportSystemInfo:input_type -> proto.Host
+	3, // 3: proto.NezhaService.ReportSystemState:output_type -> proto.Receipt
+	3, // 4: proto.NezhaService.ReportSystemInfo:output_type -> proto.Receipt
+	3, // [3:5] is the sub-list for method output_type
+	1, // [1:3] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_nezha_proto_init() }
+func file_nezha_proto_init() {
+	if File_nezha_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_nezha_proto_rawDesc), len(file_nezha_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_nezha_proto_goTypes,
+		DependencyIndexes: file_nezha_proto_depIdxs,
+		MessageInfos:      file_nezha_proto_msgTypes,
+	}.Build()
+	File_nezha_proto = out.File
+	file_nezha_proto_goTypes = nil
+	file_nezha_proto_depIdxs = nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// 默认的告警消息模板，字段与 alertEvent 相同
+const defaultNotifyTemplate = `[{{.Severity}}] {{.Hostname}}: {{.Rule}} {{.Status}}, ` +
+	`{{if .To}}{{.Metric}} {{.From}} -> {{.To}}{{else}}{{.Metric}} = {{.Value}} (threshold {{.Threshold}}){{end}}`
+
+// notifier 把告警事件推送到外部服务，title 为简短标题，text 为按模板渲染后的正文
+type notifier interface {
+	Name() string
+	Notify(ev alertEvent, title, text string) error
+}
+
+// 通知发往第三方服务，不使用面向控制端的 mTLS 客户端，启动时按 -proxy 重新创建
+var notifyClient = newHTTPClient(10*time.Second, nil)
+
+func notifyPost(endpoint, contentType string, body []byte) error {
+	resp, err := notifyClient.Post(endpoint, contentType, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &statusError{code: resp.StatusCode}
+	}
+	return nil
+}
+
+// webhookNotifier POST 告警事件的 JSON，附带渲染后的 message
+type webhookNotifier struct{ url string }
+
+func (n webhookNotifier) Name() string { return n.url }
+
+func (n webhookNotifier) Notify(ev alertEvent, title, text string) error {
+	body, err := json.Marshal(struct {
+		alertEvent
+		Message string `json:"message"`
+	}{ev, text})
+	if err != nil {
+		return err
+	}
+	return notifyPost(n.url, "application/json", body)
+}
+
+type telegramNotifier struct{ token, chatID string }
+
+func (n telegramNotifier) Name() string { return "telegram" }
+
+func (n telegramNotifier) Notify(ev alertEvent, title, text string) error {
+	body, err := json.Marshal(map[string]string{"chat_id": n.chatID, "text": text})
+	if err != nil {
+		return err
+	}
+	return redactSecret(notifyPost("https://api.telegram.org/bot"+n.token+"/sendMessage", "application/json", body), n.token)
+}
+
+// barkNotifier 的 url 为 Bark 推送地址，如 https://api.day.app/KEY，也可以是自建服务
+type barkNotifier struct{ url string }
+
+func (n barkNotifier) Name() string { return "bark" }
+
+func (n barkNotifier) Notify(ev alertEvent, title, text string) error {
+	body, err := json.Marshal(map[string]string{"title": title, "body": text, "group": "oci-agent"})
+	if err != nil {
+		return err
+	}
+	return notifyPost(n.url, "application/json; charset=utf-8", body)
+}
+
+type serverChanNotifier struct{ sendKey string }
+
+func (n serverChanNotifier) Name() string { return "serverchan" }
+
+func (n serverChanNotifier) Notify(ev alertEvent, title, text string) error {
+	form := url.Values{"title": {title}, "desp": {text}}
+	return redactSecret(notifyPost("https://sctapi.ftqq.com/"+n.sendKey+".send", "application/x-www-form-urlencoded", []byte(form.Encode())), n.sendKey)
+}
+
+// redactSecret 密钥位于请求 URL 中，net/http 的错误会带上完整 URL，写日志前去掉
+func redactSecret(err error, secret string) error {
+	if err == nil || secret == "" {
+		return err
+	}
+	return fmt.Errorf("%s", strings.ReplaceAll(err.Error(), secret, "***"))
+}
+
+func checkHTTPURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an http(s) URL", raw)
+	}
+	return nil
+}
+
+var (
+	notifiers      []notifier
+	notifyTemplate = template.Must(template.New("notify").Parse(defaultNotifyTemplate))
+	notifyPending  sync.WaitGroup
+)
+
+// buildNotifiers 根据配置创建通知渠道，Telegram 的 token 和 chat id 必须同时设置
+func buildNotifiers() ([]notifier, error) {
+	var list []notifier
+	for _, u := range cfg.NotifyWebhooks {
+		if err := checkHTTPURL(u); err != nil {
+			return nil, fmt.Errorf("notify-webhook: %w", err)
+		}
+		list = append(list, webhookNotifier{u})
+	}
+	if (cfg.TelegramToken == "") != (cfg.TelegramChatID == "") {
+		return nil, fmt.Errorf("notify-telegram-token and notify-telegram-chat must be set together")
+	}
+	if cfg.TelegramToken != "" {
+		list = append(list, telegramNotifier{cfg.TelegramToken, cfg.TelegramChatID})
+	}
+	if cfg.BarkURL != "" {
+		if err := checkHTTPURL(cfg.BarkURL); err != nil {
+			return nil, fmt.Errorf("notify-bark: %w", err)
+		}
+		list = append(list, barkNotifier{strings.TrimRight(cfg.BarkURL, "/")})
+	}
+	if cfg.ServerChanKey != "" {
+		list = append(list, serverChanNotifier{cfg.ServerChanKey})
+	}
+	return list, nil
+}
+
+// sendNotifications 在后台推送，网络慢时不影响采集周期
+func sendNotifications(ev alertEvent) {
+	if len(notifiers) == 0 {
+		return
+	}
+	var text bytes.Buffer
+	if err := notifyTemplate.Execute(&text, ev); err != nil {
+		slog.Error("render alert message failed", "component", "notify", "err", err)
+		return
+	}
+	title := fmt.Sprintf("[%s] %s %s on %s", ev.Severity, ev.Rule, ev.Status, ev.Hostname)
+	for _, n := range notifiers {
+		notifyPending.Add(1)
+		go func(n notifier) {
+			defer notifyPending.Done()
+			if err := n.Notify(ev, title, text.String()); err != nil {
+				slog.Warn("alert notification failed", "component", "notify", "notifier", n.Name(), "rule", ev.Rule, "err", err)
+			}
+		}(n)
+	}
+}
+
+// waitNotifications 退出前等待正在发送的通知，最多等待 timeout
+func waitNotifications(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		notifyPending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
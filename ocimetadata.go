@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// OCI 实例元数据服务 v2，要求带 Authorization: Bearer Oracle 请求头
+const ociMetadataURL = "http://169.254.169.254/opc/v2/instance/"
+
+// 链路本地地址，不能走代理；非 OCI 主机上连接会很快失败或超时
+var ociMetadataClient = &http.Client{
+	Timeout:   2 * time.Second,
+	Transport: &http.Transport{Proxy: nil},
+}
+
+type ociInstance struct {
+	ID                 string                            `json:"id"`
+	DisplayName        string                            `json:"displayName"`
+	Shape              string                            `json:"shape"`
+	Region             string                            `json:"region"`
+	CanonicalRegion    string                            `json:"canonicalRegionName"`
+	AvailabilityDomain string                            `json:"availabilityDomain"`
+	FaultDomain        string                            `json:"faultDomain"`
+	CompartmentID      string                            `json:"compartmentId"`
+	Image              string                            `json:"image"`
+	FreeformTags       map[string]string                 `json:"freeformTags"`
+	DefinedTags        map[string]map[string]interface{} `json:"definedTags"`
+	ShapeConfig        *struct {
+		OCPUs       float64 `json:"ocpus"`
+		MemoryInGBs float64 `json:"memoryInGBs"`
+	} `json:"shapeConfig"`
+}
+
+func fetchOCIMetadata(url string) (*ociInstance, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer Oracle")
+	resp, err := ociMetadataClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("metadata service returned %d", resp.StatusCode)
+	}
+	var inst ociInstance
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&inst); err != nil {
+		return nil, fmt.Errorf("decode metadata: %w", err)
+	}
+	return &inst, nil
+}
+
+func (inst *ociInstance) toMap() map[string]interface{} {
+	region := inst.CanonicalRegion
+	if region == "" {
+		region = inst.Region
+	}
+	m := map[string]interface{}{
+		"instance_id":         inst.ID,
+		"display_name":        inst.DisplayName,
+		"shape":               inst.Shape,
+		"region":              region,
+		"availability_domain": inst.AvailabilityDomain,
+		"fault_domain":        inst.FaultDomain,
+		"compartment_id":      inst.CompartmentID,
+		"image_id":            inst.Image,
+	}
+	if inst.ShapeConfig != nil {
+		m["ocpus"] = inst.ShapeConfig.OCPUs
+		m["memory_gb"] = inst.ShapeConfig.MemoryInGBs
+	}
+	if len(inst.FreeformTags) > 0 {
+		m["freeform_tags"] = inst.FreeformTags
+	}
+	if len(inst.DefinedTags) > 0 {
+		m["defined_tags"] = inst.DefinedTags
+	}
+	return m
+}
+
+// ociMetadataCache 与 publicIPCache 相同，按 refresh 缓存结果；获取失败也会缓存，
+// 非 OCI 主机不会每轮都等待超时
+type ociMetadataCache struct {
+	mu      sync.Mutex
+	fetched time.Time
+	value   map[string]interface{}
+}
+
+var ociMetadata = &ociMetadataCache{}
+
+func (c *ociMetadataCache) get(refresh time.Duration) map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.fetched.IsZero() && time.Since(c.fetched) < refresh {
+		return c.value
+	}
+	inst, err := fetchOCIMetadata(ociMetadataURL)
+	c.fetched = time.Now()
+	if err != nil {
+		slog.Debug("oci metadata unavailable", "component", "oci-metadata", "err", err)
+		// 已经拿到过的元数据在服务短暂不可用时继续使用
+		return c.value
+	}
+	c.value = inst.toMap()
+	return c.value
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// copyPayload 深拷贝嵌套的 map，避免序列化时的单位换算修改原始数据
+func copyPayload(data map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		if sub, ok := v.(map[string]interface{}); ok {
+			out[k] = copyPayload(sub)
+		} else {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func marshalPayload(data map[string]interface{}, indent bool) ([]byte, error) {
+	if !cfg.FormattedBytes || len(unitConversions) > 0 {
+		data = copyPayload(data)
+	}
+	if !cfg.FormattedBytes {
+		stripFormattedBytes(data)
+	}
+	if len(unitConversions) > 0 {
+		applyUnitConversions(data, unitConversions)
+	}
+	if indent {
+		return json.MarshalIndent(data, "", "  ")
+	}
+	return json.Marshal(data)
+}
+
+// rawPayload 返回按原始单位解码的 payload：去掉格式化字符串但不应用 -convert，
+// 供把数值写入固定单位字段的协议（哪吒、gRPC）使用，数值统一为 JSON 解码后的 float64
+func rawPayload(data map[string]interface{}) (map[string]interface{}, error) {
+	data = copyPayload(data)
+	stripFormattedBytes(data)
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var info map[string]interface{}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// formattedBytesSuffixes 是格式化字符串对应的原始数值字段后缀，如 total 与 total_bytes
+var formattedBytesSuffixes = []string{"_bytes", "_bytes_per_sec"}
+
+// stripFormattedBytes 删除已有原始数值字段的 "1.50G" 形式字符串，只保留机器可读的值
+func stripFormattedBytes(data map[string]interface{}) {
+	for k, v := range data {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			stripFormattedBytes(v)
+		case string:
+			for _, suffix := range formattedBytesSuffixes {
+				if _, ok := data[k+suffix]; ok {
+					delete(data, k)
+					break
+				}
+			}
+		}
+	}
+}
+
+// 上报数据与心跳必须包含的字段，测试采集端用它来校验收到的 JSON
+var (
+	reportRequiredFields    = []string{"platform", "architecture", "cpu", "memory", "current_time"}
+	heartbeatRequiredFields = []string{"status", "timestamp"}
+)
+
+func validatePayload(data map[string]interface{}) (kind string, err error) {
+	kind, required := "report", reportRequiredFields
+	if _, ok := data["status"]; ok {
+		kind, required = "heartbeat", heartbeatRequiredFields
+	}
+	var missing []string
+	for _, field := range required {
+		if _, ok := data[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return kind, fmt.Errorf("%s is missing fields: %s", kind, strings.Join(missing, ", "))
+	}
+	return kind, nil
+}
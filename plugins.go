@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pluginDefaultTimeout 是未通过 -plugin-timeout 指定时单次执行的超时
+const pluginDefaultTimeout = 10 * time.Second
+
+// pluginMaxOutput 限制插件 stdout 的大小，超出视为失败，避免异常插件撑大上报数据
+const pluginMaxOutput = 1 << 20
+
+// plugin 是一个 -plugin 外部命令，stdout 须为 JSON 对象，合并到上报的 custom.<name>
+type plugin struct {
+	name     string
+	command  string
+	timeout  time.Duration
+	interval time.Duration
+}
+
+func compilePlugins(commands, timeouts, intervals map[string]string, defaultInterval time.Duration) ([]plugin, error) {
+	for _, opts := range []struct {
+		flag   string
+		values map[string]string
+	}{{"plugin-timeout", timeouts}, {"plugin-interval", intervals}} {
+		for name := range opts.values {
+			if _, ok := commands[name]; !ok {
+				return nil, fmt.Errorf("%s: no -plugin named %q", opts.flag, name)
+			}
+		}
+	}
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	plugins := make([]plugin, 0, len(names))
+	for _, name := range names {
+		p := plugin{name: name, command: commands[name], timeout: pluginDefaultTimeout, interval: defaultInterval}
+		if strings.TrimSpace(p.command) == "" {
+			return nil, fmt.Errorf("plugin %s: empty command", name)
+		}
+		if v, ok := timeouts[name]; ok {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("plugin-timeout %s: invalid duration %q", name, v)
+			}
+			p.timeout = d
+		}
+		if v, ok := intervals[name]; ok {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("plugin-interval %s: invalid duration %q", name, v)
+			}
+			p.interval = d
+		}
+		plugins = append(plugins, p)
+	}
+	return plugins, nil
+}
+
+var pluginList []plugin
+
+// limitedWriter 超过上限后报错，使插件的输出在超限时被丢弃
+type limitedWriter struct {
+	buf bytes.Buffer
+	max int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.buf.Len()+len(p) > w.max {
+		return 0, fmt.Errorf("output exceeds %d bytes", w.max)
+	}
+	return w.buf.Write(p)
+}
+
+// execPlugin 通过 shell 执行插件命令，与 -traffic-quota-command 一致；环境变量 OCI_AGENT_PLUGIN 为插件名
+func execPlugin(p plugin) (map[string]interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", p.command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", p.command)
+	}
+	cmd.Env = append(os.Environ(), "OCI_AGENT_PLUGIN="+p.name)
+	// 后台子进程继承了 stdout 时，超时后不再等待管道关闭
+	cmd.WaitDelay = time.Second
+	stdout := &limitedWriter{max: pluginMaxOutput}
+	var stderr limitedBuffer
+	cmd.Stdout, cmd.Stderr = stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("timed out after %s", p.timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, err
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(stdout.buf.Bytes(), &out); err != nil {
+		return nil, fmt.Errorf("stdout is not a JSON object: %v", err)
+	}
+	return out, nil
+}
+
+// pluginRunner 按各插件自己的 interval 在后台执行，采集时只读取最近一次的结果，
+// 慢插件不会拖住 -collector-timeout
+type pluginRunner struct {
+	mu      sync.RWMutex
+	results map[string]map[string]interface{}
+}
+
+var plugins = &pluginRunner{results: map[string]map[string]interface{}{}}
+
+func (r *pluginRunner) runOne(p plugin) {
+	out, err := execPlugin(p)
+	if err != nil {
+		slog.Warn("plugin failed", "component", "plugins", "plugin", p.name, "err", err)
+		out = map[string]interface{}{"error": err.Error()}
+	}
+	r.mu.Lock()
+	r.results[p.name] = out
+	r.mu.Unlock()
+}
+
+// round 并发执行所有插件一次，-once 模式下在采集前同步调用
+func (r *pluginRunner) round(list []plugin) {
+	var wg sync.WaitGroup
+	for _, p := range list {
+		wg.Add(1)
+		go func(p plugin) {
+			defer wg.Done()
+			r.runOne(p)
+		}(p)
+	}
+	wg.Wait()
+}
+
+func (r *pluginRunner) run(ctx context.Context, list []plugin) {
+	for _, p := range list {
+		go func(p plugin) {
+			ticker := time.NewTicker(p.interval)
+			defer ticker.Stop()
+			for {
+				r.runOne(p)
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(p)
+	}
+}
+
+// getCustom 返回各插件最近一次的输出，失败的插件只有 error 字段
+func getCustom() map[string]interface{} {
+	plugins.mu.RLock()
+	defer plugins.mu.RUnlock()
+	if len(plugins.results) == 0 {
+		return nil
+	}
+	out := make(map[string]interface{}, len(plugins.results))
+	for name, v := range plugins.results {
+		out[name] = v
+	}
+	return out
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// parsePressure 解析 /proc/pressure/* 的内容：
+//
+//	some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//
+// total 是累计停顿的微秒数，avg 为最近 10/60/300 秒内停顿时间的百分比
+func parsePressure(content string) map[string]interface{} {
+	out := make(map[string]interface{})
+	for _, line := range strings.Split(strings.TrimSpace(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || (fields[0] != "some" && fields[0] != "full") {
+			continue
+		}
+		values := make(map[string]interface{})
+		for _, kv := range fields[1:] {
+			k, v, ok := strings.Cut(kv, "=")
+			if !ok {
+				continue
+			}
+			if k == "total" {
+				if n, err := strconv.ParseUint(v, 10, 64); err == nil {
+					values["total_stall_us"] = n
+				}
+			} else if f, err := strconv.ParseFloat(v, 64); err == nil {
+				values[k] = f
+			}
+		}
+		out[fields[0]] = values
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// getPressure 读取 CPU、内存和 IO 的 PSI，需要内核 4.20+ 且未以 psi=0 启动，否则返回 nil。
+// 1 vCPU 的实例上 load average 很容易被 IO 等待抬高，PSI 能直接反映任务因资源不足而等待的时间
+func getPressure() map[string]interface{} {
+	out := make(map[string]interface{})
+	for _, resource := range []string{"cpu", "memory", "io"} {
+		content, err := ioutil.ReadFile(hostProc("pressure", resource))
+		if err != nil {
+			continue
+		}
+		if p := parsePressure(string(content)); p != nil {
+			out[resource] = p
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// probeTarget 是一个 -probe 目标，method 为 icmp 或 tcp，tcp 的 address 带端口
+type probeTarget struct {
+	name    string
+	method  string
+	address string
+}
+
+// parseProbeTarget 解析 icmp://HOST、tcp://HOST:PORT，不带协议时按 icmp 处理
+func parseProbeTarget(name, spec string) (probeTarget, error) {
+	t := probeTarget{name: name, method: "icmp", address: spec}
+	if strings.Contains(spec, "://") {
+		u, err := url.Parse(spec)
+		if err != nil {
+			return t, err
+		}
+		t.method, t.address = u.Scheme, u.Host
+		if t.method == "icmp" {
+			t.address = u.Hostname()
+		}
+	}
+	switch t.method {
+	case "icmp":
+		if t.address == "" || strings.ContainsAny(t.address, "/:") && net.ParseIP(t.address) == nil {
+			return t, fmt.Errorf("invalid icmp target %q, expected a host name or IP", spec)
+		}
+	case "tcp":
+		if _, port, err := net.SplitHostPort(t.address); err != nil || port == "" {
+			return t, fmt.Errorf("invalid tcp target %q, expected tcp://HOST:PORT", spec)
+		}
+	default:
+		return t, fmt.Errorf("unsupported probe %q, expected icmp://HOST or tcp://HOST:PORT", spec)
+	}
+	return t, nil
+}
+
+func compileProbeTargets(specs map[string]string) ([]probeTarget, error) {
+	names := make([]string, 0, len(specs))
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	targets := make([]probeTarget, 0, len(names))
+	for _, name := range names {
+		t, err := parseProbeTarget(name, specs[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+var probeTargets []probeTarget
+
+// icmpPinger 优先使用 raw socket（需要 root 或 CAP_NET_RAW），失败时退回 Linux 的非特权 ICMP socket
+// （受 net.ipv4.ping_group_range 控制）。raw socket 会收到本机所有的 echo 回复，
+// 每个目标使用各自随机的 ID，并且只接受来自目标地址的回复
+type icmpPinger struct {
+	conn       *icmp.PacketConn
+	privileged bool
+	ipv6       bool
+	id         int
+}
+
+func newICMPPinger(ip net.IP) (*icmpPinger, error) {
+	network, address, unprivileged := "ip4:icmp", "0.0.0.0", "udp4"
+	if ip.To4() == nil {
+		network, address, unprivileged = "ip6:ipv6-icmp", "::", "udp6"
+	}
+	p := &icmpPinger{ipv6: ip.To4() == nil, privileged: true, id: rand.Intn(0x10000)}
+	conn, err := icmp.ListenPacket(network, address)
+	if err != nil {
+		if conn, err = icmp.ListenPacket(unprivileged, address); err != nil {
+			return nil, fmt.Errorf("open icmp socket (needs root, CAP_NET_RAW or net.ipv4.ping_group_range): %w", err)
+		}
+		p.privileged = false
+	}
+	p.conn = conn
+	return p, nil
+}
+
+// ping 发送一个 echo 请求并等待对应的回复
+func (p *icmpPinger) ping(ip net.IP, seq int, timeout time.Duration) (time.Duration, error) {
+	var typ icmp.Type = ipv4.ICMPTypeEcho
+	proto := 1
+	if p.ipv6 {
+		typ, proto = ipv6.ICMPTypeEchoRequest, 58
+	}
+	msg := icmp.Message{Type: typ, Body: &icmp.Echo{ID: p.id, Seq: seq, Data: []byte("oci-agent")}}
+	body, err := msg.Marshal(nil)
+	if err != nil {
+		return 0, err
+	}
+	var dst net.Addr = &net.IPAddr{IP: ip}
+	if !p.privileged {
+		dst = &net.UDPAddr{IP: ip}
+	}
+	start := time.Now()
+	if _, err := p.conn.WriteTo(body, dst); err != nil {
+		return 0, err
+	}
+	p.conn.SetReadDeadline(start.Add(timeout))
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := p.conn.ReadFrom(buf)
+		if err != nil {
+			return 0, err
+		}
+		reply, err := icmp.ParseMessage(proto, buf[:n])
+		if err != nil {
+			continue
+		}
+		if p.isReply(reply, peer, ip, seq) {
+			return time.Since(start), nil
+		}
+	}
+}
+
+// isReply 判断收到的消息是否为本次请求的回复：来源必须是目标地址，序号一致；
+// 非特权 socket 的 ID 由内核改写且内核已按 socket 分发回复，只有 raw socket 需要比较 ID
+func (p *icmpPinger) isReply(reply *icmp.Message, peer net.Addr, ip net.IP, seq int) bool {
+	echo, ok := reply.Body.(*icmp.Echo)
+	if !ok || (reply.Type != ipv4.ICMPTypeEchoReply && reply.Type != ipv6.ICMPTypeEchoReply) {
+		return false
+	}
+	var from net.IP
+	switch a := peer.(type) {
+	case *net.IPAddr:
+		from = a.IP
+	case *net.UDPAddr:
+		from = a.IP
+	}
+	if !from.Equal(ip) {
+		return false
+	}
+	return echo.Seq == seq && (!p.privileged || echo.ID == p.id)
+}
+
+// probeResult 是一个目标最近一轮探测的结果
+type probeResult struct {
+	target   probeTarget
+	sent     int
+	rtts     []time.Duration
+	lastErr  error
+	measured time.Time
+}
+
+func (r probeResult) toMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"target":       r.target.address,
+		"method":       r.target.method,
+		"sent":         r.sent,
+		"received":     len(r.rtts),
+		"loss_percent": percentOf(uint64(r.sent-len(r.rtts)), uint64(r.sent)),
+		"measured_at":  r.measured.Format("2006-01-02 15:04:05"),
+	}
+	if len(r.rtts) > 0 {
+		sorted := append([]time.Duration(nil), r.rtts...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		var sum time.Duration
+		for _, d := range sorted {
+			sum += d
+		}
+		ms := func(d time.Duration) float64 { return math.Round(float64(d)/float64(time.Millisecond)*100) / 100 }
+		// p95 取最近秩，样本少时等于最大值
+		p95 := sorted[int(math.Ceil(0.95*float64(len(sorted))))-1]
+		m["min_ms"] = ms(sorted[0])
+		m["avg_ms"] = ms(sum / time.Duration(len(sorted)))
+		m["max_ms"] = ms(sorted[len(sorted)-1])
+		m["p95_ms"] = ms(p95)
+	}
+	if r.lastErr != nil && len(r.rtts) < r.sent {
+		m["error"] = r.lastErr.Error()
+	}
+	return m
+}
+
+// probeOnce 对一个目标连续探测 count 次，每次间隔 200ms，单次超时为 timeout
+func probeOnce(t probeTarget, count int, timeout time.Duration) probeResult {
+	r := probeResult{target: t, sent: count, measured: time.Now()}
+	var pinger *icmpPinger
+	var ip net.IP
+	if t.method == "icmp" {
+		addrs, err := net.LookupIP(t.address)
+		if err != nil || len(addrs) == 0 {
+			r.lastErr = fmt.Errorf("resolve %s: %v", t.address, err)
+			return r
+		}
+		ip = addrs[0]
+		if pinger, err = newICMPPinger(ip); err != nil {
+			r.lastErr = err
+			return r
+		}
+		defer pinger.conn.Close()
+	}
+	for seq := 1; seq <= count; seq++ {
+		if seq > 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		var rtt time.Duration
+		var err error
+		if pinger != nil {
+			rtt, err = pinger.ping(ip, seq, timeout)
+		} else {
+			start := time.Now()
+			var conn net.Conn
+			if conn, err = net.DialTimeout("tcp", t.address, timeout); err == nil {
+				rtt = time.Since(start)
+				conn.Close()
+			}
+		}
+		if err != nil {
+			r.lastErr = err
+			continue
+		}
+		r.rtts = append(r.rtts, rtt)
+	}
+	return r
+}
+
+// prober 在后台按 -probe-interval 探测所有目标，采集时只读取最近一轮结果
+type prober struct {
+	mu      sync.RWMutex
+	results map[string]probeResult
+}
+
+var probes = &prober{results: map[string]probeResult{}}
+
+// round 并发探测所有目标，-once 模式下在采集前同步执行一轮
+func (p *prober) round(targets []probeTarget, count int) {
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		wg.Add(1)
+		go func(t probeTarget) {
+			defer wg.Done()
+			r := probeOnce(t, count, cfg.ProbeTimeout)
+			if r.lastErr != nil && len(r.rtts) == 0 {
+				slog.Debug("probe failed", "component", "prober", "target", t.name, "err", r.lastErr)
+			}
+			p.mu.Lock()
+			p.results[t.name] = r
+			p.mu.Unlock()
+		}(t)
+	}
+	wg.Wait()
+}
+
+func (p *prober) run(ctx context.Context, targets []probeTarget, interval time.Duration, count int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		p.round(targets, count)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// getLatency 返回各 -probe 目标最近一轮的延迟和丢包，尚无结果或未配置目标时返回 nil
+func getLatency() map[string]interface{} {
+	probes.mu.RLock()
+	defer probes.mu.RUnlock()
+	if len(probes.results) == 0 {
+		return nil
+	}
+	out := make(map[string]interface{}, len(probes.results))
+	for name, r := range probes.results {
+		out[name] = r.toMap()
+	}
+	return out
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net"
+	"testing"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+func TestICMPReplyMatching(t *testing.T) {
+	target := net.ParseIP("192.0.2.10")
+	reply := func(id, seq int) *icmp.Message {
+		return &icmp.Message{Type: ipv4.ICMPTypeEchoReply, Body: &icmp.Echo{ID: id, Seq: seq}}
+	}
+	raw := &icmpPinger{privileged: true, id: 4242}
+	unprivileged := &icmpPinger{id: 4242}
+
+	tests := []struct {
+		name   string
+		pinger *icmpPinger
+		msg    *icmp.Message
+		peer   net.Addr
+		want   bool
+	}{
+		{"reply from target", raw, reply(4242, 3), &net.IPAddr{IP: target}, true},
+		// 其他目标的探测使用相同的序号，回复从别的地址到达
+		{"reply from another target", raw, reply(4242, 3), &net.IPAddr{IP: net.ParseIP("1.1.1.1")}, false},
+		{"another prober's id", raw, reply(4243, 3), &net.IPAddr{IP: target}, false},
+		{"wrong seq", raw, reply(4242, 2), &net.IPAddr{IP: target}, false},
+		{"echo request", raw, &icmp.Message{Type: ipv4.ICMPTypeEcho, Body: &icmp.Echo{ID: 4242, Seq: 3}}, &net.IPAddr{IP: target}, false},
+		{"unprivileged rewritten id", unprivileged, reply(7, 3), &net.UDPAddr{IP: target}, true},
+		{"unprivileged from another target", unprivileged, reply(7, 3), &net.UDPAddr{IP: net.ParseIP("1.1.1.1")}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.pinger.isReply(tt.msg, tt.peer, target, 3); got != tt.want {
+			t.Errorf("%s: isReply = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
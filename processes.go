@@ -0,0 +1,90 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+type processSample struct {
+	proc       *process.Process
+	name       string
+	cpuPercent float64
+	memBytes   uint64 // RSS
+}
+
+// toMap 用户和状态只对入选的进程查询，避免遍历全部进程时的额外开销
+func (p processSample) toMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"pid":         p.proc.Pid,
+		"name":        p.name,
+		"cpu_percent": math.Round(p.cpuPercent*100) / 100,
+		"mem_bytes":   p.memBytes,
+	}
+	if user, err := p.proc.Username(); err == nil {
+		m["user"] = user
+	}
+	if status, err := p.proc.Status(); err == nil && len(status) > 0 {
+		m["state"] = status[0]
+	}
+	return m
+}
+
+// getTopProcesses 在 interval 内对比每个进程的 CPU 时间，返回按 CPU 和内存排序的前 n 个进程。
+// 没有权限读取的进程直接跳过
+func getTopProcesses(n int, interval time.Duration) map[string]interface{} {
+	if n <= 0 {
+		return nil
+	}
+	procs, err := process.Processes()
+	if err != nil {
+		return nil
+	}
+
+	before := make(map[int32]float64, len(procs))
+	for _, p := range procs {
+		if t, err := p.Times(); err == nil {
+			before[p.Pid] = t.User + t.System
+		}
+	}
+	time.Sleep(interval)
+
+	var samples []processSample
+	for _, p := range procs {
+		start, ok := before[p.Pid]
+		if !ok {
+			continue
+		}
+		t, err := p.Times()
+		if err != nil {
+			continue
+		}
+		name, err := p.Name()
+		if err != nil {
+			continue
+		}
+		s := processSample{proc: p, name: name}
+		if busy := t.User + t.System - start; busy > 0 {
+			s.cpuPercent = busy / interval.Seconds() * 100
+		}
+		if m, err := p.MemoryInfo(); err == nil {
+			s.memBytes = m.RSS
+		}
+		samples = append(samples, s)
+	}
+
+	top := func(less func(a, b processSample) bool) []interface{} {
+		sort.Slice(samples, func(i, j int) bool { return less(samples[i], samples[j]) })
+		list := make([]interface{}, 0, n)
+		for i := 0; i < n && i < len(samples); i++ {
+			list = append(list, samples[i].toMap())
+		}
+		return list
+	}
+	return map[string]interface{}{
+		"by_cpu":    top(func(a, b processSample) bool { return a.cpuPercent > b.cpuPercent }),
+		"by_memory": top(func(a, b processSample) bool { return a.memBytes > b.memBytes }),
+	}
+}
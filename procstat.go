@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// readProcStat 读取 /proc/stat 中单值的计数行（processes、procs_running 等）
+func readProcStat() (map[string]uint64, error) {
+	content, err := ioutil.ReadFile(hostProc("stat"))
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]uint64)
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		values[fields[0]] = v
+	}
+	return values, nil
+}
+
+func getProcessStates(interval time.Duration) map[string]interface{} {
+	before, err := readProcStat()
+	if err != nil {
+		return nil
+	}
+	time.Sleep(interval)
+	after, err := readProcStat()
+	if err != nil {
+		return nil
+	}
+
+	var forks float64
+	if after["processes"] >= before["processes"] {
+		forks = float64(after["processes"]-before["processes"]) / interval.Seconds()
+	}
+	// procs_running、procs_blocked 是瞬时值，比 load average 反应更快
+	return map[string]interface{}{
+		"forks_per_sec": math.Round(forks*100) / 100,
+		"procs_running": after["procs_running"],
+		"procs_blocked": after["procs_blocked"],
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// startPTY 打开 /dev/ptmx 分配一对伪终端，cmd 作为新会话的首进程，以从端为控制终端
+func startPTY(cmd *exec.Cmd, cols, rows uint16) (*os.File, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, err
+	}
+	var n int
+	err = ptyControl(master, func(fd int) error {
+		if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+			return fmt.Errorf("unlock pty: %w", err)
+		}
+		var err error
+		if n, err = unix.IoctlGetInt(fd, unix.TIOCGPTN); err != nil {
+			return fmt.Errorf("get pty number: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		master.Close()
+		return nil, err
+	}
+	slave, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, err
+	}
+	// 子进程持有从端，父进程启动后即可关闭自己的副本，子进程退出时读主端会返回 EIO
+	defer slave.Close()
+	if err := resizePTY(master, cols, rows); err != nil {
+		master.Close()
+		return nil, err
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: 0}
+	if err := cmd.Start(); err != nil {
+		master.Close()
+		return nil, err
+	}
+	return master, nil
+}
+
+// ptyControl 通过 SyscallConn 操作文件描述符；调用 Fd() 会把主端切换为阻塞模式，Close 就无法打断读循环
+func ptyControl(master *os.File, fn func(fd int) error) error {
+	rc, err := master.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var ferr error
+	if err := rc.Control(func(fd uintptr) { ferr = fn(int(fd)) }); err != nil {
+		return err
+	}
+	return ferr
+}
+
+func resizePTY(master *os.File, cols, rows uint16) error {
+	if cols == 0 || rows == 0 {
+		cols, rows = 80, 24
+	}
+	return ptyControl(master, func(fd int) error {
+		return unix.IoctlSetWinsize(fd, unix.TIOCSWINSZ, &unix.Winsize{Col: cols, Row: rows})
+	})
+}
+
+// killTerminal 向 shell 所在的进程组发送 SIGHUP，shell 是会话首进程，进程组 ID 即其 PID
+func killTerminal(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGHUP)
+	}
+}
@@ -0,0 +1,26 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// 伪终端目前只实现了 Linux 的 /dev/ptmx
+var errPTYUnsupported = errors.New("web terminal is only supported on Linux")
+
+func startPTY(cmd *exec.Cmd, cols, rows uint16) (*os.File, error) {
+	return nil, errPTYUnsupported
+}
+
+func resizePTY(master *os.File, cols, rows uint16) error {
+	return errPTYUnsupported
+}
+
+func killTerminal(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		cmd.Process.Kill()
+	}
+}
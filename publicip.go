@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var cgnatRange = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+func isPublicIP(ip net.IP) bool {
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	return !cgnatRange.Contains(ip)
+}
+
+// localPublicIPs 从本机网卡地址中找第一个公网 IPv4/IPv6
+func localPublicIPs() (v4, v6 string) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return "", ""
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || !isPublicIP(ipNet.IP) {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			if v4 == "" {
+				v4 = ipNet.IP.String()
+			}
+		} else if v6 == "" {
+			v6 = ipNet.IP.String()
+		}
+	}
+	return v4, v6
+}
+
+// echoPublicIP 通过外部回显服务查询出口 IP，network 为 tcp4 或 tcp6 以强制地址族
+func echoPublicIP(url, network string) string {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			Proxy: httpProxy,
+			DialContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, addr)
+			},
+		},
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		slog.Debug("public ip echo failed", "component", "public-ip", "url", url, "network", network, "err", err)
+		return ""
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return ""
+	}
+	ip := net.ParseIP(strings.TrimSpace(string(body)))
+	if !isPublicIP(ip) {
+		return ""
+	}
+	return ip.String()
+}
+
+// echoPublicIPAny 依次尝试各个回显服务，返回第一个有效结果
+func echoPublicIPAny(urls []string, network string) string {
+	for _, url := range urls {
+		if ip := echoPublicIP(url, network); ip != "" {
+			return ip
+		}
+	}
+	return ""
+}
+
+// OCI 的公网 IP 通过 NAT 映射到 VNIC，实例元数据和网卡上都只有私网地址，只能依赖回显服务
+type publicIPCache struct {
+	mu      sync.Mutex
+	fetched time.Time
+	value   map[string]interface{}
+	last    map[string]string // 上次检测到的地址，持久化后重启也能发现变化
+	changed map[string]interface{}
+}
+
+var publicIP = &publicIPCache{}
+
+// get 地址很少变化，按 refresh 间隔缓存结果
+func (c *publicIPCache) get(echoURLs []string, refresh time.Duration) map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.value != nil && time.Since(c.fetched) < refresh {
+		return c.value
+	}
+
+	v4, v6 := localPublicIPs()
+	// 外部回显服务默认关闭，避免泄露元数据
+	if len(echoURLs) > 0 {
+		if v4 == "" {
+			v4 = echoPublicIPAny(echoURLs, "tcp4")
+		}
+		if v6 == "" {
+			v6 = echoPublicIPAny(echoURLs, "tcp6")
+		}
+	}
+	value := make(map[string]interface{})
+	if v4 != "" {
+		value["ipv4"] = v4
+	}
+	if v6 != "" {
+		value["ipv6"] = v6
+	}
+	c.detectChange(map[string]string{"ipv4": v4, "ipv6": v6}, time.Now())
+	for k, v := range c.changed {
+		value[k] = v
+	}
+	c.value, c.fetched = value, time.Now()
+	return value
+}
+
+// detectChange 与上次的地址对比，变化时发出 public_ip_changed 事件，并在 payload 中保留
+// previous_ipv4/previous_ipv6 和 changed_at；某个地址族本次没有检测到时不视为变化
+func (c *publicIPCache) detectChange(current map[string]string, now time.Time) {
+	if c.last == nil {
+		c.last = make(map[string]string)
+		if content := readStateFile(cfg.PublicIPStateFile, "public_ip"); content != "" {
+			json.Unmarshal([]byte(content), &c.last)
+		}
+	}
+	dirty := false
+	for _, family := range []string{"ipv4", "ipv6"} {
+		ip, previous := current[family], c.last[family]
+		if ip == "" || ip == previous {
+			continue
+		}
+		c.last[family], dirty = ip, true
+		if previous == "" {
+			continue
+		}
+		if c.changed == nil {
+			c.changed = make(map[string]interface{})
+		}
+		c.changed["previous_"+family] = previous
+		c.changed["changed_at"] = now.Format(time.RFC3339)
+		hostname := reportedHostname()
+		dispatchAlert(alertEvent{
+			Rule:      "public_ip_changed",
+			Severity:  severityInfo,
+			Status:    "changed",
+			Metric:    "public_ip." + family,
+			From:      previous,
+			To:        ip,
+			Hostname:  hostname,
+			AgentID:   agentID(),
+			StartedAt: now.Format(time.RFC3339),
+			At:        now.Format(time.RFC3339),
+		})
+	}
+	if dirty {
+		body, _ := json.Marshal(c.last)
+		if err := writeStateFile(cfg.PublicIPStateFile, "public_ip", string(body), 0644); err != nil {
+			slog.Warn("persist public ip failed", "component", "public-ip", "err", err)
+		}
+	}
+}
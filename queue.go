@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// queuedReporter 先把上报放进内存环形队列，由后台协程按顺序投递，失败时指数退避，
+// 队列满时最旧的数据溢出到磁盘 spool（未配置时直接丢弃），恢复后先补发磁盘上的旧数据
+type queuedReporter struct {
+	name  string
+	send  func(context.Context, []byte) error // 只发送一次，重试和退避由队列负责
+	size  int
+	spool *spool
+	delta *deltaState // 启用 -delta 时非空
+
+	mu       sync.Mutex
+	ring     []queuedBody
+	seq      uint64
+	lastErr  error
+	failures int
+	started  bool
+	wake     chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+	ctx      context.Context // Close 时取消，中断正在进行的投递
+	cancel   context.CancelFunc
+}
+
+type queuedBody struct {
+	seq  uint64
+	body []byte
+}
+
+func newQueuedReporter(name string, send func(context.Context, []byte) error, size int, s *spool) *queuedReporter {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &queuedReporter{
+		name:   name,
+		send:   send,
+		size:   size,
+		spool:  s,
+		wake:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+func (q *queuedReporter) Name() string {
+	return q.name
+}
+
+// Report 只负责入队，返回上一次投递的错误，便于主循环记录日志和健康状态
+func (q *queuedReporter) Report(data map[string]interface{}) error {
+	if q.delta != nil {
+		q.mu.Lock()
+		failed := q.lastErr != nil
+		q.mu.Unlock()
+		data = q.delta.apply(data, time.Now(), failed)
+	}
+	body, err := marshalPayload(data, false)
+	if err != nil {
+		return err
+	}
+	q.mu.Lock()
+	q.seq++
+	q.ring = append(q.ring, queuedBody{q.seq, body})
+	var overflow [][]byte
+	if len(q.ring) > q.size {
+		n := len(q.ring) - q.size
+		for _, item := range q.ring[:n] {
+			overflow = append(overflow, item.body)
+		}
+		q.ring = append(q.ring[:0:0], q.ring[n:]...)
+	}
+	lastErr, pending := q.lastErr, len(q.ring)
+	q.mu.Unlock()
+
+	q.spill(overflow)
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	if lastErr != nil {
+		return fmt.Errorf("%d report(s) queued, last delivery failed: %w", pending, lastErr)
+	}
+	return nil
+}
+
+// spill 把数据写入磁盘 spool，没有 spool 时丢弃并记录日志
+func (q *queuedReporter) spill(bodies [][]byte) {
+	if len(bodies) == 0 {
+		return
+	}
+	if q.spool == nil {
+		slog.Warn("report queue full, dropped oldest reports", "component", "queue", "url", q.name, "dropped", len(bodies))
+		return
+	}
+	for _, body := range bodies {
+		if err := q.spool.append(body); err != nil {
+			slog.Error("spool append failed", "component", "spool", "path", q.spool.path, "err", err)
+			return
+		}
+	}
+}
+
+// deliver 不可重试的错误（如 4xx）说明服务端拒绝了这条数据，丢弃以免阻塞后面的数据，
+// 拒绝的错误记录在 rejected 中，由 flushNow 在投递完其余数据后返回
+func (q *queuedReporter) deliver(ctx context.Context, body []byte, rejected *error) error {
+	err := q.send(ctx, body)
+	if err != nil && !isRetryable(err) {
+		slog.Error("report rejected, dropping it", "component", "queue", "url", q.name, "err", err)
+		*rejected = err
+		return nil
+	}
+	return err
+}
+
+// flushNow 按顺序投递磁盘和内存中积压的全部数据，遇到失败即停止并返回错误
+func (q *queuedReporter) flushNow(ctx context.Context) error {
+	var rejected error
+	deliver := func(body []byte) error { return q.deliver(ctx, body, &rejected) }
+	// 被 Close 中断不算投递失败，否则 finalFlush 会误以为服务端不可达而跳过最后一次投递
+	result := func(err error) error {
+		if ctx.Err() != nil {
+			return err
+		}
+		return q.recordResult(err)
+	}
+	if q.spool != nil {
+		if err := q.spool.flush(deliver); err != nil {
+			return result(err)
+		}
+	}
+	for {
+		q.mu.Lock()
+		if len(q.ring) == 0 {
+			q.mu.Unlock()
+			return result(rejected)
+		}
+		head := q.ring[0]
+		q.mu.Unlock()
+
+		if err := deliver(head.body); err != nil {
+			return result(err)
+		}
+		q.mu.Lock()
+		// 投递期间队首可能已经溢出到磁盘，只有仍是这条数据时才出队
+		if len(q.ring) > 0 && q.ring[0].seq == head.seq {
+			q.ring = q.ring[1:]
+		}
+		q.mu.Unlock()
+	}
+}
+
+func (q *queuedReporter) recordResult(err error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.lastErr = err
+	if err != nil {
+		q.failures++
+	} else {
+		q.failures = 0
+	}
+	return err
+}
+
+// flushRetry 用于 -once 模式：没有后台协程，按 -retry-max-attempts 退避重试整个投递
+func (q *queuedReporter) flushRetry(ctx context.Context) error {
+	for attempt := 1; ; attempt++ {
+		err := q.flushNow(ctx)
+		if err == nil || !isRetryable(err) || attempt >= cfg.RetryMaxAttempts {
+			return err
+		}
+		delay := backoffDelay(attempt, cfg.RetryMaxBackoff)
+		slog.Warn("report attempt failed, retrying", "component", "queue", "url", q.name, "attempt", attempt, "retry_in", delay, "err", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// finalFlush 退出前再投递一次，上一次投递已经失败时服务端多半不可达，直接写入 spool 以免拖慢退出。
+// 超时后中断正在发送的请求，剩余数据照常写入 spool，被中断的那一条可能在下次启动时重复上报
+func (q *queuedReporter) finalFlush() {
+	q.mu.Lock()
+	skip := q.lastErr != nil || len(q.ring) == 0 && q.spool == nil
+	q.mu.Unlock()
+	if skip || cfg.ShutdownTimeout <= 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := q.flushNow(ctx); err != nil {
+		if ctx.Err() != nil {
+			slog.Warn("final delivery timed out", "component", "queue", "url", q.name, "timeout", cfg.ShutdownTimeout)
+			return
+		}
+		slog.Warn("final delivery failed", "component", "queue", "url", q.name, "err", err)
+	}
+}
+
+func (q *queuedReporter) pending() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.ring)
+}
+
+// start 启动后台投递协程，-once 模式下不启动，由 runOnce 直接调用 flushNow
+func (q *queuedReporter) start() {
+	q.mu.Lock()
+	q.started = true
+	q.mu.Unlock()
+	go func() {
+		defer close(q.done)
+		for {
+			var retry <-chan time.Time
+			// 被拒绝的数据已经丢弃，没有需要重试的内容
+			if err := q.flushNow(q.ctx); err != nil && isRetryable(err) {
+				q.mu.Lock()
+				delay := backoffDelay(q.failures, cfg.RetryMaxBackoff)
+				q.mu.Unlock()
+				slog.Debug("queued delivery failed", "component", "queue", "url", q.name, "retry_in", delay, "err", err)
+				retry = time.After(delay)
+			}
+			select {
+			case <-q.stop:
+				return
+			case <-retry:
+			case <-q.wake:
+				// 退避期间有新数据入队时继续等待退避结束，避免连续打到不可达的服务端
+				if retry != nil {
+					select {
+					case <-q.stop:
+						return
+					case <-retry:
+					}
+				}
+			}
+		}
+	}()
+}
+
+// Close 停止后台投递，服务端正常时在 -shutdown-timeout 内投递完剩余数据，
+// 仍未送达的写入磁盘 spool，下次启动时补发
+func (q *queuedReporter) Close() {
+	select {
+	case <-q.stop:
+		return
+	default:
+	}
+	close(q.stop)
+	// 中断正在进行的投递并等后台协程退出，之后队列只由这里访问，不会与投递同时写入 spool
+	q.cancel()
+	q.mu.Lock()
+	started := q.started
+	q.mu.Unlock()
+	if started {
+		<-q.done
+		q.finalFlush()
+	}
+	q.mu.Lock()
+	remaining := make([][]byte, 0, len(q.ring))
+	for _, item := range q.ring {
+		remaining = append(remaining, item.body)
+	}
+	q.ring = nil
+	q.mu.Unlock()
+	if q.spool != nil {
+		q.spill(remaining)
+	} else if len(remaining) > 0 {
+		slog.Warn("discarding undelivered reports", "component", "queue", "url", q.name, "count", len(remaining))
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQueueCloseInterruptsDeliveryAndSpills(t *testing.T) {
+	restoreConfig(t)
+	cfg.ShutdownTimeout = 100 * time.Millisecond
+	cfg.RetryMaxBackoff = 10 * time.Millisecond
+
+	// 服务端收到请求后一直不响应
+	release := make(chan struct{})
+	received := make(chan struct{}, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	s, err := newSpool(filepath.Join(t.TempDir(), "reports.jsonl"), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := newQueuedReporter(srv.URL, func(ctx context.Context, b []byte) error { return postPayloadContext(ctx, b, srv.URL) }, 10, s)
+	q.start()
+	if err := q.Report(map[string]interface{}{"hostname": "h"}); err != nil {
+		t.Fatal(err)
+	}
+	<-received
+
+	start := time.Now()
+	q.Close()
+	// 取消正在进行的请求后等后台协程退出，再用 -shutdown-timeout 投递一次，最后写入 spool
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Close took %s, want about the 100ms shutdown timeout", elapsed)
+	}
+	select {
+	case <-q.done:
+	default:
+		t.Error("Close returned before the delivery goroutine exited")
+	}
+	if n := s.size(); n != 1 {
+		t.Errorf("spool size after Close = %d, want 1", n)
+	}
+	if n := q.pending(); n != 0 {
+		t.Errorf("queue still holds %d report(s) after Close", n)
+	}
+}
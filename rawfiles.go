@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const rawFileReadTimeout = 500 * time.Millisecond
+
+// 只允许读取 /proc 和 /sys 下的文件
+func validateRawFilePath(path string) error {
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("path %q must be absolute", path)
+	}
+	clean := filepath.Clean(path)
+	if !strings.HasPrefix(clean, "/proc/") && !strings.HasPrefix(clean, "/sys/") {
+		return fmt.Errorf("path %q is not under /proc or /sys", path)
+	}
+	return nil
+}
+
+func readFileWithTimeout(path string, timeout time.Duration) ([]byte, error) {
+	type result struct {
+		content []byte
+		err     error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		content, err := ioutil.ReadFile(path)
+		ch <- result{content, err}
+	}()
+	select {
+	case r := <-ch:
+		return r.content, r.err
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("read %s: timed out after %s", path, timeout)
+	}
+}
+
+func getRawFiles(files map[string]string) map[string]interface{} {
+	if len(files) == 0 {
+		return nil
+	}
+	values := make(map[string]interface{}, len(files))
+	for label, path := range files {
+		// /proc/self/root 之类的软链接可以跳出 /proc，需校验解析后的真实路径
+		resolved, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			err = validateRawFilePath(resolved)
+		}
+		var content []byte
+		if err == nil {
+			content, err = readFileWithTimeout(resolved, rawFileReadTimeout)
+		}
+		if err != nil {
+			// 文件不存在或读取超时，置空而不是中断采集
+			values[label] = nil
+			continue
+		}
+		text := strings.TrimSpace(string(content))
+		if n, err := strconv.ParseFloat(text, 64); err == nil {
+			values[label] = n
+		} else {
+			values[label] = text
+		}
+	}
+	return values
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+// agentToken 是注册后服务端下发的令牌，设置后替代 -auth-token 用于所有上报和心跳
+var agentToken string
+
+type registration struct {
+	InstanceID   string            `json:"instance_id"`
+	Hostname     string            `json:"hostname"`
+	Platform     string            `json:"platform"`
+	Architecture string            `json:"architecture"`
+	Distribution string            `json:"distribution"`
+	AgentVersion string            `json:"agent_version"`
+	Labels       map[string]string `json:"labels,omitempty"`
+}
+
+// register 提交注册信息，-auth-token 作为一次性的引导令牌，服务端返回 {"token": "..."}
+func register(url string) (string, error) {
+	hostname := reportedHostname()
+	static := collectStaticInfo()
+	distribution, _ := static["distribution"].(string)
+	body, err := json.Marshal(registration{
+		InstanceID:   agentID(),
+		Hostname:     hostname,
+		Platform:     runtime.GOOS,
+		Architecture: runtime.GOARCH,
+		Distribution: distribution,
+		AgentVersion: version,
+		Labels:       cfg.Labels,
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	setAuthHeaders(req.Header)
+	if err := signRequest(req.Header, body); err != nil {
+		return "", err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		io.Copy(io.Discard, resp.Body)
+		return "", &statusError{code: resp.StatusCode}
+	}
+	var result struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&result); err != nil {
+		return "", fmt.Errorf("decode registration response: %w", err)
+	}
+	if result.Token == "" {
+		return "", fmt.Errorf("registration response has no token")
+	}
+	return result.Token, nil
+}
+
+// ensureRegistered 首次运行时注册并保存令牌，之后直接读取已保存的令牌；
+// 服务端不可达时按退避重试，直到成功、被拒绝或收到退出信号
+func ensureRegistered(ctx context.Context, url string) error {
+	if token := readStateFile(cfg.AgentTokenFile, "agent_token"); token != "" {
+		agentToken = token
+		return nil
+	}
+	for attempt := 1; ; attempt++ {
+		token, err := register(url)
+		if err == nil {
+			agentToken = token
+			slog.Info("agent registered", "component", "registration", "url", url, "instance_id", agentID())
+			if err := writeStateFile(cfg.AgentTokenFile, "agent_token", token, 0600); err != nil {
+				// 令牌仍然可用，只是下次启动需要重新注册
+				slog.Warn("cannot persist agent token", "component", "registration", "err", err)
+			}
+			return nil
+		}
+		if !isRetryable(err) || cfg.Once {
+			return err
+		}
+		delay := backoffDelay(attempt, cfg.RetryMaxBackoff)
+		slog.Warn("registration failed, retrying", "component", "registration", "url", url, "attempt", attempt, "retry_in", delay, "err", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/host"
+)
+
+const retryInitialBackoff = 500 * time.Millisecond
+
+// agentStartTime 用于心跳中的 agent_uptime_seconds
+var agentStartTime = time.Now()
+
+var (
+	agentIDOnce sync.Once
+	agentIDVal  string
+)
+
+// agentID 返回稳定的实例标识，优先使用持久化的 instance_id，其次 machine-id，最后退回主机名
+func agentID() string {
+	if instanceID != "" {
+		return instanceID
+	}
+	agentIDOnce.Do(func() {
+		if hostInfo, err := host.Info(); err == nil {
+			agentIDVal = hostInfo.HostID
+			if agentIDVal == "" {
+				agentIDVal = hostInfo.Hostname
+			}
+		}
+	})
+	return agentIDVal
+}
+
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("server returned status %d", e.code)
+}
+
+// isRetryable 网络错误、超时、5xx 和 429 可重试，其余 4xx 说明请求本身有问题，重试没有意义
+func isRetryable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code >= 500 || se.code == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// setAuthHeaders 设置实例标识和鉴权头，HTTP 上报和 WebSocket 握手共用
+func setAuthHeaders(h http.Header) {
+	if id := agentID(); id != "" {
+		h.Set("X-Agent-Id", id)
+	}
+	// 未配置 token 时不带 Authorization，兼容不鉴权的部署；注册得到的令牌优先
+	token := cfg.AuthToken
+	if agentToken != "" {
+		token = agentToken
+	}
+	if token != "" {
+		h.Set("Authorization", "Bearer "+token)
+	}
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func postPayload(body []byte, url string) error {
+	return postPayloadContext(context.Background(), body, url)
+}
+
+// postPayloadContext 只发送一次，由调用方决定是否重试；ctx 取消时中断正在进行的请求
+func postPayloadContext(ctx context.Context, body []byte, url string) error {
+	encoding := ""
+	if cfg.Gzip {
+		compressed, err := gzipBytes(body)
+		if err != nil {
+			return err
+		}
+		body, encoding = compressed, "gzip"
+	}
+	contentType := "application/json"
+	if payloadCipher != nil {
+		encrypted, err := encryptPayload(payloadCipher, body)
+		if err != nil {
+			return err
+		}
+		body, contentType = encrypted, "application/octet-stream"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	switch {
+	case payloadCipher != nil:
+		req.Header.Set(encryptionHeader, encryptionScheme)
+		if encoding != "" {
+			req.Header.Set(payloadEncodingHeader, encoding)
+		}
+	case encoding != "":
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	setAuthHeaders(req.Header)
+	if err := signRequest(req.Header, body); err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return &statusError{code: resp.StatusCode}
+	}
+	return nil
+}
+
+// backoffDelay 指数退避，实际等待时间在 [d/2, d) 之间随机，避免大量 agent 同时重试
+func backoffDelay(attempt int, max time.Duration) time.Duration {
+	d := retryInitialBackoff << uint(attempt-1)
+	if d <= 0 || d > max {
+		d = max
+	}
+	half := int64(d / 2)
+	if half <= 0 {
+		return d
+	}
+	return time.Duration(half + rand.Int63n(half))
+}
+
+// jittered 在 interval 上下随机浮动 jitter（0.1 表示 ±10%），避免大量 agent 在同一秒上报
+func jittered(interval time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 {
+		return interval
+	}
+	return interval + time.Duration((rand.Float64()*2-1)*jitter*float64(interval))
+}
+
+// splayDelay 返回 [0, splay) 内的随机延迟，用于错开同时启动（如批量重启）的 agent 的首次上报
+func splayDelay(splay time.Duration) time.Duration {
+	if splay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(splay)))
+}
+
+// schedule 按 interval（带 jitter）循环调用 fn，首次调用前先等待 splay 内的随机时间；
+// 间隔从每次开始时计算，fn 本身的耗时不会让周期逐渐推迟。ctx 结束时返回
+func schedule(ctx context.Context, interval time.Duration, fn func()) {
+	timer := time.NewTimer(splayDelay(cfg.Splay))
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		start := time.Now()
+		fn()
+		wait := time.Until(start.Add(jittered(interval, cfg.Jitter)))
+		if wait < 0 {
+			wait = 0
+		}
+		timer.Reset(wait)
+	}
+}
+
+func reportToServer(data map[string]interface{}, url string) error {
+	body, err := marshalPayload(data, false)
+	if err != nil {
+		return err
+	}
+	return postWithRetry(body, url)
+}
+
+func postWithRetry(body []byte, url string) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = postPayload(body, url)
+		if err == nil {
+			slog.Debug("report sent", "component", "reporter", "url", url, "status", http.StatusOK, "attempt", attempt)
+			return nil
+		}
+		if !isRetryable(err) || attempt >= cfg.RetryMaxAttempts {
+			return fmt.Errorf("report to %s failed after %d attempt(s): %w", url, attempt, err)
+		}
+		delay := backoffDelay(attempt, cfg.RetryMaxBackoff)
+		slog.Warn("report attempt failed, retrying", "component", "reporter", "url", url, "attempt", attempt, "retry_in", delay, "err", err)
+		time.Sleep(delay)
+	}
+}
+
+func heartbeatPayload(status string) map[string]interface{} {
+	heartbeat := map[string]interface{}{
+		"status":        status,
+		"timestamp":     time.Now().Unix(),
+		"agent_version": version,
+		"instance_id":   agentID(),
+		"hostname":      reportedHostname(),
+
+		"agent_uptime_seconds": int64(time.Since(agentStartTime).Seconds()),
+	}
+	if uptime, err := host.Uptime(); err == nil {
+		heartbeat["host_uptime_seconds"] = uptime
+	}
+	// 服务端可以用 timestamp 减去偏差来判断心跳是否过期，而不必信任本机时钟
+	if offset, ok := clockOffsetMillis(); ok {
+		heartbeat["clock_offset_ms"] = offset
+	}
+	if len(cfg.Labels) > 0 {
+		heartbeat["labels"] = cfg.Labels
+	}
+	return heartbeat
+}
+
+func sendHeartbeat(url, status string) error {
+	return reportToServer(heartbeatPayload(status), url)
+}
+
+// heartbeater 由能够在自己的连接上发送心跳的 Reporter 实现，例如 WebSocket
+type heartbeater interface {
+	Heartbeat(status string) error
+}
+
+func heartbeatsEnabled(reporters []Reporter) bool {
+	if cfg.HeartbeatURL != "" {
+		return true
+	}
+	for _, r := range reporters {
+		if _, ok := r.(heartbeater); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sendHeartbeats 向 -heartbeat-url 以及支持心跳的 Reporter 发送心跳
+func sendHeartbeats(reporters []Reporter, status string) {
+	if cfg.HeartbeatURL != "" {
+		if err := sendHeartbeat(cfg.HeartbeatURL, status); err != nil {
+			logReportError("heartbeat", cfg.HeartbeatURL, err)
+		}
+	}
+	// 各目的地并发发送，一个目的地超时不推迟其他目的地的心跳
+	var wg sync.WaitGroup
+	for _, r := range reporters {
+		if h, ok := r.(heartbeater); ok {
+			wg.Add(1)
+			go func(name string) {
+				defer wg.Done()
+				if err := h.Heartbeat(status); err != nil {
+					logReportError("heartbeat", name, err)
+				}
+			}(r.Name())
+		}
+	}
+	wg.Wait()
+}
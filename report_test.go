@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// restoreConfig 在测试结束后恢复全局配置，测试中只替换字段，不修改其中的 map
+func restoreConfig(t *testing.T) {
+	t.Helper()
+	saved, savedID := cfg, instanceID
+	t.Cleanup(func() { cfg, instanceID = saved, savedID })
+}
+
+func TestReportAuthHeaders(t *testing.T) {
+	restoreConfig(t)
+	instanceID = "test-instance"
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	cfg.AuthToken = "secret-token"
+	if err := reportToServer(map[string]interface{}{"hostname": "h"}, srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if v := got.Get("Authorization"); v != "Bearer secret-token" {
+		t.Errorf("Authorization = %q, want %q", v, "Bearer secret-token")
+	}
+	if v := got.Get("X-Agent-Id"); v != "test-instance" {
+		t.Errorf("X-Agent-Id = %q, want %q", v, "test-instance")
+	}
+
+	cfg.AuthToken = ""
+	if err := sendHeartbeat(srv.URL, "online"); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := got["Authorization"]; ok {
+		t.Errorf("Authorization sent without a token: %q", v)
+	}
+	if v := got.Get("X-Agent-Id"); v != "test-instance" {
+		t.Errorf("heartbeat X-Agent-Id = %q, want %q", v, "test-instance")
+	}
+}
+
+func TestPostWithRetry(t *testing.T) {
+	restoreConfig(t)
+	cfg.RetryMaxAttempts = 5
+	cfg.RetryMaxBackoff = 10 * time.Millisecond
+
+	// 前两次返回 503，第三次成功
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	if err := postWithRetry([]byte(`{}`), srv.URL); err != nil {
+		t.Fatalf("postWithRetry: %v", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("attempts = %d, want 3", n)
+	}
+
+	// 4xx 不重试
+	calls.Store(0)
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer bad.Close()
+	err := postWithRetry([]byte(`{}`), bad.URL)
+	var se *statusError
+	if !errors.As(err, &se) || se.code != http.StatusBadRequest {
+		t.Fatalf("postWithRetry error = %v, want status 400", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("attempts on 400 = %d, want 1", n)
+	}
+}
+
+func TestGzipPayload(t *testing.T) {
+	restoreConfig(t)
+	body := bytes.Repeat([]byte(`{"cpu":{"percent":12.5}}`), 50)
+	compressed, err := gzipBytes(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(compressed) >= len(body) {
+		t.Errorf("compressed %d bytes into %d, want smaller", len(body), len(compressed))
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded, err := io.ReadAll(zr); err != nil || !bytes.Equal(decoded, body) {
+		t.Fatalf("gzip round-trip = %q, %v; want the original body", decoded, err)
+	}
+
+	// 开启 -gzip 时服务端收到 Content-Encoding: gzip 且能解出原始 JSON
+	var encoding string
+	var payload map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.Header.Get("Content-Encoding")
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if err := json.NewDecoder(zr).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+	cfg.Gzip = true
+	if err := postPayload([]byte(`{"hostname":"h"}`), srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if encoding != "gzip" {
+		t.Errorf("Content-Encoding = %q, want gzip", encoding)
+	}
+	if payload["hostname"] != "h" {
+		t.Errorf("decoded payload = %v, want hostname h", payload)
+	}
+}
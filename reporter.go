@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// Reporter 将一次采集结果发送到某个目的地
+type Reporter interface {
+	Report(data map[string]interface{}) error
+	// Name 用于日志，通常是目标地址
+	Name() string
+}
+
+func buildReporters() ([]Reporter, error) {
+	var reporters []Reporter
+	for i, url := range cfg.ReportURLs {
+		var s *spool
+		if cfg.SpoolPath != "" {
+			var err error
+			if s, err = newSpool(spoolPathFor(i), cfg.SpoolMaxBytes); err != nil {
+				return nil, err
+			}
+			health.watchSpool(s)
+		}
+		url := url
+		// 队列自己负责退避重试，这里每次只发送一次
+		q := newQueuedReporter(url, func(ctx context.Context, b []byte) error { return postPayloadContext(ctx, b, url) }, cfg.QueueSize, s)
+		if cfg.Delta {
+			q.delta = &deltaState{}
+		}
+		health.watchQueue(q)
+		reporters = append(reporters, q)
+	}
+	if cfg.WebSocketURL != "" {
+		reporters = append(reporters, newWSReporter(cfg.WebSocketURL))
+	}
+	if cfg.GRPCAddr != "" {
+		r, err := newGRPCReporter(cfg.GRPCAddr, cfg.GRPCPlaintext)
+		if err != nil {
+			return nil, fmt.Errorf("grpc: %w", err)
+		}
+		reporters = append(reporters, r)
+	}
+	if cfg.NezhaServer != "" {
+		r, err := newNezhaReporter(cfg.NezhaServer, cfg.NezhaSecret, cfg.NezhaTLS)
+		if err != nil {
+			return nil, fmt.Errorf("nezha: %w", err)
+		}
+		reporters = append(reporters, r)
+	}
+	if cfg.InfluxURL != "" {
+		r, err := newInfluxReporter(cfg.InfluxURL)
+		if err != nil {
+			return nil, fmt.Errorf("influx: %w", err)
+		}
+		reporters = append(reporters, r)
+	}
+	if cfg.MQTTBroker != "" {
+		r, err := newMQTTReporter(cfg.MQTTBroker)
+		if err != nil {
+			return nil, fmt.Errorf("mqtt: %w", err)
+		}
+		reporters = append(reporters, r)
+	}
+	if cfg.FIFOPath != "" {
+		r, err := newFIFOReporter(cfg.FIFOPath)
+		if err != nil {
+			return nil, err
+		}
+		reporters = append(reporters, r)
+	}
+	return reporters, nil
+}
+
+// spoolPathFor 第一个 -report-url 沿用 -spool-path，其余地址各自使用 path.1、path.2 ...，互不影响重放
+func spoolPathFor(i int) string {
+	if i == 0 {
+		return cfg.SpoolPath
+	}
+	return fmt.Sprintf("%s.%d", cfg.SpoolPath, i)
+}
@@ -0,0 +1,66 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+const fifoWriteTimeout = 2 * time.Second
+
+// fifoReporter 以 NDJSON 的形式写入命名管道，读端断开后下次上报时重新打开
+type fifoReporter struct {
+	path string
+	f    *os.File
+}
+
+func newFIFOReporter(path string) (Reporter, error) {
+	st, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		if err := syscall.Mkfifo(path, 0600); err != nil {
+			return nil, fmt.Errorf("create fifo %s: %w", path, err)
+		}
+	} else if err != nil {
+		return nil, err
+	} else if st.Mode()&os.ModeNamedPipe == 0 {
+		return nil, fmt.Errorf("%s exists and is not a named pipe", path)
+	}
+	return &fifoReporter{path: path}, nil
+}
+
+func (r *fifoReporter) Name() string {
+	return "fifo:" + r.path
+}
+
+func (r *fifoReporter) Report(data map[string]interface{}) error {
+	line, err := marshalPayload(data, false)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if r.f == nil {
+		// 非阻塞打开：没有读端时直接返回 ENXIO，而不是一直阻塞住 agent
+		f, err := os.OpenFile(r.path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+		if err != nil {
+			if errors.Is(err, syscall.ENXIO) {
+				return fmt.Errorf("fifo %s has no reader", r.path)
+			}
+			return err
+		}
+		r.f = f
+	}
+
+	r.f.SetWriteDeadline(time.Now().Add(fifoWriteTimeout))
+	if _, err := r.f.Write(line); err != nil {
+		// 读端断开（EPIPE）或写超时，关闭后下次重新打开
+		r.f.Close()
+		r.f = nil
+		return fmt.Errorf("write fifo %s: %w", r.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+package main
+
+import "errors"
+
+func newFIFOReporter(path string) (Reporter, error) {
+	return nil, errors.New("fifo reporting is not supported on windows")
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/host"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"oci-agent/agentpb"
+)
+
+// grpcReporter 通过一条双向流持续上报，服务端逐条回 ReportAck；流断开后下一次上报时重建。
+// 心跳走一元调用，实现 heartbeater 接口
+type grpcReporter struct {
+	addr   string
+	conn   *grpc.ClientConn
+	client agentpb.AgentServiceClient
+
+	mu     sync.Mutex
+	stream agentpb.AgentService_StreamReportsClient
+	cancel context.CancelFunc
+	broken error // 接收协程发现的流错误，下一次上报时返回并重建
+}
+
+// newGRPCReporter 默认使用 TLS（沿用 -tls-* 配置），plaintext 只用于内网或测试
+func newGRPCReporter(addr string, plaintext bool) (*grpcReporter, error) {
+	creds := insecure.NewCredentials()
+	if !plaintext {
+		conf := tlsConfig
+		if conf == nil {
+			conf = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		creds = credentials.NewTLS(conf)
+	}
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	return &grpcReporter{addr: addr, conn: conn, client: agentpb.NewAgentServiceClient(conn)}, nil
+}
+
+func (r *grpcReporter) Name() string {
+	return "grpc://" + r.addr
+}
+
+// outgoingContext 把与 HTTP 相同的鉴权和签名头放进 gRPC metadata
+func outgoingContext(ctx context.Context) (context.Context, error) {
+	header := http.Header{}
+	setAuthHeaders(header)
+	if err := signRequest(header, nil); err != nil {
+		return nil, err
+	}
+	md := metadata.MD{}
+	for k, v := range header {
+		md[strings.ToLower(k)] = v
+	}
+	return metadata.NewOutgoingContext(ctx, md), nil
+}
+
+func (r *grpcReporter) openStream() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx, err := outgoingContext(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+	stream, err := r.client.StreamReports(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+	r.stream, r.cancel, r.broken = stream, cancel, nil
+	go r.receiveAcks(stream)
+	return nil
+}
+
+// receiveAcks 读取服务端确认，流出错时标记为断开
+func (r *grpcReporter) receiveAcks(stream agentpb.AgentService_StreamReportsClient) {
+	for {
+		if _, err := stream.Recv(); err != nil {
+			r.mu.Lock()
+			if r.stream == stream {
+				r.broken = err
+			}
+			r.mu.Unlock()
+			return
+		}
+	}
+}
+
+func (r *grpcReporter) resetStream() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.stream, r.cancel = nil, nil
+}
+
+func (r *grpcReporter) Report(data map[string]interface{}) error {
+	report, err := toProtoReport(data)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.broken != nil {
+		err := r.broken
+		r.resetStream()
+		r.broken = nil
+		// 上一条流已经断开，本条直接用新流发送，错误只记录在日志里
+		logReportError("reporter", r.Name(), fmt.Errorf("stream closed: %w", err))
+	}
+	if r.stream == nil {
+		if err := r.openStream(); err != nil {
+			return err
+		}
+	}
+	if err := r.stream.Send(report); err != nil {
+		r.resetStream()
+		return err
+	}
+	return nil
+}
+
+func (r *grpcReporter) Heartbeat(status string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTPTimeout)
+	defer cancel()
+	ctx, err := outgoingContext(ctx)
+	if err != nil {
+		return err
+	}
+	req := &agentpb.HeartbeatRequest{
+		InstanceId:         agentID(),
+		Hostname:           reportedHostname(),
+		Status:             status,
+		Timestamp:          time.Now().Unix(),
+		AgentVersion:       version,
+		Labels:             cfg.Labels,
+		AgentUptimeSeconds: int64(time.Since(agentStartTime).Seconds()),
+	}
+	if uptime, err := host.Uptime(); err == nil {
+		req.HostUptimeSeconds = uptime
+	}
+	_, err = r.client.Heartbeat(ctx, req)
+	return err
+}
+
+func (r *grpcReporter) Close() {
+	r.mu.Lock()
+	if r.stream != nil {
+		r.stream.CloseSend()
+	}
+	r.resetStream()
+	r.mu.Unlock()
+	r.conn.Close()
+}
+
+// toProtoReport 把常用指标取到强类型字段，其余内容放入 extra；*_bytes、*_bytes_per_sec 字段固定为原始单位，
+// 因此不应用 -convert，格式化的 "1.50G" 字符串在强类型协议里没有意义，一律去掉
+func toProtoReport(data map[string]interface{}) (*agentpb.Report, error) {
+	info, err := rawPayload(data)
+	if err != nil {
+		return nil, err
+	}
+
+	section := func(name string) map[string]interface{} {
+		m, _ := info[name].(map[string]interface{})
+		if m == nil {
+			m = map[string]interface{}{}
+		}
+		return m
+	}
+	str := func(m map[string]interface{}, key string) string {
+		s, _ := m[key].(string)
+		delete(m, key)
+		return s
+	}
+	num := func(m map[string]interface{}, key string) float64 {
+		n, _ := m[key].(float64)
+		delete(m, key)
+		return n
+	}
+
+	report := &agentpb.Report{InstanceId: str(info, "instance_id"), Timestamp: time.Now().Unix()}
+	if labels, ok := info["labels"].(map[string]interface{}); ok {
+		report.Labels = make(map[string]string, len(labels))
+		for k, v := range labels {
+			report.Labels[k] = fmt.Sprint(v)
+		}
+		delete(info, "labels")
+	}
+	load := section("load_average")
+	report.Host = &agentpb.Host{
+		Platform:        str(info, "platform"),
+		Architecture:    str(info, "architecture"),
+		PlatformVersion: str(info, "platform_version"),
+		Distribution:    str(info, "distribution"),
+		Virtualization:  str(info, "virtualization"),
+		AgentVersion:    str(info, "agent_version"),
+		BootTime:        str(info, "boot_time"),
+		Uptime:          str(info, "uptime"),
+		ProcessCount:    uint32(num(info, "process_count")),
+		Load1:           num(load, "1min"),
+		Load5:           num(load, "5min"),
+		Load15:          num(load, "15min"),
+	}
+	cpu := section("cpu")
+	report.Cpu = &agentpb.Cpu{
+		Model:         str(cpu, "model"),
+		Count:         uint32(num(cpu, "count")),
+		EffectiveCpus: num(cpu, "effective_cpus"),
+		Percent:       num(cpu, "percent"),
+	}
+	memory := func(m map[string]interface{}) *agentpb.Memory {
+		return &agentpb.Memory{
+			TotalBytes:     uint64(num(m, "total_bytes")),
+			UsedBytes:      uint64(num(m, "used_bytes")),
+			Percent:        num(m, "percent"),
+			AvailableBytes: uint64(num(m, "available_bytes")),
+		}
+	}
+	report.Memory, report.Swap = memory(section("memory")), memory(section("swap"))
+	disk := section("disk")
+	report.Disk = &agentpb.Disk{
+		TotalBytes: uint64(num(disk, "total_bytes")),
+		UsedBytes:  uint64(num(disk, "used_bytes")),
+		Percent:    num(disk, "percent"),
+	}
+	network := section("network")
+	publicIP, _ := network["public_ip"].(map[string]interface{})
+	if publicIP == nil {
+		publicIP = map[string]interface{}{}
+	}
+	report.Network = &agentpb.Network{
+		UploadSpeedBytesPerSec:   uint64(num(network, "upload_speed_bytes_per_sec")),
+		DownloadSpeedBytesPerSec: uint64(num(network, "download_speed_bytes_per_sec")),
+		UploadTotalBytes:         uint64(num(network, "upload_total_bytes")),
+		DownloadTotalBytes:       uint64(num(network, "download_total_bytes")),
+		PublicIpv4:               str(publicIP, "ipv4"),
+		PublicIpv6:               str(publicIP, "ipv6"),
+	}
+	if len(publicIP) == 0 {
+		delete(network, "public_ip")
+	}
+
+	// 已经取完的子对象不再放进 extra
+	for _, name := range []string{"load_average", "cpu", "memory", "swap", "disk", "network"} {
+		if m, ok := info[name].(map[string]interface{}); ok && len(m) == 0 {
+			delete(info, name)
+		}
+	}
+	extra, err := structpb.NewStruct(info)
+	if err != nil {
+		return nil, err
+	}
+	report.Extra = extra
+	return report, nil
+}
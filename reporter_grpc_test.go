@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestProtoReportIgnoresUnitConversions(t *testing.T) {
+	saved := unitConversions
+	defer func() { unitConversions = saved }()
+	conversions, err := compileUnitConversions(map[string]string{
+		"memory.used_bytes":                  "MiB",
+		"network.upload_speed_bytes_per_sec": "Mbps",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unitConversions = conversions
+
+	report, err := toProtoReport(map[string]interface{}{
+		"instance_id": "i-1",
+		"memory":      map[string]interface{}{"used": "1.50G", "used_bytes": uint64(1610612736), "total_bytes": uint64(4 << 30)},
+		"network":     map[string]interface{}{"upload_speed": "11.92M", "upload_speed_bytes_per_sec": uint64(12500000)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := report.Memory.UsedBytes; got != 1610612736 {
+		t.Errorf("memory.used_bytes = %d, want raw bytes 1610612736", got)
+	}
+	if got := report.Network.UploadSpeedBytesPerSec; got != 12500000 {
+		t.Errorf("network.upload_speed_bytes_per_sec = %d, want 12500000", got)
+	}
+	if report.InstanceId != "i-1" {
+		t.Errorf("instance_id = %q, want i-1", report.InstanceId)
+	}
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// influxReporter 以 InfluxDB line protocol 输出原始数值（不经过 -convert 和格式化字符串），
+// 目标为 InfluxDB v2 的 HTTP 写入接口，或 Telegraf socket_listener 的 udp://、tcp://、unix:// 地址
+type influxReporter struct {
+	target *url.URL
+	write  string // InfluxDB v2 的 /api/v2/write 完整地址
+}
+
+func newInfluxReporter(raw string) (*influxReporter, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	r := &influxReporter{target: u}
+	switch u.Scheme {
+	case "http", "https":
+		if cfg.InfluxOrg == "" || cfg.InfluxBucket == "" {
+			return nil, fmt.Errorf("influx-org and influx-bucket are required for %s", raw)
+		}
+		q := url.Values{"org": {cfg.InfluxOrg}, "bucket": {cfg.InfluxBucket}, "precision": {"s"}}
+		r.write = strings.TrimRight(raw, "/") + "/api/v2/write?" + q.Encode()
+	case "udp", "tcp", "unix":
+	default:
+		return nil, fmt.Errorf("unsupported influx url %q, expected http(s)://, udp://, tcp:// or unix://", raw)
+	}
+	return r, nil
+}
+
+func (r *influxReporter) Name() string {
+	return r.target.Redacted()
+}
+
+func (r *influxReporter) Report(data map[string]interface{}) error {
+	body := influxLines(data, time.Now())
+	if r.write != "" {
+		return r.post(body)
+	}
+	return r.sendSocket(body)
+}
+
+func (r *influxReporter) post(body []byte) error {
+	encoding := ""
+	if cfg.Gzip {
+		compressed, err := gzipBytes(body)
+		if err != nil {
+			return err
+		}
+		body, encoding = compressed, "gzip"
+	}
+	req, err := http.NewRequest(http.MethodPost, r.write, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	if cfg.InfluxToken != "" {
+		req.Header.Set("Authorization", "Token "+cfg.InfluxToken)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return &statusError{code: resp.StatusCode}
+	}
+	return nil
+}
+
+// influxMaxDatagram 保证每个 UDP 包不被截断，按行拆分
+const influxMaxDatagram = 8192
+
+func (r *influxReporter) sendSocket(body []byte) error {
+	address := r.target.Host
+	if r.target.Scheme == "unix" {
+		address = r.target.Path
+	}
+	conn, err := net.DialTimeout(r.target.Scheme, address, cfg.HTTPTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetWriteDeadline(time.Now().Add(cfg.HTTPTimeout))
+	if r.target.Scheme != "udp" {
+		_, err = conn.Write(body)
+		return err
+	}
+	for len(body) > 0 {
+		n := len(body)
+		if n > influxMaxDatagram {
+			n = bytes.LastIndexByte(body[:influxMaxDatagram], '\n') + 1
+			if n == 0 {
+				n = influxMaxDatagram
+			}
+		}
+		if _, err := conn.Write(body[:n]); err != nil {
+			return err
+		}
+		body = body[n:]
+	}
+	return nil
+}
+
+var (
+	influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	influxTagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+)
+
+// influxWriter 拼接 line protocol，公共标签附加在每一行上
+type influxWriter struct {
+	buf  bytes.Buffer
+	tags string
+	ts   string
+}
+
+// influxFieldValue 整数写成 123i，浮点数原样，布尔值为 true/false，其他类型（字符串等）跳过
+func influxFieldValue(v interface{}) (string, bool) {
+	switch n := v.(type) {
+	case bool:
+		return strconv.FormatBool(n), true
+	case int, int32, int64, uint, uint32, uint64:
+		return fmt.Sprintf("%di", n), true
+	case float32, float64:
+		f, _ := toFloat(n)
+		return strconv.FormatFloat(f, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// line 输出一个 measurement，fields 中只取数值和布尔字段，extraTags 为 key、value 交替
+func (w *influxWriter) line(measurement string, fields map[string]interface{}, extraTags ...string) {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var set []string
+	for _, k := range keys {
+		if v, ok := influxFieldValue(fields[k]); ok {
+			set = append(set, influxTagEscaper.Replace(k)+"="+v)
+		}
+	}
+	if len(set) == 0 {
+		return
+	}
+	w.buf.WriteString(influxMeasurementEscaper.Replace(measurement))
+	w.buf.WriteString(w.tags)
+	for i := 0; i+1 < len(extraTags); i += 2 {
+		if extraTags[i+1] == "" {
+			continue
+		}
+		w.buf.WriteString("," + influxTagEscaper.Replace(extraTags[i]) + "=" + influxTagEscaper.Replace(extraTags[i+1]))
+	}
+	w.buf.WriteString(" " + strings.Join(set, ",") + " " + w.ts + "\n")
+}
+
+// influxLines 把一次采集结果转换为 line protocol，公共标签为 host、instance_id、region 和 -label
+func influxLines(info map[string]interface{}, now time.Time) []byte {
+	tags := map[string]string{"instance_id": agentID()}
+	tags["host"] = reportedHostname()
+	if meta, ok := info["oci_metadata"].(map[string]interface{}); ok {
+		if region, ok := meta["region"].(string); ok {
+			tags["region"] = region
+		}
+	}
+	for k, v := range cfg.Labels {
+		tags[k] = v
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	// line protocol 要求标签按 key 排序时写入效率最高
+	sort.Strings(keys)
+	var common strings.Builder
+	for _, k := range keys {
+		if tags[k] != "" {
+			common.WriteString("," + influxTagEscaper.Replace(k) + "=" + influxTagEscaper.Replace(tags[k]))
+		}
+	}
+	w := &influxWriter{tags: common.String(), ts: strconv.FormatInt(now.Unix(), 10)}
+
+	section := func(name string) map[string]interface{} {
+		m, _ := info[name].(map[string]interface{})
+		return m
+	}
+	cpuSection := section("cpu")
+	w.line("cpu", cpuSection)
+	if times, ok := cpuSection["times"].(map[string]interface{}); ok {
+		w.line("cpu_times", times)
+	}
+	if cores, ok := cpuSection["cores"].(map[string]interface{}); ok {
+		for name, c := range cores {
+			if c, ok := c.(map[string]interface{}); ok {
+				w.line("cpu_core", c, "core", name)
+			}
+		}
+	}
+	w.line("mem", section("memory"))
+	w.line("swap", section("swap"))
+	system := map[string]interface{}{"process_count": info["process_count"]}
+	if load, ok := info["load_average"].(map[string]float64); ok {
+		system["load1"], system["load5"], system["load15"] = load["1min"], load["5min"], load["15min"]
+	}
+	w.line("system", system)
+	if fds := section("file_descriptors"); fds != nil {
+		w.line("file_descriptors", fds)
+	}
+	if entropy := section("entropy"); entropy != nil {
+		w.line("entropy", entropy)
+	}
+	for resource, p := range section("pressure") {
+		kinds, _ := p.(map[string]interface{})
+		for kind, values := range kinds {
+			if values, ok := values.(map[string]interface{}); ok {
+				w.line("pressure", values, "kind", kind, "resource", resource)
+			}
+		}
+	}
+
+	if disk := section("disk"); disk != nil {
+		w.line("disk_total", disk)
+		partitions, _ := disk["partitions"].(map[string]interface{})
+		for mountpoint, p := range partitions {
+			if p, ok := p.(map[string]interface{}); ok {
+				device, _ := p["device"].(string)
+				fstype, _ := p["fstype"].(string)
+				w.line("disk", p, "device", device, "fstype", fstype, "mountpoint", mountpoint)
+			}
+		}
+		if io, ok := disk["io"].(map[string]interface{}); ok {
+			devices, _ := io["devices"].(map[string]interface{})
+			for name, d := range devices {
+				if d, ok := d.(map[string]interface{}); ok {
+					w.line("diskio", d, "device", name)
+				}
+			}
+		}
+	}
+	w.line("net_total", section("network"))
+	for name, iface := range section("network_interfaces") {
+		if iface, ok := iface.(map[string]interface{}); ok {
+			w.line("net", iface, "interface", name)
+		}
+	}
+	for name, t := range section("tunnels") {
+		t, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		w.line("tunnel", t, "interface", name)
+		peers, _ := t["peers"].([]interface{})
+		for _, p := range peers {
+			if p, ok := p.(map[string]interface{}); ok {
+				key, _ := p["public_key"].(string)
+				w.line("wireguard_peer", p, "interface", name, "peer", key)
+			}
+		}
+	}
+	for tool, f := range section("firewall") {
+		if f, ok := f.(map[string]interface{}); ok {
+			w.line("firewall", f, "tool", tool)
+		}
+	}
+	if t := section("traffic"); t != nil {
+		w.line("traffic", t)
+	}
+	for name, p := range section("latency") {
+		if p, ok := p.(map[string]interface{}); ok {
+			method, _ := p["method"].(string)
+			w.line("latency", p, "method", method, "probe", name)
+		}
+	}
+	for name, c := range section("checks") {
+		if c, ok := c.(map[string]interface{}); ok {
+			kind, _ := c["type"].(string)
+			w.line("check", c, "check", name, "type", kind)
+		}
+	}
+	for name, j := range section("jobs") {
+		if j, ok := j.(map[string]interface{}); ok {
+			w.line("job", j, "job", name)
+		}
+	}
+	for name, g := range section("gpus") {
+		if g, ok := g.(map[string]interface{}); ok {
+			vendor, _ := g["vendor"].(string)
+			model, _ := g["name"].(string)
+			w.line("gpu", g, "gpu", name, "model", model, "vendor", vendor)
+		}
+	}
+	if smart := section("smart"); smart != nil {
+		devices, _ := smart["devices"].(map[string]interface{})
+		for name, d := range devices {
+			if d, ok := d.(map[string]interface{}); ok {
+				model, _ := d["model"].(string)
+				w.line("smart", d, "device", name, "model", model)
+			}
+		}
+	}
+	for name, s := range section("temperatures") {
+		if s, ok := s.(map[string]interface{}); ok {
+			kind, _ := s["kind"].(string)
+			w.line("temperature", s, "kind", kind, "sensor", name)
+		}
+	}
+	return w.buf.Bytes()
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// mqttReporter 把指标和心跳发布为 retained 消息，主题为 <prefix>/<instance_id>/metrics 和 .../heartbeat，
+// 遗嘱消息在 agent 异常断开时把心跳主题置为 offline，便于 Home Assistant、Node-RED 判断在线状态
+type mqttReporter struct {
+	broker string
+	client mqtt.Client
+	topic  string // <prefix>/<instance_id>
+	qos    byte
+	retain bool
+}
+
+func newMQTTReporter(broker string) (*mqttReporter, error) {
+	r := &mqttReporter{
+		broker: broker,
+		topic:  strings.TrimRight(cfg.MQTTTopicPrefix, "/") + "/" + agentID(),
+		qos:    byte(cfg.MQTTQoS),
+		retain: cfg.MQTTRetain,
+	}
+	will, err := json.Marshal(heartbeatPayload("offline"))
+	if err != nil {
+		return nil, err
+	}
+	clientID := cfg.MQTTClientID
+	if clientID == "" {
+		clientID = "oci-agent-" + agentID()
+	}
+	opts := mqtt.NewClientOptions().
+		AddBroker(broker).
+		SetClientID(clientID).
+		SetUsername(cfg.MQTTUsername).
+		SetPassword(cfg.MQTTPassword).
+		SetBinaryWill(r.topic+"/heartbeat", will, r.qos, true).
+		SetConnectTimeout(cfg.HTTPTimeout).
+		SetWriteTimeout(cfg.HTTPTimeout).
+		// 断线后由客户端在后台重连，启动时 broker 不可达也不阻塞主循环
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetMaxReconnectInterval(cfg.RetryMaxBackoff).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			slog.Warn("mqtt connection lost", "component", "mqtt", "url", broker, "err", err)
+		})
+	if tlsConfig != nil {
+		opts.SetTLSConfig(tlsConfig)
+	}
+	r.client = mqtt.NewClient(opts)
+	// 首次连接最多等待一个请求超时，之后由后台重试，不影响启动
+	if token := r.client.Connect(); !token.WaitTimeout(cfg.HTTPTimeout) {
+		slog.Warn("mqtt broker not reachable yet, retrying in background", "component", "mqtt", "url", broker)
+	} else if err := token.Error(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *mqttReporter) Name() string {
+	return r.broker
+}
+
+func (r *mqttReporter) publish(subtopic string, body []byte) error {
+	if !r.client.IsConnectionOpen() {
+		return fmt.Errorf("mqtt broker %s not connected", r.broker)
+	}
+	token := r.client.Publish(r.topic+"/"+subtopic, r.qos, r.retain, body)
+	if !token.WaitTimeout(cfg.HTTPTimeout) {
+		return fmt.Errorf("mqtt publish to %s timed out", r.topic+"/"+subtopic)
+	}
+	return token.Error()
+}
+
+func (r *mqttReporter) Report(data map[string]interface{}) error {
+	body, err := marshalPayload(data, false)
+	if err != nil {
+		return err
+	}
+	return r.publish("metrics", body)
+}
+
+func (r *mqttReporter) Heartbeat(status string) error {
+	body, err := json.Marshal(heartbeatPayload(status))
+	if err != nil {
+		return err
+	}
+	return r.publish("heartbeat", body)
+}
+
+func (r *mqttReporter) Close() {
+	r.client.Disconnect(uint((250 * time.Millisecond).Milliseconds()))
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/tls"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/shirou/gopsutil/v3/host"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"oci-agent/nezhapb"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// nezhaUUID 是面板识别 agent 的 client_uuid；实例 ID 不是 UUID（如退回到主机名）时按 RFC 4122 v5 的方式从它派生一个固定的 UUID
+func nezhaUUID() string {
+	id := agentID()
+	if uuidPattern.MatchString(id) {
+		return strings.ToLower(id)
+	}
+	sum := sha1.Sum([]byte("oci-agent:" + id))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// nezhaAuth 与哪吒 agent 相同，在每次调用的 metadata 中携带 client_secret 和 client_uuid
+type nezhaAuth struct {
+	secret, uuid string
+	secure       bool
+}
+
+func (a nezhaAuth) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"client_secret": a.secret, "client_uuid": a.uuid}, nil
+}
+
+func (a nezhaAuth) RequireTransportSecurity() bool {
+	return a.secure
+}
+
+// nezhaReporter 按哪吒监控 v1 的 agent 协议上报：建立流时先用 ReportSystemInfo 发送主机信息，
+// 之后每轮通过 ReportSystemState 双向流发送一条 State；哪吒没有心跳，不实现 heartbeater
+type nezhaReporter struct {
+	addr   string
+	conn   *grpc.ClientConn
+	client nezhapb.NezhaServiceClient
+
+	mu     sync.Mutex
+	stream nezhapb.NezhaService_ReportSystemStateClient
+	cancel context.CancelFunc
+	broken error
+}
+
+// newNezhaReporter 与哪吒 agent 一样默认不加密，-nezha-tls 时使用 TLS（沿用 -tls-* 配置）
+func newNezhaReporter(addr, secret string, useTLS bool) (*nezhaReporter, error) {
+	creds := insecure.NewCredentials()
+	if useTLS {
+		conf := tlsConfig
+		if conf == nil {
+			conf = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		creds = credentials.NewTLS(conf)
+	}
+	conn, err := grpc.NewClient(addr,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithPerRPCCredentials(nezhaAuth{secret: secret, uuid: nezhaUUID(), secure: useTLS}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &nezhaReporter{addr: addr, conn: conn, client: nezhapb.NewNezhaServiceClient(conn)}, nil
+}
+
+func (r *nezhaReporter) Name() string {
+	return "nezha://" + r.addr
+}
+
+// openStream 先上报主机信息，面板据此显示系统、CPU 型号和各项总量
+func (r *nezhaReporter) openStream(info map[string]interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTPTimeout)
+	_, err := r.client.ReportSystemInfo(ctx, nezhaHost(info))
+	cancel()
+	if err != nil {
+		return fmt.Errorf("report system info: %w", err)
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	stream, err := r.client.ReportSystemState(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+	r.stream, r.cancel, r.broken = stream, cancel, nil
+	go r.receiveReceipts(stream)
+	return nil
+}
+
+func (r *nezhaReporter) receiveReceipts(stream nezhapb.NezhaService_ReportSystemStateClient) {
+	for {
+		if _, err := stream.Recv(); err != nil {
+			r.mu.Lock()
+			if r.stream == stream {
+				r.broken = err
+			}
+			r.mu.Unlock()
+			return
+		}
+	}
+}
+
+func (r *nezhaReporter) resetStream() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.stream, r.cancel = nil, nil
+}
+
+// Report 使用未经 -convert 换算的数值，哪吒的字段固定为字节和字节每秒
+func (r *nezhaReporter) Report(data map[string]interface{}) error {
+	info, err := rawPayload(data)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.broken != nil {
+		logReportError("reporter", r.Name(), fmt.Errorf("stream closed: %w", r.broken))
+		r.resetStream()
+		r.broken = nil
+	}
+	if r.stream == nil {
+		if err := r.openStream(info); err != nil {
+			return err
+		}
+	}
+	if err := r.stream.Send(nezhaState(info)); err != nil {
+		r.resetStream()
+		return err
+	}
+	return nil
+}
+
+func (r *nezhaReporter) Close() {
+	r.mu.Lock()
+	if r.stream != nil {
+		r.stream.CloseSend()
+	}
+	r.resetStream()
+	r.mu.Unlock()
+	r.conn.Close()
+}
+
+// nezhaSection 和 nezhaNum 从 JSON 解码后的 payload 中取值，缺失时为零值
+func nezhaSection(m map[string]interface{}, name string) map[string]interface{} {
+	s, _ := m[name].(map[string]interface{})
+	return s
+}
+
+func nezhaNum(m map[string]interface{}, key string) float64 {
+	n, _ := m[key].(float64)
+	return n
+}
+
+func nezhaHost(info map[string]interface{}) *nezhapb.Host {
+	str := func(key string) string {
+		s, _ := info[key].(string)
+		return s
+	}
+	cpu := nezhaSection(info, "cpu")
+	h := &nezhapb.Host{
+		Platform:        str("platform"),
+		PlatformVersion: str("platform_version"),
+		MemTotal:        uint64(nezhaNum(nezhaSection(info, "memory"), "total_bytes")),
+		SwapTotal:       uint64(nezhaNum(nezhaSection(info, "swap"), "total_bytes")),
+		DiskTotal:       uint64(nezhaNum(nezhaSection(info, "disk"), "total_bytes")),
+		Arch:            str("architecture"),
+		Virtualization:  str("virtualization"),
+		Version:         "oci-agent " + version,
+	}
+	// 面板按 gopsutil 的发行版 ID（ubuntu、debian 等）显示系统图标，payload 中的 distribution 是完整名称
+	if platform, _, platformVersion, err := host.PlatformInformation(); err == nil && platform != "" {
+		h.Platform, h.PlatformVersion = platform, platformVersion
+	}
+	// 与哪吒 agent 的格式相同：型号 核数 Virtual Core
+	if model, _ := cpu["model"].(string); model != "" {
+		h.Cpu = []string{fmt.Sprintf("%s %d Virtual Core", model, int(nezhaNum(cpu, "count")))}
+	}
+	if boot, err := host.BootTime(); err == nil {
+		h.BootTime = boot
+	}
+	for _, g := range sortedSection(nezhaSection(info, "gpus")) {
+		if name, _ := g["name"].(string); name != "" {
+			h.Gpu = append(h.Gpu, name)
+		}
+	}
+	return h
+}
+
+// nezhaState 把一轮采集映射到哪吒的 State；连接数为各 TCP 状态之和，与哪吒 agent 一致
+func nezhaState(info map[string]interface{}) *nezhapb.State {
+	network := nezhaSection(info, "network")
+	load := nezhaSection(info, "load_average")
+	s := &nezhapb.State{
+		Cpu:            nezhaNum(nezhaSection(info, "cpu"), "percent"),
+		MemUsed:        uint64(nezhaNum(nezhaSection(info, "memory"), "used_bytes")),
+		SwapUsed:       uint64(nezhaNum(nezhaSection(info, "swap"), "used_bytes")),
+		DiskUsed:       uint64(nezhaNum(nezhaSection(info, "disk"), "used_bytes")),
+		NetInTransfer:  uint64(nezhaNum(network, "download_total_bytes")),
+		NetOutTransfer: uint64(nezhaNum(network, "upload_total_bytes")),
+		NetInSpeed:     uint64(nezhaNum(network, "download_speed_bytes_per_sec")),
+		NetOutSpeed:    uint64(nezhaNum(network, "upload_speed_bytes_per_sec")),
+		Load1:          nezhaNum(load, "1min"),
+		Load5:          nezhaNum(load, "5min"),
+		Load15:         nezhaNum(load, "15min"),
+		ProcessCount:   uint64(nezhaNum(info, "process_count")),
+	}
+	if uptime, err := host.Uptime(); err == nil {
+		s.Uptime = uptime
+	}
+	if conns := nezhaSection(info, "connections"); conns != nil {
+		for _, n := range nezhaSection(conns, "tcp") {
+			if n, ok := n.(float64); ok {
+				s.TcpConnCount += uint64(n)
+			}
+		}
+		s.UdpConnCount = uint64(nezhaNum(conns, "udp_sockets"))
+	}
+	temps := nezhaSection(info, "temperatures")
+	names := make([]string, 0, len(temps))
+	for name := range temps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if t, ok := temps[name].(map[string]interface{}); ok {
+			s.Temperatures = append(s.Temperatures, &nezhapb.State_SensorTemperature{Name: name, Temperature: nezhaNum(t, "current")})
+		}
+	}
+	for _, g := range sortedSection(nezhaSection(info, "gpus")) {
+		s.Gpu = append(s.Gpu, nezhaNum(g, "utilization_percent"))
+	}
+	return s
+}
+
+// sortedSection 按名称顺序返回 section 中的子对象，保证 GPU 在 Host 和 State 中的顺序一致
+func sortedSection(section map[string]interface{}) []map[string]interface{} {
+	names := make([]string, 0, len(section))
+	for name := range section {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var out []map[string]interface{}
+	for _, name := range names {
+		if m, ok := section[name].(map[string]interface{}); ok {
+			out = append(out, m)
+		}
+	}
+	return out
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	"oci-agent/nezhapb"
+)
+
+// wireTags 返回消息编码后按出现顺序的字段编号
+func wireTags(t *testing.T, m proto.Message) []protowire.Number {
+	t.Helper()
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tags []protowire.Number
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatal(protowire.ParseError(n))
+		}
+		b = b[n:]
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			t.Fatal(protowire.ParseError(n))
+		}
+		b = b[n:]
+		tags = append(tags, num)
+	}
+	return tags
+}
+
+// 字段编号必须与 nezhahq/agent 的 proto/nezha.proto 一致，否则面板会把数值解码到别的字段
+func TestNezhaWireTags(t *testing.T) {
+	state := &nezhapb.State{
+		Cpu: 1, MemUsed: 3, SwapUsed: 4, DiskUsed: 5,
+		NetInTransfer: 6, NetOutTransfer: 7, NetInSpeed: 8, NetOutSpeed: 9,
+		Uptime: 10, Load1: 11, Load5: 12, Load15: 13,
+		TcpConnCount: 14, UdpConnCount: 15, ProcessCount: 16,
+		Temperatures: []*nezhapb.State_SensorTemperature{{Name: "cpu", Temperature: 40}},
+		Gpu:          []float64{18},
+	}
+	want := []protowire.Number{1, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18}
+	if got := wireTags(t, state); !equalTags(got, want) {
+		t.Errorf("State tags = %v, want %v", got, want)
+	}
+
+	hostInfo := &nezhapb.Host{
+		Platform: "linux", PlatformVersion: "22.04", Cpu: []string{"x"},
+		MemTotal: 4, DiskTotal: 5, SwapTotal: 6, Arch: "arm64", Virtualization: "kvm",
+		BootTime: 9, Version: "v1", Gpu: []string{"g"},
+	}
+	want = []protowire.Number{1, 2, 3, 4, 5, 6, 7, 8, 9, 12, 13}
+	if got := wireTags(t, hostInfo); !equalTags(got, want) {
+		t.Errorf("Host tags = %v, want %v", got, want)
+	}
+}
+
+func equalTags(a, b []protowire.Number) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestNezhaStateIgnoresUnitConversions(t *testing.T) {
+	saved := unitConversions
+	defer func() { unitConversions = saved }()
+	conversions, err := compileUnitConversions(map[string]string{
+		"memory.used_bytes":                    "MiB",
+		"network.download_speed_bytes_per_sec": "Mbps",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unitConversions = conversions
+
+	data := map[string]interface{}{
+		"memory":  map[string]interface{}{"used": "1.50G", "used_bytes": uint64(1610612736)},
+		"network": map[string]interface{}{"download_speed_bytes_per_sec": uint64(12500000), "download_total_bytes": uint64(1 << 40)},
+	}
+	info, err := rawPayload(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := nezhaState(info)
+	if s.MemUsed != 1610612736 {
+		t.Errorf("MemUsed = %d, want raw bytes 1610612736", s.MemUsed)
+	}
+	if s.NetInSpeed != 12500000 || s.NetInTransfer != 1<<40 {
+		t.Errorf("NetInSpeed/NetInTransfer = %d/%d, want raw bytes", s.NetInSpeed, s.NetInTransfer)
+	}
+	// 原始数据不被修改
+	if data["memory"].(map[string]interface{})["used"] != "1.50G" {
+		t.Error("rawPayload modified the collected data")
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsWriteTimeout = 10 * time.Second
+	wsPingInterval = 30 * time.Second
+)
+
+// WebSocket 上复用的通道，每一帧都是 {"channel": ..., "data": ...}
+const (
+	wsChannelMetrics    = "metrics"
+	wsChannelHeartbeat  = "heartbeat"
+	wsChannelTask       = "task"
+	wsChannelTaskResult = "task_result"
+)
+
+type wsFrame struct {
+	Channel string          `json:"channel"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// wsReporter 通过一条长连接的 WebSocket 复用指标、心跳和任务通道，指标帧与 HTTP 上报的 JSON 结构相同，
+// 连接断开后按指数退避重连，不会影响 agent 主循环
+type wsReporter struct {
+	url string
+
+	mu        sync.Mutex
+	conn      *websocket.Conn
+	failures  int
+	nextDial  time.Time
+	connDeath chan struct{}
+}
+
+func newWSReporter(url string) *wsReporter {
+	return &wsReporter{url: url}
+}
+
+func (r *wsReporter) Name() string {
+	return r.url
+}
+
+func (r *wsReporter) dial() error {
+	if now := time.Now(); now.Before(r.nextDial) {
+		return fmt.Errorf("websocket reconnect backing off for %s", r.nextDial.Sub(now).Round(time.Second))
+	}
+	header := http.Header{}
+	setAuthHeaders(header)
+	if err := signRequest(header, nil); err != nil {
+		return err
+	}
+	dialer := websocket.Dialer{
+		Proxy:            httpProxy,
+		HandshakeTimeout: cfg.HTTPTimeout,
+		TLSClientConfig:  tlsConfig,
+	}
+	conn, _, err := dialer.Dial(r.url, header)
+	if err != nil {
+		r.failures++
+		r.nextDial = time.Now().Add(backoffDelay(r.failures, cfg.RetryMaxBackoff))
+		return err
+	}
+	slog.Info("websocket connected", "component", "websocket", "url", r.url)
+	r.conn, r.failures = conn, 0
+	dead := make(chan struct{})
+	r.connDeath = dead
+
+	// 读循环负责处理 ping/close 控制帧和下发的任务，读出错说明连接已断
+	go func() {
+		defer close(dead)
+		// 网页终端绑定在这条连接上，连接断开后没有人能再看到它们
+		defer terminals.closeAll()
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				slog.Warn("websocket disconnected", "component", "websocket", "url", r.url, "err", err)
+				return
+			}
+			r.handleFrame(message)
+		}
+	}()
+	go func() {
+		ticker := time.NewTicker(wsPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-dead:
+				return
+			case <-ticker.C:
+				conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout))
+			}
+		}
+	}()
+	return nil
+}
+
+func (r *wsReporter) handleFrame(message []byte) {
+	var frame wsFrame
+	if err := json.Unmarshal(message, &frame); err != nil {
+		slog.Warn("invalid websocket frame", "component", "websocket", "url", r.url, "err", err)
+		return
+	}
+	switch frame.Channel {
+	case wsChannelTask:
+		var t task
+		if err := json.Unmarshal(frame.Data, &t); err != nil {
+			slog.Warn("invalid task frame", "component", "websocket", "url", r.url, "err", err)
+			return
+		}
+		go taskExec.handle(t, r.url, func(body []byte) error { return r.send(wsChannelTaskResult, body) })
+	case wsChannelTerminal:
+		var msg terminalMessage
+		if err := json.Unmarshal(frame.Data, &msg); err != nil {
+			slog.Warn("invalid terminal frame", "component", "websocket", "url", r.url, "err", err)
+			return
+		}
+		// 在读循环中按顺序处理，保证输入不乱序
+		terminals.handle(msg, func(body []byte) error { return r.send(wsChannelTerminal, body) })
+	default:
+		slog.Debug("ignoring websocket frame", "component", "websocket", "url", r.url, "channel", frame.Channel)
+	}
+}
+
+func (r *wsReporter) closeLocked() {
+	if r.conn != nil {
+		r.conn.Close()
+		r.conn = nil
+	}
+}
+
+func (r *wsReporter) Report(data map[string]interface{}) error {
+	body, err := marshalPayload(data, false)
+	if err != nil {
+		return err
+	}
+	return r.send(wsChannelMetrics, body)
+}
+
+func (r *wsReporter) Heartbeat(status string) error {
+	body, err := marshalPayload(heartbeatPayload(status), false)
+	if err != nil {
+		return err
+	}
+	return r.send(wsChannelHeartbeat, body)
+}
+
+// send 把 data 包装成指定通道的帧发送，必要时先重连
+func (r *wsReporter) send(channel string, data []byte) error {
+	body, err := json.Marshal(wsFrame{Channel: channel, Data: data})
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn != nil {
+		select {
+		case <-r.connDeath:
+			r.closeLocked()
+		default:
+		}
+	}
+	if r.conn == nil {
+		if err := r.dial(); err != nil {
+			return err
+		}
+	}
+	r.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if err := r.conn.WriteMessage(websocket.TextMessage, body); err != nil {
+		r.closeLocked()
+		return err
+	}
+	return nil
+}
+
+func (r *wsReporter) Close() {
+	terminals.closeAll()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn != nil {
+		r.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	}
+	r.closeLocked()
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+var (
+	selfProcOnce sync.Once
+	selfProc     *process.Process
+)
+
+// getSelf 返回 agent 自身的资源占用；cpu_percent 是距上一次采集以来的平均值（可超过 100，按单核计）
+func getSelf() map[string]interface{} {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	section := map[string]interface{}{
+		"goroutines":        runtime.NumGoroutine(),
+		"heap_alloc":        formatBytes(ms.HeapAlloc),
+		"heap_alloc_bytes":  ms.HeapAlloc,
+		"heap_sys_bytes":    ms.HeapSys,
+		"gc_count":          ms.NumGC,
+		"gc_pause_total_ms": math.Round(float64(ms.PauseTotalNs)/1e4) / 100,
+		"uptime_seconds":    int64(time.Since(agentStartTime).Seconds()),
+		"version":           version,
+	}
+	if ms.NumGC > 0 {
+		// PauseNs 是最近 256 次 GC 停顿的环形缓冲
+		section["gc_last_pause_ms"] = math.Round(float64(ms.PauseNs[(ms.NumGC+255)%256])/1e4) / 100
+	}
+	selfProcOnce.Do(func() {
+		selfProc, _ = process.NewProcess(int32(os.Getpid()))
+	})
+	if selfProc == nil {
+		return section
+	}
+	if mem, err := selfProc.MemoryInfo(); err == nil {
+		section["rss"] = formatBytes(mem.RSS)
+		section["rss_bytes"] = mem.RSS
+	}
+	// Percent(0) 与上一次调用比较，首次调用返回自进程启动以来的平均值
+	if pct, err := selfProc.Percent(0); err == nil {
+		section["cpu_percent"] = math.Round(pct*100) / 100
+	}
+	if threads, err := selfProc.NumThreads(); err == nil {
+		section["threads"] = threads
+	}
+	if fds, err := selfProc.NumFDs(); err == nil {
+		section["open_fds"] = fds
+	}
+	return section
+}
+
+// checkLoopbackAddr 确保 -pprof-listen 只监听回环地址，pprof 会暴露内存内容和命令行参数（可能包含令牌）
+func checkLoopbackAddr(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("%q is not a loopback address, use 127.0.0.1:PORT or [::1]:PORT", addr)
+}
+
+// servePprof 在独立的端口上提供 net/http/pprof，不注册到 -listen 的服务上
+func servePprof(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return http.ListenAndServe(addr, mux)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/host"
+)
+
+// 传感器分类，-temp-warn 可以按分类或传感器名 glob 设置告警阈值
+const (
+	sensorKindCPU  = "cpu"
+	sensorKindNVMe = "nvme"
+)
+
+// sensorKind 按 hwmon 驱动名识别 CPU 封装温度和 NVMe 温度，其余传感器返回空
+func sensorKind(key string) string {
+	switch {
+	case strings.HasPrefix(key, "coretemp_package"),
+		strings.HasPrefix(key, "k10temp_tctl"), strings.HasPrefix(key, "k10temp_tdie"),
+		strings.HasPrefix(key, "zenpower_tdie"), strings.HasPrefix(key, "cpu_thermal"):
+		return sensorKindCPU
+	case strings.HasPrefix(key, "nvme_composite"):
+		return sensorKindNVMe
+	}
+	return ""
+}
+
+type tempThreshold struct {
+	pattern string // 分类名或传感器名 glob
+	celsius float64
+}
+
+var tempThresholds []tempThreshold
+
+// compileTempThresholds 解析 -temp-warn 的 kind-or-glob=celsius，更具体的传感器名优先于分类
+func compileTempThresholds(specs map[string]string) ([]tempThreshold, error) {
+	var thresholds []tempThreshold
+	for pattern, value := range specs {
+		celsius, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid temperature %q", pattern, value)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%s: %w", pattern, err)
+		}
+		thresholds = append(thresholds, tempThreshold{pattern, celsius})
+	}
+	// 分类放在最后，按 glob 匹配的传感器名先命中
+	sort.SliceStable(thresholds, func(i, j int) bool {
+		iKind := thresholds[i].pattern == sensorKindCPU || thresholds[i].pattern == sensorKindNVMe
+		jKind := thresholds[j].pattern == sensorKindCPU || thresholds[j].pattern == sensorKindNVMe
+		if iKind != jKind {
+			return jKind
+		}
+		return thresholds[i].pattern < thresholds[j].pattern
+	})
+	return thresholds, nil
+}
+
+// warnThreshold 返回传感器的告警阈值，未配置时使用传感器自身上报的 high 值
+func warnThreshold(key, kind string, high float64) (float64, bool) {
+	for _, t := range tempThresholds {
+		if ok, _ := path.Match(t.pattern, key); ok || (kind != "" && t.pattern == kind) {
+			return t.celsius, true
+		}
+	}
+	return high, high > 0
+}
+
+// getTemperatures 返回各传感器的当前/高温/临界温度，没有传感器的平台或虚拟机返回空 map。
+// 识别出的 CPU 封装和 NVMe 传感器带 kind 字段，超过阈值时 warning 为 true
+func getTemperatures() map[string]interface{} {
+	// 部分传感器读取失败时 gopsutil 仍会返回其余结果和一个 warning 错误
+	stats, _ := host.SensorsTemperatures()
+	temps := make(map[string]interface{}, len(stats))
+	for _, t := range stats {
+		current := math.Round(t.Temperature*10) / 10
+		sensor := map[string]interface{}{
+			"current":  current,
+			"high":     t.High,
+			"critical": t.Critical,
+		}
+		kind := sensorKind(t.SensorKey)
+		if kind != "" {
+			sensor["kind"] = kind
+		}
+		if threshold, ok := warnThreshold(t.SensorKey, kind, t.High); ok {
+			sensor["warn_threshold"] = threshold
+			sensor["warning"] = current >= threshold
+		}
+		temps[t.SensorKey] = sensor
+	}
+	return temps
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// serveHTTP 启动 -listen 指定的 HTTP 服务，与推送循环同时运行
+func serveHTTP(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", metricsHandler)
+	mux.HandleFunc("/healthz", healthzHandler)
+	mux.HandleFunc("/info", infoHandler)
+	mux.HandleFunc("/refresh", refreshHandler)
+	mux.HandleFunc("/history", historyHandler)
+	return http.ListenAndServe(addr, mux)
+}
+
+// infoHandler 返回与上报内容相同的 JSON，供监控系统直接拉取
+func infoHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := marshalPayload(getSystemInfo(), r.URL.Query().Has("pretty"))
+	if err != nil {
+		slog.Error("marshal info failed", "component", "http-server", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// refreshHandler 重新采集缓存的主机信息（CPU 型号、发行版、分区列表等），效果与 SIGHUP 相同
+func refreshHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	slog.Info("refreshing host facts", "component", "facts", "trigger", "http")
+	facts.refresh()
+	w.WriteHeader(http.StatusNoContent)
+}
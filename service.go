@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	serviceName     = "oci-agent"
+	serviceUnitPath = "/etc/systemd/system/" + serviceName + ".service"
+)
+
+const serviceUnitTemplate = `[Unit]
+Description=OCI agent
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=simple
+ExecStart=%s
+ExecReload=/bin/kill -HUP $MAINPID
+Restart=always
+RestartSec=5
+
+[Install]
+WantedBy=multi-user.target
+`
+
+// systemdQuote 按 systemd 的规则给 ExecStart 的参数加引号，% 和 $ 需要转义
+func systemdQuote(arg string) string {
+	arg = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$").Replace(arg)
+	return `"` + arg + `"`
+}
+
+func serviceUnit(exe string, args []string) string {
+	parts := []string{systemdQuote(exe), cmdRun}
+	for _, arg := range args {
+		parts = append(parts, systemdQuote(arg))
+	}
+	return fmt.Sprintf(serviceUnitTemplate, strings.Join(parts, " "))
+}
+
+func systemctl(args ...string) error {
+	out, err := exec.Command("systemctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func checkSystemd() error {
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("install and uninstall only support systemd on linux, not %s", runtime.GOOS)
+	}
+	if _, err := os.Stat("/run/systemd/system"); err != nil {
+		return fmt.Errorf("systemd is not running on this host")
+	}
+	return nil
+}
+
+// installService 写入 systemd unit 并立即启用，args 为 install 之后的参数，原样传给 run
+func installService(args []string) error {
+	if err := checkSystemd(); err != nil {
+		return err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(serviceUnitPath, []byte(serviceUnit(exe, args)), 0644); err != nil {
+		return fmt.Errorf("write %s: %w", serviceUnitPath, err)
+	}
+	if err := systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	if err := systemctl("enable", "--now", serviceName); err != nil {
+		return err
+	}
+	slog.Info("service installed", "component", "service", "unit", serviceUnitPath)
+	return nil
+}
+
+func uninstallService() error {
+	if err := checkSystemd(); err != nil {
+		return err
+	}
+	// 服务可能已经被手动停止或禁用，这里的错误不影响删除 unit
+	if err := systemctl("disable", "--now", serviceName); err != nil {
+		slog.Warn("disable service failed", "component", "service", "err", err)
+	}
+	if err := os.Remove(serviceUnitPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	slog.Info("service uninstalled", "component", "service", "unit", serviceUnitPath)
+	return nil
+}
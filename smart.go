@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// smartctlReport 是 smartctl -j 输出中用到的字段，ATA 与 NVMe 设备各自只有一部分
+type smartctlReport struct {
+	Smartctl struct {
+		ExitStatus int `json:"exit_status"`
+		Messages   []struct {
+			String   string `json:"string"`
+			Severity string `json:"severity"`
+		} `json:"messages"`
+	} `json:"smartctl"`
+	Device struct {
+		Name     string `json:"name"`
+		Type     string `json:"type"`
+		Protocol string `json:"protocol"`
+	} `json:"device"`
+	ModelName    string `json:"model_name"`
+	SerialNumber string `json:"serial_number"`
+	SmartStatus  *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	Temperature struct {
+		Current *int `json:"current"`
+	} `json:"temperature"`
+	PowerOnTime struct {
+		Hours *uint64 `json:"hours"`
+	} `json:"power_on_time"`
+	ATASmartAttributes struct {
+		Table []struct {
+			ID    int    `json:"id"`
+			Name  string `json:"name"`
+			Value int    `json:"value"`
+			Raw   struct {
+				Value uint64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+	NVMeHealth *struct {
+		CriticalWarning uint64 `json:"critical_warning"`
+		AvailableSpare  uint64 `json:"available_spare"`
+		PercentageUsed  uint64 `json:"percentage_used"`
+		MediaErrors     uint64 `json:"media_errors"`
+		UnsafeShutdowns uint64 `json:"unsafe_shutdowns"`
+	} `json:"nvme_smart_health_information_log"`
+}
+
+// smartctl 的退出码按位表示结果，第 0、1 位是参数错误或无法打开设备，其余位（如磁盘将要故障）
+// 仍然伴随完整的 JSON 输出
+const smartctlFatalBits = 0x3
+
+// ATA 属性中的寿命指标，不同厂商使用不同的 ID，取归一化值（100 为全新）
+var ataWearAttributes = map[int]bool{
+	177: true, // Wear_Leveling_Count（Samsung）
+	202: true, // Percent_Lifetime_Remain（Micron/Crucial）
+	231: true, // SSD_Life_Left
+	233: true, // Media_Wearout_Indicator（Intel）
+}
+
+func runSmartctl(ctx context.Context, args ...string) ([]byte, error) {
+	out, err := exec.CommandContext(ctx, "smartctl", append([]string{"-j"}, args...)...).Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode()&smartctlFatalBits == 0 && len(out) > 0 {
+		err = nil
+	}
+	return out, err
+}
+
+// smartDevice 将一个设备的 smartctl 结果转换为上报字段，health 为 PASSED 或 FAILED，
+// 设备不支持 SMART（如 OCI 的半虚拟化块存储）时为 UNKNOWN
+func smartDevice(r smartctlReport) map[string]interface{} {
+	d := map[string]interface{}{"protocol": r.Device.Protocol, "health": "UNKNOWN"}
+	if r.ModelName != "" {
+		d["model"] = r.ModelName
+	}
+	if r.SerialNumber != "" {
+		d["serial"] = r.SerialNumber
+	}
+	if r.SmartStatus != nil {
+		d["passed"] = r.SmartStatus.Passed
+		d["health"] = "FAILED"
+		if r.SmartStatus.Passed {
+			d["health"] = "PASSED"
+		}
+	}
+	if r.Temperature.Current != nil {
+		d["temperature_celsius"] = *r.Temperature.Current
+	}
+	if r.PowerOnTime.Hours != nil {
+		d["power_on_hours"] = *r.PowerOnTime.Hours
+	}
+	for _, attr := range r.ATASmartAttributes.Table {
+		switch attr.ID {
+		case 5:
+			d["reallocated_sectors"] = attr.Raw.Value
+		case 197:
+			d["pending_sectors"] = attr.Raw.Value
+		case 198:
+			d["offline_uncorrectable"] = attr.Raw.Value
+		}
+		if ataWearAttributes[attr.ID] {
+			d["life_remaining_percent"] = attr.Value
+		}
+	}
+	if h := r.NVMeHealth; h != nil {
+		d["critical_warning"] = h.CriticalWarning
+		d["available_spare_percent"] = h.AvailableSpare
+		d["media_errors"] = h.MediaErrors
+		d["unsafe_shutdowns"] = h.UnsafeShutdowns
+		// percentage_used 可能超过 100，表示已超出厂商标称的写入寿命
+		remaining := 0
+		if h.PercentageUsed < 100 {
+			remaining = 100 - int(h.PercentageUsed)
+		}
+		d["life_remaining_percent"] = remaining
+	}
+	return d
+}
+
+// scanSMART 用 smartctl --scan 列出设备并逐个读取，读取 SMART 通常需要 root
+func scanSMART() (map[string]interface{}, error) {
+	if _, err := exec.LookPath("smartctl"); err != nil {
+		return nil, fmt.Errorf("smartctl not found, install smartmontools")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	out, err := runSmartctl(ctx, "--scan")
+	if err != nil {
+		return nil, fmt.Errorf("smartctl --scan: %w", err)
+	}
+	var scan struct {
+		Devices []struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		} `json:"devices"`
+	}
+	if err := json.Unmarshal(out, &scan); err != nil {
+		return nil, fmt.Errorf("parse smartctl --scan: %w", err)
+	}
+	devices := make(map[string]interface{})
+	failed := 0
+	for _, dev := range scan.Devices {
+		name := filepath.Base(dev.Name)
+		out, err := runSmartctl(ctx, "-a", "-d", dev.Type, dev.Name)
+		var r smartctlReport
+		if jerr := json.Unmarshal(out, &r); jerr != nil || err != nil {
+			if err == nil {
+				err = jerr
+			}
+			devices[name] = map[string]interface{}{"health": "UNKNOWN", "error": err.Error()}
+			continue
+		}
+		d := smartDevice(r)
+		if d["health"] == "UNKNOWN" {
+			for _, m := range r.Smartctl.Messages {
+				if m.Severity == "error" {
+					d["error"] = m.String
+					break
+				}
+			}
+		}
+		if d["health"] == "FAILED" {
+			failed++
+		}
+		devices[name] = d
+	}
+	return map[string]interface{}{"devices": devices, "failed": failed}, nil
+}
+
+// smartCache 缓存 SMART 结果，磁盘健康变化很慢，不需要每个周期都唤醒磁盘读取
+type smartCache struct {
+	mu      sync.Mutex
+	fetched time.Time
+	value   map[string]interface{}
+	warned  bool
+}
+
+var smartStatus = &smartCache{}
+
+func (c *smartCache) get(refresh time.Duration) map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.fetched.IsZero() && time.Since(c.fetched) < refresh {
+		return c.value
+	}
+	value, err := scanSMART()
+	c.fetched = time.Now()
+	if err != nil {
+		// 只在第一次失败时警告，之后每次刷新失败都以 debug 记录
+		if !c.warned {
+			slog.Warn("smart data unavailable", "component", "smart", "err", err)
+			c.warned = true
+		} else {
+			slog.Debug("smart data unavailable", "component", "smart", "err", err)
+		}
+		return c.value
+	}
+	c.value = value
+	return c.value
+}
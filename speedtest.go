@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// speedtestCooldown 是两次测速之间的最短间隔，避免控制端重复下发时反复占满带宽（OCI 按出站流量计费）
+const speedtestCooldown = 5 * time.Minute
+
+var errSpeedtestBusy = errors.New("a speedtest is already running")
+
+// speedtester 保证同一时间只有一次测速，并保存定时测速的最新结果，随下一次上报以 speedtest 字段发送一次
+type speedtester struct {
+	mu      sync.Mutex
+	running bool
+	last    time.Time
+	pending map[string]interface{}
+}
+
+var speedtests = &speedtester{}
+
+func (s *speedtester) begin() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return errSpeedtestBusy
+	}
+	if wait := speedtestCooldown - time.Since(s.last); !s.last.IsZero() && wait > 0 {
+		return fmt.Errorf("last speedtest ran %s ago, wait %s", time.Since(s.last).Round(time.Second), wait.Round(time.Second))
+	}
+	s.running = true
+	return nil
+}
+
+func (s *speedtester) end() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running, s.last = false, time.Now()
+}
+
+// run 测速一次，target 为空时使用 -speedtest-url，也可以是 iperf3://HOST[:PORT]
+func (s *speedtester) run(target string) (map[string]interface{}, error) {
+	if target == "" {
+		target = cfg.SpeedtestURL
+	}
+	if target == "" {
+		return nil, fmt.Errorf("no speedtest target, set -speedtest-url or pass one in the task")
+	}
+	if err := s.begin(); err != nil {
+		return nil, err
+	}
+	defer s.end()
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]interface{}{"server": u.Redacted(), "measured_at": time.Now().Format("2006-01-02 15:04:05")}
+	switch u.Scheme {
+	case "iperf3":
+		result["method"] = "iperf3"
+		err = iperfSpeedtest(u.Host, result)
+	case "http", "https":
+		result["method"] = "http"
+		err = httpSpeedtest(target, cfg.SpeedtestUploadURL, result)
+	default:
+		err = fmt.Errorf("unsupported speedtest target %q, expected http(s):// or iperf3://", target)
+	}
+	return result, err
+}
+
+// rateFields 以与网速相同的格式写入结果，如 download_speed 和 download_speed_bytes_per_sec
+func rateFields(result map[string]interface{}, direction string, bytes uint64, elapsed time.Duration) {
+	rate := float64(bytes) / elapsed.Seconds()
+	result[direction+"_speed"] = formatBytes(uint64(rate))
+	result[direction+"_speed_bytes_per_sec"] = uint64(rate)
+	result[direction+"_bytes"] = bytes
+	result[direction+"_seconds"] = math.Round(elapsed.Seconds()*100) / 100
+}
+
+// httpSpeedtest 下载 downloadURL 直到 -speedtest-duration 或 -speedtest-max-bytes，再按同样的限制向 uploadURL POST
+func httpSpeedtest(downloadURL, uploadURL string, result map[string]interface{}) error {
+	client := newHTTPClient(cfg.SpeedtestDuration+cfg.HTTPTimeout, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.SpeedtestDuration)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(io.Discard, io.LimitReader(resp.Body, cfg.SpeedtestMaxBytes))
+	resp.Body.Close()
+	elapsed := time.Since(start)
+	if resp.StatusCode != http.StatusOK {
+		return &statusError{code: resp.StatusCode}
+	}
+	// 到达时长上限是正常结束
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	rateFields(result, "download", uint64(n), elapsed)
+
+	if uploadURL == "" {
+		return nil
+	}
+	upCtx, upCancel := context.WithTimeout(context.Background(), cfg.SpeedtestDuration)
+	defer upCancel()
+	body := &countingReader{r: io.LimitReader(zeroReader{}, cfg.SpeedtestMaxBytes)}
+	req, err = http.NewRequestWithContext(upCtx, http.MethodPost, uploadURL, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	start = time.Now()
+	resp, err = client.Do(req)
+	elapsed = time.Since(start)
+	if err == nil {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return &statusError{code: resp.StatusCode}
+		}
+	} else if !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	rateFields(result, "upload", body.n, elapsed)
+	return nil
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n uint64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += uint64(n)
+	return n, err
+}
+
+// iperfSpeedtest 调用本机的 iperf3 客户端，先测上传再用 -R 测下载
+func iperfSpeedtest(hostport string, result map[string]interface{}) error {
+	host, port := hostport, "5201"
+	if h, p, err := net.SplitHostPort(hostport); err == nil {
+		host, port = h, p
+	}
+	seconds := strconv.Itoa(int(math.Max(1, cfg.SpeedtestDuration.Seconds()/2)))
+	for _, direction := range []string{"upload", "download"} {
+		args := []string{"-c", host, "-p", port, "-J", "-t", seconds}
+		if direction == "download" {
+			args = append(args, "-R")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.SpeedtestDuration+cfg.HTTPTimeout)
+		out, err := exec.CommandContext(ctx, "iperf3", args...).Output()
+		cancel()
+		var report struct {
+			End struct {
+				SumReceived struct {
+					Bytes   uint64  `json:"bytes"`
+					Seconds float64 `json:"seconds"`
+				} `json:"sum_received"`
+			} `json:"end"`
+			Error string `json:"error"`
+		}
+		if jerr := json.Unmarshal(out, &report); jerr != nil {
+			if err != nil {
+				return fmt.Errorf("iperf3: %w", err)
+			}
+			return fmt.Errorf("iperf3: %w", jerr)
+		}
+		if report.Error != "" {
+			return fmt.Errorf("iperf3: %s", report.Error)
+		}
+		sum := report.End.SumReceived
+		if sum.Seconds <= 0 {
+			return fmt.Errorf("iperf3 reported no data")
+		}
+		rateFields(result, direction, sum.Bytes, time.Duration(sum.Seconds*float64(time.Second)))
+	}
+	return nil
+}
+
+// schedule 定期测速，结果不写入 network 的实时网速，而是保存起来随下一次上报发送一次
+func (s *speedtester) schedule(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		result, err := s.run("")
+		if err != nil {
+			if result == nil {
+				slog.Warn("speedtest skipped", "component", "speedtest", "err", err)
+				continue
+			}
+			result["error"] = err.Error()
+			slog.Warn("speedtest failed", "component", "speedtest", "url", result["server"], "err", err)
+		} else {
+			slog.Info("speedtest finished", "component", "speedtest", "url", result["server"], "download", result["download_speed"], "upload", result["upload_speed"])
+		}
+		s.mu.Lock()
+		s.pending = result
+		s.mu.Unlock()
+	}
+}
+
+// takeResult 取出尚未上报的定时测速结果，每个结果只上报一次
+func (s *speedtester) takeResult() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := s.pending
+	s.pending = nil
+	return result
+}
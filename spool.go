@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// spool 在采集端不可达时把上报数据按行追加到本地文件，恢复后按顺序补发
+type spool struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+	lines    int        // 积压的条数，与文件内容同步维护，size 无需重新读取文件
+	bytes    int64      // 文件大小，append 据此判断是否超过 maxBytes
+	dropped  uint64     // 累计因超过 maxBytes 丢弃的记录数，flush 据此校正要删除的条数
+	flushing sync.Mutex // 同一时间只有一次补发
+}
+
+func newSpool(path string, maxBytes int64) (*spool, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	s := &spool{path: path, maxBytes: maxBytes}
+	// 上次运行遗留的积压只在启动时读取一次
+	lines, err := s.readLines()
+	if err != nil {
+		slog.Warn("read spool failed", "component", "spool", "path", path, "err", err)
+	}
+	s.lines = len(lines)
+	if st, err := os.Stat(path); err == nil {
+		s.bytes = st.Size()
+	}
+	return s, nil
+}
+
+func (s *spool) readLines() ([][]byte, error) {
+	content, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var lines [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), int(s.maxBytes)+1)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) > 0 {
+			lines = append(lines, append([]byte(nil), scanner.Bytes()...))
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// writeLines 先写临时文件再 rename，避免写到一半时进程退出导致文件损坏；调用方持有 s.mu
+func (s *spool) writeLines(lines [][]byte) error {
+	if len(lines) == 0 {
+		err := os.Remove(s.path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		s.lines, s.bytes = 0, 0
+		return nil
+	}
+	tmp := s.path + ".tmp"
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	if err := ioutil.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	s.lines, s.bytes = len(lines), int64(buf.Len())
+	return nil
+}
+
+// size 返回积压的条数
+func (s *spool) size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lines
+}
+
+// append 以 O_APPEND 追加一行，只有超过 maxBytes 需要丢弃最旧的记录时才重写整个文件
+func (s *spool) append(payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.bytes+int64(len(payload))+1 > s.maxBytes {
+		return s.trimLocked(payload)
+	}
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	// 整行一次写入，避免与其他写入交错
+	line := make([]byte, 0, len(payload)+1)
+	line = append(append(line, payload...), '\n')
+	_, err = f.Write(line)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	s.lines++
+	s.bytes += int64(len(line))
+	return nil
+}
+
+// trimLocked 追加 payload 后从最旧的记录开始丢弃，直到总大小不超过 maxBytes，再整体重写
+func (s *spool) trimLocked(payload []byte) error {
+	lines, err := s.readLines()
+	if err != nil {
+		return err
+	}
+	lines = append(lines, payload)
+	var total int64
+	for _, line := range lines {
+		total += int64(len(line)) + 1
+	}
+	dropped := 0
+	for total > s.maxBytes && len(lines) > 0 {
+		total -= int64(len(lines[0])) + 1
+		lines = lines[1:]
+		dropped++
+	}
+	if dropped > 0 {
+		s.dropped += uint64(dropped)
+		slog.Warn("spool full, dropped oldest reports", "component", "spool", "path", s.path, "dropped", dropped)
+	}
+	return s.writeLines(lines)
+}
+
+// flush 按顺序补发积压的记录：在锁内取快照，发送时不持锁，慢请求不会阻塞 append；
+// 结束后只从文件头部删除已送达的记录，补发期间追加的记录保留。遇到失败时返回错误
+func (s *spool) flush(send func([]byte) error) error {
+	s.flushing.Lock()
+	defer s.flushing.Unlock()
+	s.mu.Lock()
+	lines, err := s.readLines()
+	dropped := s.dropped
+	s.mu.Unlock()
+	if err != nil || len(lines) == 0 {
+		return err
+	}
+	delivered := 0
+	var sendErr error
+	for _, line := range lines {
+		if sendErr = send(line); sendErr != nil {
+			break
+		}
+		delivered++
+	}
+	if delivered > 0 {
+		if err := s.removeDelivered(delivered, dropped); err != nil {
+			return err
+		}
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+	slog.Info("spool flushed", "component", "spool", "path", s.path, "count", delivered)
+	return nil
+}
+
+// removeDelivered 删除文件头部已送达的 n 条记录；补发期间因空间不足已被丢弃的最旧记录不再重复删除
+func (s *spool) removeDelivered(n int, droppedBefore uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n -= int(s.dropped - droppedBefore)
+	if n <= 0 {
+		return nil
+	}
+	lines, err := s.readLines()
+	if err != nil {
+		return err
+	}
+	if n > len(lines) {
+		n = len(lines)
+	}
+	return s.writeLines(lines[n:])
+}
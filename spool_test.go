@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func spoolContents(t *testing.T, s *spool) []string {
+	t.Helper()
+	lines, err := s.readLines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		out = append(out, string(line))
+	}
+	return out
+}
+
+func TestSpoolOutageAndReplay(t *testing.T) {
+	s, err := newSpool(filepath.Join(t.TempDir(), "spool", "reports.jsonl"), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, body := range []string{`{"n":1}`, `{"n":2}`, `{"n":3}`} {
+		if err := s.append([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 采集端仍不可达：第一条送达后失败，已送达的记录被删除，其余保留
+	outage := errors.New("connection refused")
+	var sent []string
+	err = s.flush(func(body []byte) error {
+		if len(sent) == 1 {
+			return outage
+		}
+		sent = append(sent, string(body))
+		return nil
+	})
+	if !errors.Is(err, outage) {
+		t.Fatalf("flush error = %v, want %v", err, outage)
+	}
+	if got := spoolContents(t, s); len(got) != 2 || got[0] != `{"n":2}` || got[1] != `{"n":3}` {
+		t.Fatalf("spool after partial flush = %v, want [{\"n\":2} {\"n\":3}]", got)
+	}
+	if n := s.size(); n != 2 {
+		t.Errorf("size after partial flush = %d, want 2", n)
+	}
+	// 重新打开时从文件恢复积压条数
+	reopened, err := newSpool(s.path, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := reopened.size(); n != 2 {
+		t.Errorf("size after reopening = %d, want 2", n)
+	}
+
+	// 恢复后按顺序补发，补发期间新追加的记录保留到下一次
+	sent = nil
+	err = s.flush(func(body []byte) error {
+		if len(sent) == 0 {
+			if err := s.append([]byte(`{"n":4}`)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		sent = append(sent, string(body))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 2 || sent[0] != `{"n":2}` || sent[1] != `{"n":3}` {
+		t.Fatalf("replayed %v, want [{\"n\":2} {\"n\":3}]", sent)
+	}
+	if got := spoolContents(t, s); len(got) != 1 || got[0] != `{"n":4}` {
+		t.Fatalf("spool after recovery = %v, want [{\"n\":4}]", got)
+	}
+
+	// 全部送达后文件被删除
+	if err := s.flush(func([]byte) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(s.path); !os.IsNotExist(err) {
+		t.Errorf("spool file still present after a full flush: %v", err)
+	}
+	if n := s.size(); n != 0 {
+		t.Errorf("size after a full flush = %d, want 0", n)
+	}
+}
+
+func TestSpoolDropsOldestWhenFull(t *testing.T) {
+	// 每条记录加换行 8 字节，最多保留 2 条
+	s, err := newSpool(filepath.Join(t.TempDir(), "reports.jsonl"), 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, body := range []string{"report1", "report2", "report3"} {
+		if err := s.append([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := spoolContents(t, s); len(got) != 2 || got[0] != "report2" || got[1] != "report3" {
+		t.Fatalf("spool = %v, want [report2 report3]", got)
+	}
+
+	// 补发期间最旧的记录被挤掉，送达后不能误删尚未发送的记录
+	err = s.flush(func(body []byte) error {
+		if string(body) == "report2" {
+			if err := s.append([]byte("report4")); err != nil {
+				t.Fatal(err)
+			}
+			return nil
+		}
+		return errors.New("timeout")
+	})
+	if err == nil {
+		t.Fatal("flush succeeded, want the second send to fail")
+	}
+	if got := spoolContents(t, s); len(got) != 2 || got[0] != "report3" || got[1] != "report4" {
+		t.Fatalf("spool = %v, want [report3 report4]", got)
+	}
+	if n := s.size(); n != 2 {
+		t.Errorf("size = %d, want 2", n)
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/host"
+)
+
+// sshAuthLogs 是 -ssh-auth-log 未指定时依次尝试的日志，分别对应 Debian/Ubuntu 和 Oracle Linux/RHEL
+var sshAuthLogs = []string{"/var/log/auth.log", "/var/log/secure"}
+
+// sshWindow 是 failed_last_hour、top_sources 等统计的时间窗口
+const sshWindow = time.Hour
+
+// sshMaxRead 限制单次采集读取的日志量，日志暴涨时跳过中间部分，只统计最新的内容
+const sshMaxRead = 16 << 20
+
+var (
+	// Ubuntu 24.04 起认证由 sshd-session 进程记录
+	sshdLine = regexp.MustCompile(`sshd(?:-session)?\[(\d+)\]: (.*)$`)
+
+	sshFailed   = regexp.MustCompile(`^Failed \S+ for (?:invalid user )?(\S*) from (\S+) port`)
+	sshInvalid  = regexp.MustCompile(`^Invalid user (\S*) from (\S+)`)
+	sshAccepted = regexp.MustCompile(`^Accepted (\S+) for (\S+) from (\S+) port`)
+)
+
+type sshAttempt struct {
+	at time.Time
+	ip string
+}
+
+// sshMonitor 增量读取认证日志，从启动时的文件末尾开始，只统计 agent 运行期间的登录；
+// 同一个 sshd 进程（即同一个连接）的多次失败只计一次
+type sshMonitor struct {
+	mu       sync.Mutex
+	tail     fileTail
+	failed   []sshAttempt
+	accepted []sshAttempt
+	total    uint64
+	seenPIDs map[string]time.Time
+	last     map[string]interface{}
+}
+
+var sshLogins = &sshMonitor{seenPIDs: map[string]time.Time{}}
+
+func resolveAuthLog(path string) string {
+	if path != "" {
+		return path
+	}
+	for _, p := range sshAuthLogs {
+		if _, err := os.Stat(hostFile(p)); err == nil {
+			return hostFile(p)
+		}
+	}
+	return ""
+}
+
+// parse 统计新增的日志行；syslog 的时间戳不带年份，记录的时间取读到该行的时间
+func (m *sshMonitor) parse(data []byte, now time.Time) {
+	start := 0
+	for i, b := range data {
+		if b != '\n' {
+			continue
+		}
+		line := string(data[start:i])
+		start = i + 1
+		match := sshdLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		pid, msg := match[1], match[2]
+		if a := sshAccepted.FindStringSubmatch(msg); a != nil {
+			m.accepted = append(m.accepted, sshAttempt{now, a[3]})
+			m.last = map[string]interface{}{"user": a[2], "ip": a[3], "method": a[1], "at": now.Format(time.RFC3339)}
+			continue
+		}
+		var ip string
+		if f := sshFailed.FindStringSubmatch(msg); f != nil {
+			ip = f[2]
+		} else if f := sshInvalid.FindStringSubmatch(msg); f != nil {
+			ip = f[2]
+		} else {
+			continue
+		}
+		if _, seen := m.seenPIDs[pid]; seen {
+			continue
+		}
+		m.seenPIDs[pid] = now
+		m.failed = append(m.failed, sshAttempt{now, ip})
+		m.total++
+	}
+}
+
+// prune 丢弃窗口外的记录；sshd 的 pid 会被复用，超出窗口的 pid 也一并忘掉
+func (m *sshMonitor) prune(now time.Time) {
+	cutoff := now.Add(-sshWindow)
+	keep := func(list []sshAttempt) []sshAttempt {
+		i := sort.Search(len(list), func(i int) bool { return list[i].at.After(cutoff) })
+		return append(list[:0], list[i:]...)
+	}
+	m.failed = keep(m.failed)
+	m.accepted = keep(m.accepted)
+	for pid, at := range m.seenPIDs {
+		if !at.After(cutoff) {
+			delete(m.seenPIDs, pid)
+		}
+	}
+}
+
+func topSources(attempts []sshAttempt, n int) []interface{} {
+	counts := map[string]int{}
+	for _, a := range attempts {
+		counts[a.ip]++
+	}
+	ips := make([]string, 0, len(counts))
+	for ip := range counts {
+		ips = append(ips, ip)
+	}
+	sort.Slice(ips, func(i, j int) bool {
+		if counts[ips[i]] != counts[ips[j]] {
+			return counts[ips[i]] > counts[ips[j]]
+		}
+		return ips[i] < ips[j]
+	})
+	if len(ips) > n {
+		ips = ips[:n]
+	}
+	top := make([]interface{}, 0, len(ips))
+	for _, ip := range ips {
+		top = append(top, map[string]interface{}{"ip": ip, "count": counts[ip]})
+	}
+	return top
+}
+
+// activeSessions 读取 utmp 中的登录会话，包括 SSH 和本地终端
+func activeSessions() []interface{} {
+	users, err := host.Users()
+	if err != nil {
+		return nil
+	}
+	sessions := make([]interface{}, 0, len(users))
+	for _, u := range users {
+		s := map[string]interface{}{"user": u.User, "terminal": u.Terminal}
+		if u.Host != "" {
+			s["from"] = u.Host
+		}
+		if u.Started > 0 {
+			s["started_at"] = time.Unix(int64(u.Started), 0).Format(time.RFC3339)
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions
+}
+
+// getSSH 返回登录失败统计、最近一次成功登录和当前会话；读取认证日志通常需要 root 或 adm 组
+func getSSH() map[string]interface{} {
+	m := sshLogins
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.tail.path == "" {
+		m.tail = fileTail{path: resolveAuthLog(cfg.SSHAuthLog), maxRead: sshMaxRead}
+	}
+	section := map[string]interface{}{}
+	if sessions := activeSessions(); sessions != nil {
+		section["sessions"] = sessions
+		section["active_sessions"] = len(sessions)
+	}
+	if m.tail.path != "" {
+		now := time.Now()
+		data, err := m.tail.read()
+		if err == nil {
+			m.parse(data, now)
+			m.prune(now)
+			section["failed_last_hour"] = len(m.failed)
+			section["failed_total"] = m.total
+			section["accepted_last_hour"] = len(m.accepted)
+			section["top_sources"] = topSources(m.failed, 5)
+			if m.last != nil {
+				section["last_accepted"] = m.last
+			}
+		} else {
+			section["error"] = err.Error()
+		}
+	}
+	if len(section) == 0 {
+		return nil
+	}
+	return section
+}
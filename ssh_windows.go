@@ -0,0 +1,8 @@
+package main
+
+import "os"
+
+// fileInode 在 Windows 上不可用，日志轮转只能靠文件变短来识别
+func fileInode(st os.FileInfo) uint64 {
+	return 0
+}
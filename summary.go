@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+var summaryFieldNames = []string{"cpu", "mem", "swap", "disk", "net", "load", "procs"}
+
+func validateSummaryFields(fields []string) error {
+	for _, f := range fields {
+		known := false
+		for _, name := range summaryFieldNames {
+			if f == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown summary field %q, expected one of %s", f, strings.Join(summaryFieldNames, ","))
+		}
+	}
+	return nil
+}
+
+func sectionValue(info map[string]interface{}, section, key string) interface{} {
+	if m, ok := info[section].(map[string]interface{}); ok {
+		return m[key]
+	}
+	return nil
+}
+
+func summaryPercent(v interface{}) string {
+	if n, ok := toFloat(v); ok {
+		return fmt.Sprintf("%3.0f%%", n)
+	}
+	return "   -"
+}
+
+// formatSummary 生成一行定宽的摘要，便于 tail -f 查看
+func formatSummary(info map[string]interface{}, fields []string) string {
+	parts := make([]string, 0, len(fields))
+	for _, f := range fields {
+		switch f {
+		case "cpu":
+			parts = append(parts, "CPU "+summaryPercent(sectionValue(info, "cpu", "percent")))
+		case "mem":
+			parts = append(parts, "MEM "+summaryPercent(sectionValue(info, "memory", "percent")))
+		case "swap":
+			parts = append(parts, "SWAP "+summaryPercent(sectionValue(info, "swap", "percent")))
+		case "disk":
+			parts = append(parts, "DISK "+summaryPercent(sectionValue(info, "disk", "percent")))
+		case "net":
+			up, _ := sectionValue(info, "network", "upload_speed").(string)
+			down, _ := sectionValue(info, "network", "download_speed").(string)
+			parts = append(parts, fmt.Sprintf("NET ↑%-8s ↓%-8s", up, down))
+		case "load":
+			if l, ok := info["load_average"].(map[string]float64); ok {
+				parts = append(parts, fmt.Sprintf("LOAD %5.2f", l["1min"]))
+			} else {
+				parts = append(parts, "LOAD     -")
+			}
+		case "procs":
+			parts = append(parts, fmt.Sprintf("PROCS %5v", info["process_count"]))
+		}
+	}
+	return strings.Join(parts, " ")
+}
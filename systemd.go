@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// unitRestartBackoff 是同一个单元两次自动重启之间的最短间隔，避免反复崩溃的服务被不停拉起
+const unitRestartBackoff = 5 * time.Minute
+
+// unitProperties 是 systemctl show 读取的属性
+const unitProperties = "Id,LoadState,ActiveState,SubState,NRestarts,MainPID,ActiveEnterTimestamp,Result"
+
+// parseSystemctlShow 解析 systemctl show 的输出，多个单元之间以空行分隔，顺序与参数一致
+func parseSystemctlShow(out string) []map[string]string {
+	var units []map[string]string
+	current := map[string]string{}
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if len(current) > 0 {
+				units = append(units, current)
+				current = map[string]string{}
+			}
+			continue
+		}
+		if k, v, ok := strings.Cut(line, "="); ok {
+			current[k] = v
+		}
+	}
+	if len(current) > 0 {
+		units = append(units, current)
+	}
+	return units
+}
+
+// unitWatcher 记录各单元上次的 active_state，状态变化时发出 unit_state_changed 事件
+type unitWatcher struct {
+	mu          sync.Mutex
+	states      map[string]string
+	lastRestart map[string]time.Time
+	autoRestart map[string]int
+}
+
+var units = &unitWatcher{states: map[string]string{}, lastRestart: map[string]time.Time{}, autoRestart: map[string]int{}}
+
+func unitEvent(rule, severity, unit, from, to string, now time.Time) {
+	hostname := reportedHostname()
+	dispatchAlert(alertEvent{
+		Rule:      rule,
+		Severity:  severity,
+		Status:    "changed",
+		Metric:    "units." + unit + ".active_state",
+		From:      from,
+		To:        to,
+		Hostname:  hostname,
+		AgentID:   agentID(),
+		StartedAt: now.Format(time.RFC3339),
+		At:        now.Format(time.RFC3339),
+	})
+}
+
+// restart 重启失败的单元，重启本身在后台执行，结果体现在下一次采集的状态中
+func (w *unitWatcher) restart(name string, now time.Time) {
+	if last, ok := w.lastRestart[name]; ok && now.Sub(last) < unitRestartBackoff {
+		return
+	}
+	w.lastRestart[name] = now
+	w.autoRestart[name]++
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, "systemctl", "restart", "--", name).CombinedOutput()
+		if err != nil {
+			slog.Warn("unit restart failed", "component", "units", "unit", name, "err", err, "output", strings.TrimSpace(string(out)))
+			return
+		}
+		slog.Info("unit restarted", "component", "units", "unit", name)
+	}()
+	unitEvent("unit_restarted", severityWarning, name, "failed", "restarting", now)
+}
+
+// getUnits 读取 -unit 指定的 systemd 单元状态；restarts 为 systemd 自身按 Restart= 重启的次数，
+// auto_restarts 为 -unit-restart 重启的次数
+func getUnits() map[string]interface{} {
+	if len(cfg.Units) == 0 {
+		return nil
+	}
+	if _, err := exec.LookPath("systemctl"); err != nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	args := append([]string{"show", "--property=" + unitProperties, "--"}, cfg.Units...)
+	out, err := exec.CommandContext(ctx, "systemctl", args...).Output()
+	if err != nil {
+		slog.Debug("systemctl show failed", "component", "units", "err", err)
+		return nil
+	}
+	props := parseSystemctlShow(string(out))
+	if len(props) != len(cfg.Units) {
+		return nil
+	}
+
+	now := time.Now()
+	w := units
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	section := make(map[string]interface{}, len(cfg.Units))
+	for i, name := range cfg.Units {
+		p := props[i]
+		state := p["ActiveState"]
+		u := map[string]interface{}{
+			"load_state":   p["LoadState"],
+			"active_state": state,
+			"sub_state":    p["SubState"],
+			"active":       state == "active",
+			"failed":       state == "failed",
+		}
+		if p["Result"] != "" && p["Result"] != "success" {
+			u["result"] = p["Result"]
+		}
+		if n, err := strconv.ParseUint(p["NRestarts"], 10, 64); err == nil {
+			u["restarts"] = n
+		}
+		if pid, err := strconv.Atoi(p["MainPID"]); err == nil && pid > 0 {
+			u["main_pid"] = pid
+		}
+		if since := p["ActiveEnterTimestamp"]; since != "" && state == "active" {
+			u["active_since"] = since
+		}
+		if n := w.autoRestart[name]; n > 0 {
+			u["auto_restarts"] = n
+		}
+		section[name] = u
+
+		// 首次采集只记录状态，不认为是变化
+		if previous, seen := w.states[name]; seen && previous != state {
+			severity := severityInfo
+			if state == "failed" {
+				severity = severityCritical
+			}
+			unitEvent("unit_state_changed", severity, name, previous, state, now)
+		}
+		w.states[name] = state
+		if state == "failed" && cfg.UnitRestart {
+			w.restart(name, now)
+		}
+	}
+	return section
+}
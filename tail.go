@@ -0,0 +1,64 @@
+package main
+
+import (
+	"io"
+	"os"
+)
+
+// fileTail 增量读取一个不断追加的日志文件，从第一次读取时的文件末尾开始，只处理 agent 运行期间新写入的行
+type fileTail struct {
+	path    string
+	maxRead int64 // 单次最多读取的字节数，日志暴涨时跳过中间部分，只处理最新的内容
+	offset  int64
+	inode   uint64
+	started bool
+}
+
+// read 读取上次位置之后新增的日志；文件被轮转（inode 变化或变短）时从新文件开头读取
+func (t *fileTail) read() ([]byte, error) {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	inode := fileInode(st)
+	size := st.Size()
+	if !t.started {
+		t.started, t.offset, t.inode = true, size, inode
+		return nil, nil
+	}
+	if inode != t.inode || size < t.offset {
+		t.offset, t.inode = 0, inode
+	}
+	if size-t.offset > t.maxRead {
+		t.offset = size - t.maxRead
+	}
+	if _, err := f.Seek(t.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(f, size-t.offset))
+	if err != nil {
+		return nil, err
+	}
+	// 最后一行可能还没写完，留到下次
+	if i := lastNewline(data); i >= 0 {
+		data = data[:i+1]
+	} else {
+		data = nil
+	}
+	t.offset += int64(len(data))
+	return data, nil
+}
+
+func lastNewline(data []byte) int {
+	for i := len(data) - 1; i >= 0; i-- {
+		if data[i] == '\n' {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 控制端下发的任务类型
+const (
+	taskReboot         = "reboot"
+	taskRunScript      = "run_script"
+	taskRestartService = "restart_service"
+	taskSpeedtest      = "speedtest"
+)
+
+// serviceNamePattern 限制 restart_service 的目标，systemd 单元名和 Windows 服务名都在这个范围内
+var serviceNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.@-]+$`)
+
+// taskOutputLimit 限制回传的 stdout/stderr 大小，避免脚本输出过多撑爆上报
+const taskOutputLimit = 64 << 10
+
+type task struct {
+	ID     string   `json:"id"`
+	Type   string   `json:"type"`
+	Target string   `json:"target"` // run_script 为脚本路径，restart_service 为服务名，speedtest 为测速地址（可选）
+	Args   []string `json:"args"`
+}
+
+type taskResult struct {
+	ID         string `json:"id"`
+	AgentID    string `json:"agent_id"`
+	Type       string `json:"type"`
+	ExitCode   int    `json:"exit_code"`
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	Error      string `json:"error,omitempty"`
+	StartedAt  string `json:"started_at"`
+	FinishedAt string `json:"finished_at"`
+
+	Result map[string]interface{} `json:"result,omitempty"` // 不执行命令的任务（如 speedtest）的结构化结果
+}
+
+// taskAllowlist 为任务类型 -> 允许的目标，"*" 表示该类型的任意目标，未列出的类型一律拒绝
+var taskAllowlist = map[string][]string{}
+
+// compileTaskAllowlist 解析 -task-allow 的 type[=target] 形式
+func compileTaskAllowlist(specs []string) (map[string][]string, error) {
+	allow := make(map[string][]string)
+	for _, spec := range specs {
+		typ, target := spec, "*"
+		if eq := strings.Index(spec, "="); eq >= 0 {
+			typ, target = strings.TrimSpace(spec[:eq]), strings.TrimSpace(spec[eq+1:])
+		}
+		switch typ {
+		case taskReboot, taskRunScript, taskRestartService, taskSpeedtest:
+		default:
+			return nil, fmt.Errorf("unknown task type %q", typ)
+		}
+		if target == "" {
+			return nil, fmt.Errorf("empty target in %q", spec)
+		}
+		allow[typ] = append(allow[typ], target)
+	}
+	return allow, nil
+}
+
+func taskAllowed(t task) bool {
+	for _, target := range taskAllowlist[t.Type] {
+		if target == "*" || target == t.Target {
+			return true
+		}
+	}
+	return false
+}
+
+// taskCommand 把任务翻译成要执行的命令
+func taskCommand(ctx context.Context, t task) (*exec.Cmd, error) {
+	switch t.Type {
+	case taskReboot:
+		// 延迟一分钟重启，保证结果能先回传给控制端
+		if runtime.GOOS == "windows" {
+			return exec.CommandContext(ctx, "shutdown", "/r", "/t", "60"), nil
+		}
+		return exec.CommandContext(ctx, "shutdown", "-r", "+1"), nil
+	case taskRestartService:
+		if t.Target == "" {
+			return nil, fmt.Errorf("restart_service requires a target")
+		}
+		if !serviceNamePattern.MatchString(t.Target) {
+			return nil, fmt.Errorf("invalid service name %q", t.Target)
+		}
+		if runtime.GOOS == "windows" {
+			// -Command 会把参数拼成脚本执行，服务名通过环境变量作为数据传入，不进入脚本文本
+			cmd := exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", "Restart-Service -Name $env:OCI_AGENT_SERVICE")
+			cmd.Env = append(os.Environ(), "OCI_AGENT_SERVICE="+t.Target)
+			return cmd, nil
+		}
+		return exec.CommandContext(ctx, "systemctl", "restart", "--", t.Target), nil
+	case taskRunScript:
+		if t.Target == "" {
+			return nil, fmt.Errorf("run_script requires a target")
+		}
+		return exec.CommandContext(ctx, t.Target, t.Args...), nil
+	}
+	return nil, fmt.Errorf("unknown task type %q", t.Type)
+}
+
+// limitedBuffer 超出上限后丢弃后续输出
+type limitedBuffer struct {
+	bytes.Buffer
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := taskOutputLimit - b.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return b.Buffer.String() + "\n[output truncated]"
+	}
+	return b.Buffer.String()
+}
+
+func runTask(t task, timeout time.Duration) (result taskResult) {
+	result = taskResult{ID: t.ID, AgentID: agentID(), Type: t.Type, ExitCode: -1, StartedAt: time.Now().Format(time.RFC3339)}
+	defer func() { result.FinishedAt = time.Now().Format(time.RFC3339) }()
+
+	if !taskAllowed(t) {
+		result.Error = fmt.Sprintf("task %s with target %q is not allowed by -task-allow", t.Type, t.Target)
+		return result
+	}
+	if t.Type == taskSpeedtest {
+		res, err := speedtests.run(t.Target)
+		result.Result = res
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.ExitCode = 0
+		}
+		return result
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd, err := taskCommand(ctx, t)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	var stdout, stderr limitedBuffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err = cmd.Run()
+	result.Stdout, result.Stderr = stdout.String(), stderr.String()
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		result.Error = fmt.Sprintf("timed out after %s", timeout)
+	} else if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// taskRunner 执行控制端下发的任务并去重，任务可以来自 -task-url 轮询，也可以来自 WebSocket 的 task 通道
+type taskRunner struct {
+	url     string // 为空时只处理 WebSocket 推送的任务
+	timeout time.Duration
+
+	mu   sync.Mutex
+	seen map[string]time.Time // 已执行的任务 ID，防止控制端重复下发时重复执行
+}
+
+func newTaskRunner(url string, timeout time.Duration) *taskRunner {
+	return &taskRunner{url: url, timeout: timeout, seen: make(map[string]time.Time)}
+}
+
+// taskExec 由轮询和 WebSocket 共用，保证同一任务只执行一次，parseFlags 按配置替换
+var taskExec = newTaskRunner("", 5*time.Minute)
+
+func (r *taskRunner) fetch() ([]task, error) {
+	req, err := http.NewRequest(http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	setAuthHeaders(req.Header)
+	if err := signRequest(req.Header, nil); err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, &statusError{code: resp.StatusCode}
+	}
+	var body struct {
+		Tasks []task `json:"tasks"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode tasks: %w", err)
+	}
+	return body.Tasks, nil
+}
+
+// claim 记录任务 ID，返回 false 表示已经执行过；一天前的记录会被清理
+func (r *taskRunner) claim(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for seenID, at := range r.seen {
+		if now.Sub(at) > 24*time.Hour {
+			delete(r.seen, seenID)
+		}
+	}
+	if _, ok := r.seen[id]; ok {
+		return false
+	}
+	r.seen[id] = now
+	return true
+}
+
+// handle 执行任务并通过 deliver 回传结果，dest 只用于日志
+func (r *taskRunner) handle(t task, dest string, deliver func([]byte) error) {
+	if t.ID == "" || !r.claim(t.ID) {
+		return
+	}
+	slog.Info("running task", "component", "tasks", "id", t.ID, "type", t.Type, "target", t.Target)
+	result := runTask(t, r.timeout)
+	if result.Error != "" {
+		slog.Warn("task failed", "component", "tasks", "id", t.ID, "type", t.Type, "exit_code", result.ExitCode, "err", result.Error)
+	}
+	body, err := json.Marshal(result)
+	if err != nil {
+		slog.Error("marshal task result failed", "component", "tasks", "id", t.ID, "err", err)
+		return
+	}
+	if err := deliver(body); err != nil {
+		logReportError("tasks", dest, err)
+	}
+}
+
+func (r *taskRunner) poll() {
+	tasks, err := r.fetch()
+	if err != nil {
+		logReportError("tasks", r.url, err)
+		return
+	}
+	post := func(body []byte) error { return postWithRetry(body, r.url) }
+	for _, t := range tasks {
+		go r.handle(t, r.url, post)
+	}
+}
+
+func (r *taskRunner) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		r.poll()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
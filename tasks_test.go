@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRestartServiceTarget(t *testing.T) {
+	tests := []struct {
+		target string
+		ok     bool
+	}{
+		{"nginx", true},
+		{"nginx.service", true},
+		{"getty@tty1.service", true},
+		{"W32Time", true},
+		{"x; Remove-Item -Recurse C:\\", false},
+		{"$(reboot)", false},
+		{"a b", false},
+		{"'svc'", false},
+	}
+	for _, tt := range tests {
+		cmd, err := taskCommand(context.Background(), task{Type: taskRestartService, Target: tt.target})
+		if tt.ok != (err == nil) {
+			t.Errorf("taskCommand(%q) error = %v, want ok=%v", tt.target, err, tt.ok)
+			continue
+		}
+		// Linux 作为单独的参数传入，Windows 通过环境变量传入
+		if err == nil && !strings.Contains(strings.Join(append(cmd.Args, cmd.Env...), "\x00"), tt.target) {
+			t.Errorf("taskCommand(%q) does not pass the service name: %v", tt.target, cmd.Args)
+		}
+	}
+}
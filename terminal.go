@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// terminal 通道在 WebSocket 上双向传递网页终端的消息，data 为 terminalMessage：
+// 控制端发送 open（带 user、cols、rows）、input、resize、close，agent 回送 opened、output、exit、error；
+// input 和 output 的 data 为 base64 编码的原始字节。open 必须带用 -signing-secret 计算的签名，见 terminalOpenPayload
+const wsChannelTerminal = "terminal"
+
+const (
+	terminalMaxSessions = 4
+	terminalChunk       = 16 << 10
+)
+
+type terminalMessage struct {
+	Session  string `json:"session"`
+	Type     string `json:"type"`
+	User     string `json:"user,omitempty"` // 控制端登录的用户，用于 -terminal-allow 和审计
+	Cols     uint16 `json:"cols,omitempty"`
+	Rows     uint16 `json:"rows,omitempty"`
+	Data     string `json:"data,omitempty"`
+	ExitCode *int   `json:"exit_code,omitempty"`
+	Error    string `json:"error,omitempty"`
+
+	// open 的签名，与 HTTP 请求签名使用相同的 -signing-secret 和算法
+	Timestamp string `json:"timestamp,omitempty"`
+	Nonce     string `json:"nonce,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
+type terminalSession struct {
+	id, user string
+	pty      *os.File
+	cmd      *exec.Cmd
+	started  time.Time
+	idle     *time.Timer
+
+	bytesIn, bytesOut atomic.Uint64
+}
+
+// terminalManager 管理所有网页终端会话，连接断开或 agent 退出时关闭全部会话
+type terminalManager struct {
+	mu       sync.Mutex
+	sessions map[string]*terminalSession
+}
+
+var terminals = &terminalManager{sessions: map[string]*terminalSession{}}
+
+var terminalNonces = &nonceCache{}
+
+// terminalOpenPayload 是 open 的签名内容：signature = hex(HMAC-SHA256(secret, timestamp + "\n" + nonce + "\n" + payload))，
+// payload 绑定 agent_id、会话和用户，签名不能挪用到其他 agent，也不能冒用其他用户
+func terminalOpenPayload(msg terminalMessage) []byte {
+	return []byte("terminal-open\n" + agentID() + "\n" + msg.Session + "\n" + msg.User)
+}
+
+// terminalAllowed 先校验 open 的签名，再检查签名中的用户是否在 -terminal-allow 中，未配置时网页终端关闭
+func terminalAllowed(msg terminalMessage) error {
+	if cfg.SigningSecret == "" {
+		return fmt.Errorf("web terminal requires -signing-secret")
+	}
+	if err := verifySignatureFields(cfg.SigningSecret, msg.Timestamp, msg.Nonce, msg.Signature, terminalOpenPayload(msg), terminalNonces); err != nil {
+		return fmt.Errorf("invalid open signature: %w", err)
+	}
+	for _, u := range cfg.TerminalAllow {
+		if (u == "*" || u == msg.User) && msg.User != "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("user %q is not allowed by -terminal-allow", msg.User)
+}
+
+// terminalShell 依次使用 -terminal-shell、$SHELL、/bin/bash 和 /bin/sh
+func terminalShell() string {
+	if cfg.TerminalShell != "" {
+		return cfg.TerminalShell
+	}
+	if sh := os.Getenv("SHELL"); sh != "" {
+		return sh
+	}
+	if _, err := os.Stat("/bin/bash"); err == nil {
+		return "/bin/bash"
+	}
+	return "/bin/sh"
+}
+
+// auditTerminal 把会话的开始和结束写入日志，配置了 -terminal-audit-log 时另外追加一行 JSON
+func auditTerminal(event string, s *terminalSession, fields map[string]interface{}) {
+	attrs := []any{"component", "terminal", "session", s.id, "user", s.user}
+	for k, v := range fields {
+		attrs = append(attrs, k, v)
+	}
+	slog.Info("terminal "+event, attrs...)
+	if cfg.TerminalAuditLog == "" {
+		return
+	}
+	record := map[string]interface{}{
+		"time":     time.Now().Format(time.RFC3339),
+		"event":    event,
+		"session":  s.id,
+		"user":     s.user,
+		"agent_id": agentID(),
+	}
+	for k, v := range fields {
+		record[k] = v
+	}
+	line, _ := json.Marshal(record)
+	f, err := os.OpenFile(cfg.TerminalAuditLog, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		slog.Error("write terminal audit log failed", "component", "terminal", "err", err)
+		return
+	}
+	defer f.Close()
+	f.Write(append(line, '\n'))
+}
+
+// handle 处理控制端的一条 terminal 消息，send 把回复发回同一条连接
+func (m *terminalManager) handle(msg terminalMessage, send func([]byte) error) {
+	reply := func(r terminalMessage) {
+		r.Session = msg.Session
+		body, _ := json.Marshal(r)
+		if err := send(body); err != nil {
+			slog.Debug("send terminal message failed", "component", "terminal", "session", msg.Session, "err", err)
+		}
+	}
+	if msg.Session == "" {
+		return
+	}
+	if msg.Type == "open" {
+		if err := m.open(msg, reply); err != nil {
+			slog.Warn("terminal rejected", "component", "terminal", "session", msg.Session, "user", msg.User, "err", err)
+			reply(terminalMessage{Type: "error", Error: err.Error()})
+		}
+		return
+	}
+	m.mu.Lock()
+	s := m.sessions[msg.Session]
+	m.mu.Unlock()
+	if s == nil {
+		reply(terminalMessage{Type: "error", Error: "no such session"})
+		return
+	}
+	switch msg.Type {
+	case "input":
+		data, err := base64.StdEncoding.DecodeString(msg.Data)
+		if err != nil {
+			reply(terminalMessage{Type: "error", Error: "input must be base64"})
+			return
+		}
+		s.idle.Reset(cfg.TerminalIdleTimeout)
+		s.bytesIn.Add(uint64(len(data)))
+		s.pty.Write(data)
+	case "resize":
+		resizePTY(s.pty, msg.Cols, msg.Rows)
+	case "close":
+		s.kill()
+	}
+}
+
+func (m *terminalManager) open(msg terminalMessage, reply func(terminalMessage)) error {
+	if err := terminalAllowed(msg); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sessions[msg.Session]; ok {
+		return fmt.Errorf("session %s already exists", msg.Session)
+	}
+	if len(m.sessions) >= terminalMaxSessions {
+		return fmt.Errorf("too many terminal sessions, at most %d", terminalMaxSessions)
+	}
+	shell := terminalShell()
+	cmd := exec.Command(shell, "-l")
+	cmd.Env = append(os.Environ(), "TERM=xterm-256color")
+	if home, err := os.UserHomeDir(); err == nil {
+		cmd.Dir = home
+	}
+	pty, err := startPTY(cmd, msg.Cols, msg.Rows)
+	if err != nil {
+		return err
+	}
+	s := &terminalSession{id: msg.Session, user: msg.User, pty: pty, cmd: cmd, started: time.Now()}
+	s.idle = time.AfterFunc(cfg.TerminalIdleTimeout, func() {
+		slog.Info("terminal idle, closing", "component", "terminal", "session", s.id, "user", s.user, "idle", cfg.TerminalIdleTimeout)
+		s.kill()
+	})
+	m.sessions[s.id] = s
+	auditTerminal("opened", s, map[string]interface{}{"shell": shell, "pid": cmd.Process.Pid})
+	reply(terminalMessage{Type: "opened"})
+	go m.pump(s, reply)
+	return nil
+}
+
+// pump 把 shell 的输出转发给控制端，shell 退出后回送退出码并记录审计
+func (m *terminalManager) pump(s *terminalSession, reply func(terminalMessage)) {
+	buf := make([]byte, terminalChunk)
+	for {
+		n, err := s.pty.Read(buf)
+		if n > 0 {
+			s.bytesOut.Add(uint64(n))
+			reply(terminalMessage{Type: "output", Data: base64.StdEncoding.EncodeToString(buf[:n])})
+		}
+		// shell 退出后读主端返回 EIO，会话被关闭时返回 ErrClosed
+		if err != nil {
+			break
+		}
+	}
+	s.kill()
+	// 忽略 SIGHUP 的 shell 在宽限期后强制结束
+	force := time.AfterFunc(5*time.Second, func() { s.cmd.Process.Kill() })
+	s.cmd.Wait()
+	force.Stop()
+	s.idle.Stop()
+	m.mu.Lock()
+	delete(m.sessions, s.id)
+	m.mu.Unlock()
+	code := s.cmd.ProcessState.ExitCode()
+	reply(terminalMessage{Type: "exit", ExitCode: &code})
+	auditTerminal("closed", s, map[string]interface{}{
+		"exit_code":        code,
+		"duration_seconds": int64(time.Since(s.started).Seconds()),
+		"bytes_in":         s.bytesIn.Load(),
+		"bytes_out":        s.bytesOut.Load(),
+	})
+}
+
+// kill 向 shell 的进程组发送 SIGHUP（与关闭终端窗口相同），并关闭主端让 pump 退出
+func (s *terminalSession) kill() {
+	killTerminal(s.cmd)
+	s.pty.Close()
+}
+
+// closeAll 在 WebSocket 断开或退出时关闭所有会话，不再有人能看到这些终端
+func (m *terminalManager) closeAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.sessions {
+		s.kill()
+	}
+}
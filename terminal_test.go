@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// signTerminalOpen 按控制端的做法为 open 签名
+func signTerminalOpen(msg terminalMessage, secret, nonce string) terminalMessage {
+	msg.Type = "open"
+	msg.Timestamp = strconv.FormatInt(time.Now().Unix(), 10)
+	msg.Nonce = nonce
+	msg.Signature = computeSignature(secret, msg.Timestamp, msg.Nonce, terminalOpenPayload(msg))
+	return msg
+}
+
+func TestTerminalAllowed(t *testing.T) {
+	restoreConfig(t)
+	instanceID = "agent-1"
+	cfg.SigningSecret = "s3cret"
+	cfg.TerminalAllow = []string{"alice"}
+
+	signed := signTerminalOpen(terminalMessage{Session: "t1", User: "alice"}, cfg.SigningSecret, "nonce-1")
+	tampered := signed
+	tampered.User, tampered.Nonce = "bob", "nonce-2"
+	other := signTerminalOpen(terminalMessage{Session: "t2", User: "bob"}, cfg.SigningSecret, "nonce-3")
+	wrongKey := signTerminalOpen(terminalMessage{Session: "t3", User: "alice"}, "other", "nonce-4")
+
+	tests := []struct {
+		name    string
+		msg     terminalMessage
+		wantErr string
+	}{
+		{"signed allowed user", signed, ""},
+		{"replayed nonce", signed, "already used"},
+		{"user changed after signing", tampered, "signature mismatch"},
+		{"signed but not allowed", other, "not allowed"},
+		{"signed with another key", wrongKey, "signature mismatch"},
+		{"unsigned", terminalMessage{Type: "open", Session: "t4", User: "alice"}, "missing signature"},
+	}
+	for _, tt := range tests {
+		err := terminalAllowed(tt.msg)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: error = %v, want it to contain %q", tt.name, err, tt.wantErr)
+		}
+	}
+
+	// 同一个签名不能用于另一个 agent
+	instanceID = "agent-2"
+	if err := terminalAllowed(signTerminalOpen(terminalMessage{Session: "t5", User: "alice"}, cfg.SigningSecret, "nonce-5")); err != nil {
+		t.Fatalf("signed for agent-2: %v", err)
+	}
+	moved := signed
+	moved.Nonce = "nonce-6"
+	moved.Signature = computeSignature(cfg.SigningSecret, moved.Timestamp, moved.Nonce, []byte("terminal-open\nagent-1\nt1\nalice"))
+	if err := terminalAllowed(moved); err == nil {
+		t.Error("accepted an open message signed for another agent")
+	}
+}
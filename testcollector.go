@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// serveTestCollector 启动一个最小的采集端，接收并校验 agent 上报的数据，用于联调
+var testNonces = &nonceCache{}
+
+func serveTestCollector(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if !testCollectorAuthorized(w, r) {
+			return
+		}
+		if websocket.IsWebSocketUpgrade(r) {
+			serveTestWebSocket(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		raw, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if cfg.SigningSecret != "" {
+			if err := verifySignature(cfg.SigningSecret, r.Header, raw, testNonces); err != nil {
+				fmt.Printf("[%s] %s %s: rejected, %v\n", time.Now().Format("2006-01-02 15:04:05"), r.RemoteAddr, r.URL.Path, err)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+		}
+		encoding := r.Header.Get("Content-Encoding")
+		if scheme := r.Header.Get(encryptionHeader); scheme != "" || payloadCipher != nil {
+			if scheme != encryptionScheme || payloadCipher == nil {
+				fmt.Printf("[%s] %s %s: rejected, body encryption %q does not match -encryption-key\n", time.Now().Format("2006-01-02 15:04:05"), r.RemoteAddr, r.URL.Path, scheme)
+				http.Error(w, "unexpected body encryption", http.StatusBadRequest)
+				return
+			}
+			if raw, err = decryptPayload(payloadCipher, raw); err != nil {
+				fmt.Printf("[%s] %s %s: rejected, %v\n", time.Now().Format("2006-01-02 15:04:05"), r.RemoteAddr, r.URL.Path, err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			encoding = r.Header.Get(payloadEncodingHeader)
+		}
+		var reader io.Reader = bytes.NewReader(raw)
+		if encoding == "gzip" {
+			zr, err := gzip.NewReader(reader)
+			if err != nil {
+				http.Error(w, "invalid gzip body: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			defer zr.Close()
+			reader = zr
+		}
+		body, err := ioutil.ReadAll(reader)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var data map[string]interface{}
+		if err := json.Unmarshal(body, &data); err != nil {
+			fmt.Printf("[%s] %s %s: invalid JSON: %v\n", time.Now().Format("2006-01-02 15:04:05"), r.RemoteAddr, r.URL.Path, err)
+			http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := printTestPayload(r, data, len(body)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	slog.Info("test collector listening", "component", "test-collector", "addr", addr)
+	return http.ListenAndServe(addr, mux)
+}
+
+func testCollectorAuthorized(w http.ResponseWriter, r *http.Request) bool {
+	if cfg.AuthToken == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+cfg.AuthToken)) == 1 {
+		return true
+	}
+	fmt.Printf("[%s] %s %s: rejected, missing or wrong bearer token\n", time.Now().Format("2006-01-02 15:04:05"), r.RemoteAddr, r.URL.Path)
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+	return false
+}
+
+// printTestPayload 校验并打印一条收到的数据，HTTP 和 WebSocket 共用
+func printTestPayload(r *http.Request, data map[string]interface{}, size int) error {
+	kind, err := validatePayload(data)
+	if err != nil {
+		fmt.Printf("[%s] %s %s: invalid %s: %v\n", time.Now().Format("2006-01-02 15:04:05"), r.RemoteAddr, r.URL.Path, kind, err)
+		return err
+	}
+	pretty, _ := json.MarshalIndent(data, "", "  ")
+	fmt.Printf("[%s] %s %s: valid %s from agent %q (%d bytes)\n%s\n", time.Now().Format("2006-01-02 15:04:05"), r.RemoteAddr, r.URL.Path, kind, r.Header.Get("X-Agent-Id"), size, pretty)
+	return nil
+}
+
+func serveTestWebSocket(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	fmt.Printf("[%s] %s %s: websocket connected\n", time.Now().Format("2006-01-02 15:04:05"), r.RemoteAddr, r.URL.Path)
+	for {
+		_, frame, err := conn.ReadMessage()
+		if err != nil {
+			fmt.Printf("[%s] %s %s: websocket closed: %v\n", time.Now().Format("2006-01-02 15:04:05"), r.RemoteAddr, r.URL.Path, err)
+			return
+		}
+		var envelope struct {
+			Channel string                 `json:"channel"`
+			Data    map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(frame, &envelope); err != nil || envelope.Data == nil {
+			fmt.Printf("[%s] %s %s: invalid frame, expected {\"channel\":...,\"data\":{...}}: %v\n", time.Now().Format("2006-01-02 15:04:05"), r.RemoteAddr, r.URL.Path, err)
+			continue
+		}
+		if envelope.Channel == wsChannelTerminal {
+			fmt.Printf("[%s] %s %s: terminal %v\n", time.Now().Format("2006-01-02 15:04:05"), r.RemoteAddr, r.URL.Path, envelope.Data["type"])
+			continue
+		}
+		if envelope.Channel == wsChannelTaskResult {
+			pretty, _ := json.MarshalIndent(envelope.Data, "", "  ")
+			fmt.Printf("[%s] %s %s: task result\n%s\n", time.Now().Format("2006-01-02 15:04:05"), r.RemoteAddr, r.URL.Path, pretty)
+			continue
+		}
+		printTestPayload(r, envelope.Data, len(frame))
+	}
+}
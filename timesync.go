@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// sntpTimeout 需要明显小于 -collector-timeout，服务器不可达时不至于让整个 time_sync 段被放弃
+const sntpTimeout = 2 * time.Second
+
+// ntpEpochOffset 是 1900-01-01（NTP 纪元）到 1970-01-01 的秒数
+const ntpEpochOffset = 2208988800
+
+// timeSyncDaemons 按 pid 文件或状态文件识别正在运行的时间同步服务
+var timeSyncDaemons = []struct {
+	name  string
+	paths []string
+}{
+	{"chrony", []string{"/run/chrony/chronyd.pid", "/var/run/chrony/chronyd.pid", "/run/chronyd.pid"}},
+	{"ntpd", []string{"/run/ntpd.pid", "/var/run/ntpd.pid"}},
+	{"systemd-timesyncd", []string{"/run/systemd/timesync/synchronized", "/run/systemd/netif/state"}},
+}
+
+func detectTimeSyncDaemon() string {
+	for _, d := range timeSyncDaemons {
+		for _, p := range d.paths {
+			if _, err := os.Stat(hostFile(p)); err == nil {
+				return d.name
+			}
+		}
+	}
+	return ""
+}
+
+func ntpTime(b []byte) time.Time {
+	secs := binary.BigEndian.Uint32(b[0:4])
+	frac := binary.BigEndian.Uint32(b[4:8])
+	nanos := (int64(frac) * 1e9) >> 32
+	return time.Unix(int64(secs)-ntpEpochOffset, nanos)
+}
+
+// sntpResult 是一次 SNTP 查询的结果，offset 为服务器时间减本机时间
+type sntpResult struct {
+	offset  time.Duration
+	rtt     time.Duration
+	stratum int
+}
+
+// querySNTP 向 server 发送一个 SNTPv4 客户端请求（RFC 4330），按四个时间戳计算偏差和往返时延
+func querySNTP(server string, timeout time.Duration) (sntpResult, error) {
+	var r sntpResult
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "123")
+	}
+	conn, err := net.DialTimeout("udp", server, timeout)
+	if err != nil {
+		return r, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	req := make([]byte, 48)
+	req[0] = 0x23 // LI=0, VN=4, Mode=3（client）
+	t1 := time.Now()
+	// transmit timestamp 写入本机发送时间，服务器原样放回 originate 字段，用于匹配回复
+	secs := uint64(t1.Unix()) + ntpEpochOffset
+	frac := uint64(t1.Nanosecond()) << 32 / 1e9
+	binary.BigEndian.PutUint32(req[40:], uint32(secs))
+	binary.BigEndian.PutUint32(req[44:], uint32(frac))
+	if _, err := conn.Write(req); err != nil {
+		return r, err
+	}
+	resp := make([]byte, 48)
+	n, err := conn.Read(resp)
+	t4 := time.Now()
+	if err != nil {
+		return r, err
+	}
+	if n < 48 {
+		return r, fmt.Errorf("short NTP response (%d bytes)", n)
+	}
+	if mode := resp[0] & 0x7; mode != 4 && mode != 5 {
+		return r, fmt.Errorf("unexpected NTP mode %d", mode)
+	}
+	r.stratum = int(resp[1])
+	// stratum 0 是 kiss-of-death，服务器要求客户端停止或降低查询频率
+	if r.stratum == 0 {
+		return r, fmt.Errorf("NTP server sent kiss code %q", string(resp[12:16]))
+	}
+	if string(resp[24:32]) != string(req[40:48]) {
+		return r, fmt.Errorf("NTP response does not match the request")
+	}
+	t2, t3 := ntpTime(resp[32:40]), ntpTime(resp[40:48])
+	r.offset = (t2.Sub(t1) + t3.Sub(t4)) / 2
+	r.rtt = t4.Sub(t1) - t3.Sub(t2)
+	return r, nil
+}
+
+// timeSyncCache 按 -ntp-interval 缓存 SNTP 测量结果，避免每个上报周期都查询服务器
+type timeSyncCache struct {
+	mu       sync.Mutex
+	measured time.Time
+	result   sntpResult
+	err      error
+}
+
+var timeSync = &timeSyncCache{}
+
+func (c *timeSyncCache) get(server string, interval time.Duration) (sntpResult, time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.measured.IsZero() || time.Since(c.measured) >= interval {
+		c.result, c.err = querySNTP(server, sntpTimeout)
+		c.measured = time.Now()
+		if c.err != nil {
+			slog.Debug("sntp query failed", "component", "timesync", "url", server, "err", c.err)
+		}
+	}
+	return c.result, c.measured, c.err
+}
+
+// clockOffsetMillis 返回最近一次 SNTP 测得的偏差，用于心跳；未配置 -ntp-server 或尚未测量成功时返回 false
+func clockOffsetMillis() (float64, bool) {
+	if cfg.NTPServer == "" {
+		return 0, false
+	}
+	timeSync.mu.Lock()
+	defer timeSync.mu.Unlock()
+	if timeSync.measured.IsZero() || timeSync.err != nil {
+		return 0, false
+	}
+	return durationMillis(timeSync.result.offset), true
+}
+
+func durationMillis(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Millisecond)*100) / 100
+}
+
+// getTimeSync 汇总内核的时钟同步状态（Linux adjtimex）、本机的同步服务和对 -ntp-server 的实测偏差
+func getTimeSync() map[string]interface{} {
+	section := make(map[string]interface{})
+	if daemon := detectTimeSyncDaemon(); daemon != "" {
+		section["daemon"] = daemon
+	}
+	if status := kernelClockStatus(); status != nil {
+		for k, v := range status {
+			section[k] = v
+		}
+	}
+	if cfg.NTPServer != "" {
+		r, at, err := timeSync.get(cfg.NTPServer, cfg.NTPInterval)
+		section["ntp_server"] = cfg.NTPServer
+		section["measured_at"] = at.Format("2006-01-02 15:04:05")
+		if err != nil {
+			section["error"] = err.Error()
+		} else {
+			section["offset_ms"] = durationMillis(r.offset)
+			section["rtt_ms"] = durationMillis(r.rtt)
+			section["stratum"] = r.stratum
+		}
+	}
+	if len(section) == 0 {
+		return nil
+	}
+	return section
+}
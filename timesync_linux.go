@@ -0,0 +1,32 @@
+package main
+
+import "golang.org/x/sys/unix"
+
+const (
+	staUnsync  = 0x0040 // 内核时钟未同步
+	staNano    = 0x2000 // offset 单位为纳秒而不是微秒
+	timeError  = 5      // adjtimex 返回的 TIME_ERROR 状态
+	maxErrorUS = 16000000
+)
+
+// kernelClockStatus 通过 adjtimex 读取内核时钟状态，chrony、ntpd 和 systemd-timesyncd 都会更新它，
+// 与 timedatectl 显示的 "System clock synchronized" 一致
+func kernelClockStatus() map[string]interface{} {
+	var tx unix.Timex
+	state, err := unix.Adjtimex(&tx)
+	if err != nil {
+		return nil
+	}
+	synced := state != timeError && tx.Status&staUnsync == 0
+	status := map[string]interface{}{"synced": synced}
+	offset := float64(tx.Offset) / 1000 // 微秒转毫秒
+	if tx.Status&staNano != 0 {
+		offset /= 1000
+	}
+	status["kernel_offset_ms"] = offset
+	// maxerror 达到 16 秒上限表示长时间没有同步
+	if int64(tx.Maxerror) < maxErrorUS {
+		status["max_error_ms"] = float64(tx.Maxerror) / 1000
+	}
+	return status
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package main
+
+// kernelClockStatus 只在 Linux 上可用，其他平台只报告 -ntp-server 的测量结果
+func kernelClockStatus() map[string]interface{} {
+	return nil
+}
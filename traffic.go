@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+// trafficState 持久化到状态文件，重启后继续累计本周期的流量
+type trafficState struct {
+	PeriodStart string            `json:"period_start"` // 本周期开始日期，YYYY-MM-DD
+	Sent        uint64            `json:"sent_bytes"`
+	Recv        uint64            `json:"recv_bytes"`
+	LastSent    map[string]uint64 `json:"last_sent"` // 各网卡上次读到的计数器
+	LastRecv    map[string]uint64 `json:"last_recv"`
+	ActionRun   bool              `json:"action_run"` // 本周期是否已执行过超额命令
+}
+
+// trafficAccount 按网卡累加计数器增量，单块网卡计数器回绕或重启归零时把当前值视为增量
+type trafficAccount struct {
+	mu     sync.Mutex
+	state  *trafficState
+	loaded bool
+}
+
+var traffic = &trafficAccount{}
+
+// trafficPeriodStart 返回 now 所在计费周期的开始日期，resetDay 超过当月天数时取当月最后一天
+func trafficPeriodStart(now time.Time, resetDay int) time.Time {
+	start := func(year int, month time.Month) time.Time {
+		day := resetDay
+		if last := time.Date(year, month+1, 0, 0, 0, 0, 0, now.Location()).Day(); day > last {
+			day = last
+		}
+		return time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+	}
+	if s := start(now.Year(), now.Month()); !now.Before(s) {
+		return s
+	}
+	prev := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, now.Location())
+	return start(prev.Year(), prev.Month())
+}
+
+// parseByteSize 解析 10TB、9.5TiB、500GB 这样的容量，单位与 -convert 相同，纯数字按字节处理
+func parseByteSize(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	number, unit := s, "B"
+	if i >= 0 {
+		number, unit = s[:i], strings.TrimSpace(s[i:])
+	}
+	factor, ok := unitFamilies["bytes"][unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit %q in %q", unit, s)
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return uint64(n * factor), nil
+}
+
+func (a *trafficAccount) load() {
+	if a.loaded {
+		return
+	}
+	a.loaded = true
+	if content := readStateFile(cfg.TrafficStateFile, "traffic.json"); content != "" {
+		var s trafficState
+		if err := json.Unmarshal([]byte(content), &s); err == nil {
+			a.state = &s
+			return
+		}
+		slog.Warn("ignoring corrupt traffic state", "component", "traffic", "err", "invalid JSON")
+	}
+}
+
+// update 读取计数器并累加到本周期，进入新周期时从 0 开始
+func (a *trafficAccount) update(counters []net.IOCountersStat, now time.Time) trafficState {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.load()
+
+	period := trafficPeriodStart(now, cfg.TrafficResetDay).Format("2006-01-02")
+	if a.state == nil {
+		// 首次运行只记录基线，启动前的流量不计入
+		a.state = &trafficState{PeriodStart: period, LastSent: map[string]uint64{}, LastRecv: map[string]uint64{}}
+		for _, c := range counters {
+			a.state.LastSent[c.Name], a.state.LastRecv[c.Name] = c.BytesSent, c.BytesRecv
+		}
+	}
+	s := a.state
+	if s.PeriodStart != period {
+		slog.Info("traffic period reset", "component", "traffic", "previous", s.PeriodStart, "sent_bytes", s.Sent, "recv_bytes", s.Recv)
+		s.PeriodStart, s.Sent, s.Recv, s.ActionRun = period, 0, 0, false
+	}
+	delta := func(last map[string]uint64, name string, current uint64) uint64 {
+		previous, ok := last[name]
+		last[name] = current
+		switch {
+		case !ok:
+			return 0
+		case current < previous:
+			return current
+		}
+		return current - previous
+	}
+	sent, recv := make(map[string]uint64, len(counters)), make(map[string]uint64, len(counters))
+	for _, c := range counters {
+		s.Sent += delta(s.LastSent, c.Name, c.BytesSent)
+		s.Recv += delta(s.LastRecv, c.Name, c.BytesRecv)
+		sent[c.Name], recv[c.Name] = s.LastSent[c.Name], s.LastRecv[c.Name]
+	}
+	// 已消失的网卡不再保留
+	s.LastSent, s.LastRecv = sent, recv
+
+	if body, err := json.Marshal(s); err == nil {
+		if err := writeStateFile(cfg.TrafficStateFile, "traffic.json", string(body), 0644); err != nil {
+			slog.Warn("persist traffic state failed", "component", "traffic", "err", err)
+		}
+	}
+	return *s
+}
+
+// quotaUsed 按 -traffic-quota-direction 取用于对比配额的流量
+func quotaUsed(s trafficState) uint64 {
+	switch cfg.TrafficQuotaDirection {
+	case "recv":
+		return s.Recv
+	case "both":
+		return s.Sent + s.Recv
+	}
+	return s.Sent
+}
+
+// runQuotaAction 每个周期最多执行一次超额命令，如关闭网卡或停止服务，最长执行一分钟
+func (a *trafficAccount) runQuotaAction(command string) {
+	a.mu.Lock()
+	if a.state == nil || a.state.ActionRun {
+		a.mu.Unlock()
+		return
+	}
+	a.state.ActionRun = true
+	body, _ := json.Marshal(a.state)
+	a.mu.Unlock()
+	if err := writeStateFile(cfg.TrafficStateFile, "traffic.json", string(body), 0644); err != nil {
+		slog.Warn("persist traffic state failed", "component", "traffic", "err", err)
+	}
+
+	slog.Warn("traffic quota reached, running quota command", "component", "traffic", "command", command)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		slog.Error("traffic quota command failed", "component", "traffic", "err", err, "output", strings.TrimSpace(string(out)))
+	}
+}
+
+// getTraffic 返回本计费周期的累计流量，配置了 -traffic-quota 时附带配额使用率，可配合 -alert 使用
+func getTraffic() map[string]interface{} {
+	all, err := net.IOCounters(true)
+	if err != nil {
+		return nil
+	}
+	var counters []net.IOCountersStat
+	for _, c := range all {
+		if interfaceIncluded(c.Name, cfg.NetInclude, cfg.NetExclude) {
+			counters = append(counters, c)
+		}
+	}
+	s := traffic.update(counters, time.Now())
+	section := map[string]interface{}{
+		"period_start": s.PeriodStart,
+		"reset_day":    cfg.TrafficResetDay,
+		"sent":         formatBytes(s.Sent),
+		"recv":         formatBytes(s.Recv),
+		"sent_bytes":   s.Sent,
+		"recv_bytes":   s.Recv,
+	}
+	if cfg.TrafficQuota > 0 {
+		used := quotaUsed(s)
+		section["quota_bytes"] = cfg.TrafficQuota
+		section["quota_direction"] = cfg.TrafficQuotaDirection
+		section["quota_percent"] = percentOf(used, cfg.TrafficQuota)
+		section["quota_exceeded"] = used >= cfg.TrafficQuota
+		if used >= cfg.TrafficQuota && cfg.TrafficQuotaCommand != "" {
+			traffic.runQuotaAction(cfg.TrafficQuotaCommand)
+		}
+	}
+	return section
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+// WireGuard 每 2 分钟左右重新握手，超过 3 分钟没有握手基本可以认为隧道已断
+const wireguardStaleAfter = 3 * time.Minute
+
+var tunnelPrefixes = []string{"wg", "tun", "tap"}
+
+func isTunnelInterface(name string) bool {
+	for _, prefix := range tunnelPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// wireGuardInterface 是 wg show all dump 中一个接口及其对端
+type wireGuardInterface struct {
+	publicKey  string
+	listenPort string
+	peers      []map[string]interface{}
+	latest     time.Time // 所有对端中最近一次握手
+}
+
+// getWireGuardDump 解析 wg show all dump，需要 root 或 CAP_NET_ADMIN。接口行有 5 列：
+// 接口、私钥、公钥、监听端口、fwmark；对端行有 9 列：接口、公钥、预共享密钥、endpoint、
+// allowed-ips、最近握手、接收字节、发送字节、keepalive。私钥和预共享密钥不会输出
+func getWireGuardDump() map[string]*wireGuardInterface {
+	out, err := exec.Command("wg", "show", "all", "dump").Output()
+	if err != nil {
+		return nil
+	}
+	now := time.Now()
+	ifaces := make(map[string]*wireGuardInterface)
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Split(line, "\t")
+		switch len(fields) {
+		case 5:
+			ifaces[fields[0]] = &wireGuardInterface{publicKey: fields[2], listenPort: fields[3]}
+		case 9:
+			wg := ifaces[fields[0]]
+			if wg == nil {
+				continue
+			}
+			peer := map[string]interface{}{"public_key": fields[1]}
+			if fields[3] != "(none)" {
+				peer["endpoint"] = fields[3]
+			}
+			if fields[4] != "(none)" {
+				peer["allowed_ips"] = strings.Split(fields[4], ",")
+			}
+			if epoch, err := strconv.ParseInt(fields[5], 10, 64); err == nil && epoch > 0 {
+				t := time.Unix(epoch, 0)
+				peer["last_handshake"] = t.Format("2006-01-02 15:04:05")
+				peer["handshake_age_seconds"] = int64(now.Sub(t).Seconds())
+				peer["stale"] = now.Sub(t) > wireguardStaleAfter
+				if t.After(wg.latest) {
+					wg.latest = t
+				}
+			} else {
+				// 从未握手的对端同样视为断开
+				peer["stale"] = true
+			}
+			if rx, err := strconv.ParseUint(fields[6], 10, 64); err == nil {
+				peer["rx_bytes"] = rx
+			}
+			if tx, err := strconv.ParseUint(fields[7], 10, 64); err == nil {
+				peer["tx_bytes"] = tx
+			}
+			wg.peers = append(wg.peers, peer)
+		}
+	}
+	return ifaces
+}
+
+func getTunnels() map[string]interface{} {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	counters := make(map[string]net.IOCountersStat)
+	if stats, err := net.IOCounters(true); err == nil {
+		for _, s := range stats {
+			counters[s.Name] = s
+		}
+	}
+
+	// wg-quick 的接口可以任意命名，出现在 wg show 中的接口都算作隧道
+	var wireguard map[string]*wireGuardInterface
+	if _, err := exec.LookPath("wg"); err == nil {
+		wireguard = getWireGuardDump()
+	}
+	tunnels := make(map[string]interface{})
+	for _, iface := range ifaces {
+		if _, ok := wireguard[iface.Name]; !ok && !isTunnelInterface(iface.Name) {
+			continue
+		}
+		state := "down"
+		for _, f := range iface.Flags {
+			if f == "up" {
+				state = "up"
+				break
+			}
+		}
+		c := counters[iface.Name]
+		tunnel := map[string]interface{}{
+			"state":    state,
+			"rx_bytes": c.BytesRecv,
+			"tx_bytes": c.BytesSent,
+		}
+		if wg, ok := wireguard[iface.Name]; ok {
+			tunnel["type"] = "wireguard"
+			tunnel["public_key"] = wg.publicKey
+			if wg.listenPort != "0" {
+				tunnel["listen_port"] = wg.listenPort
+			}
+			peers := make([]interface{}, 0, len(wg.peers))
+			for _, p := range wg.peers {
+				peers = append(peers, p)
+			}
+			tunnel["peers"] = peers
+			tunnel["peer_count"] = len(peers)
+			if !wg.latest.IsZero() {
+				age := time.Since(wg.latest)
+				tunnel["last_handshake"] = wg.latest.Format("2006-01-02 15:04:05")
+				tunnel["handshake_age_seconds"] = int64(age.Seconds())
+				tunnel["stale"] = age > wireguardStaleAfter
+			}
+		}
+		tunnels[iface.Name] = tunnel
+	}
+	if len(tunnels) == 0 {
+		return nil
+	}
+	return tunnels
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// 各单位相对于基础单位（B、B/s、s）的倍数
+var unitFamilies = map[string]map[string]float64{
+	"bytes": {
+		"B": 1, "KB": 1e3, "MB": 1e6, "GB": 1e9, "TB": 1e12,
+		"KiB": 1 << 10, "MiB": 1 << 20, "GiB": 1 << 30, "TiB": 1 << 40,
+	},
+	"rate": {
+		"B/s": 1, "KB/s": 1e3, "MB/s": 1e6, "GB/s": 1e9,
+		"KiB/s": 1 << 10, "MiB/s": 1 << 20, "GiB/s": 1 << 30,
+		"bps": 1.0 / 8, "Kbps": 1e3 / 8, "Mbps": 1e6 / 8, "Gbps": 1e9 / 8,
+	},
+	"time": {
+		"ms": 1e-3, "s": 1, "min": 60, "h": 3600,
+	},
+}
+
+// 字段的原始单位由字段名后缀约定决定，顺序要求先匹配更长的后缀
+var unitSuffixes = []struct {
+	suffix string
+	family string
+	unit   string
+}{
+	{"_bytes_per_sec", "rate", "B/s"},
+	{"_bytes", "bytes", "B"},
+	{"_seconds", "time", "s"},
+	{"_ms", "time", "ms"},
+}
+
+// unitKeySuffixes 是换算后字段名使用的后缀，换算后的字段不再沿用 _bytes 等原始单位的后缀，
+// 避免下游把 MiB、Mbps 当成字节读取
+var unitKeySuffixes = map[string]string{
+	"B": "_bytes", "KB": "_kb", "MB": "_mb", "GB": "_gb", "TB": "_tb",
+	"KiB": "_kib", "MiB": "_mib", "GiB": "_gib", "TiB": "_tib",
+	"B/s": "_bytes_per_sec", "KB/s": "_kb_per_sec", "MB/s": "_mb_per_sec", "GB/s": "_gb_per_sec",
+	"KiB/s": "_kib_per_sec", "MiB/s": "_mib_per_sec", "GiB/s": "_gib_per_sec",
+	"bps": "_bps", "Kbps": "_kbps", "Mbps": "_mbps", "Gbps": "_gbps",
+	"ms": "_ms", "s": "_seconds", "min": "_minutes", "h": "_hours",
+}
+
+type unitConversion struct {
+	path   []string
+	factor float64
+	key    string // 换算后的字段名，如 rx_bytes 换算为 MiB 后为 rx_mib
+}
+
+var unitConversions []unitConversion
+
+// compileUnitConversions 在启动时校验 field=unit 配置并计算换算系数
+func compileUnitConversions(specs map[string]string) ([]unitConversion, error) {
+	var conversions []unitConversion
+	for field, unit := range specs {
+		path := strings.Split(field, ".")
+		last := path[len(path)-1]
+		family, from, suffix := "", "", ""
+		for _, s := range unitSuffixes {
+			if strings.HasSuffix(last, s.suffix) {
+				family, from, suffix = s.family, s.unit, s.suffix
+				break
+			}
+		}
+		if family == "" {
+			return nil, fmt.Errorf("field %q has no known unit suffix", field)
+		}
+		to, ok := unitFamilies[family][unit]
+		if !ok {
+			return nil, fmt.Errorf("field %q: cannot convert %s to %q", field, from, unit)
+		}
+		conversions = append(conversions, unitConversion{
+			path:   path,
+			factor: unitFamilies[family][from] / to,
+			key:    strings.TrimSuffix(last, suffix) + unitKeySuffixes[unit],
+		})
+	}
+	return conversions, nil
+}
+
+func applyUnitConversions(data map[string]interface{}, conversions []unitConversion) {
+	for _, c := range conversions {
+		convertField(data, c.path, c.factor, c.key)
+	}
+}
+
+// convertField 换算 path 指向的字段并改用 key 作为字段名
+func convertField(m map[string]interface{}, path []string, factor float64, key string) {
+	if len(path) == 1 {
+		if v, ok := toFloat(m[path[0]]); ok {
+			delete(m, path[0])
+			m[key] = math.Round(v*factor*100) / 100
+		}
+		return
+	}
+	for k, child := range m {
+		if path[0] != "*" && path[0] != k {
+			continue
+		}
+		if sub, ok := child.(map[string]interface{}); ok {
+			convertField(sub, path[1:], factor, key)
+		}
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,48 @@
+package main
+
+import "testing"
+
+func TestUnitConversionsRenameFields(t *testing.T) {
+	conversions, err := compileUnitConversions(map[string]string{
+		"memory.used_bytes":                    "MiB",
+		"network.download_speed_bytes_per_sec": "Mbps",
+		"tunnels.*.rx_bytes":                   "GiB",
+		"disk.total_bytes":                     "B",
+		"ping.rtt_ms":                          "s",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := map[string]interface{}{
+		"memory":  map[string]interface{}{"used_bytes": uint64(3 << 20)},
+		"network": map[string]interface{}{"download_speed_bytes_per_sec": uint64(12500000)},
+		"tunnels": map[string]interface{}{"wg0": map[string]interface{}{"rx_bytes": uint64(1 << 30)}},
+		"disk":    map[string]interface{}{"total_bytes": uint64(100)},
+		"ping":    map[string]interface{}{"rtt_ms": 1500.0},
+	}
+	applyUnitConversions(data, conversions)
+
+	tests := []struct {
+		section map[string]interface{}
+		oldKey  string
+		newKey  string
+		want    float64
+	}{
+		{data["memory"].(map[string]interface{}), "used_bytes", "used_mib", 3},
+		{data["network"].(map[string]interface{}), "download_speed_bytes_per_sec", "download_speed_mbps", 100},
+		{data["tunnels"].(map[string]interface{})["wg0"].(map[string]interface{}), "rx_bytes", "rx_gib", 1},
+		{data["ping"].(map[string]interface{}), "rtt_ms", "rtt_seconds", 1.5},
+	}
+	for _, tt := range tests {
+		if _, ok := tt.section[tt.oldKey]; ok {
+			t.Errorf("%s still present after conversion", tt.oldKey)
+		}
+		if got := tt.section[tt.newKey]; got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.newKey, got, tt.want)
+		}
+	}
+	// 换算到原始单位时字段名不变
+	if got := data["disk"].(map[string]interface{})["total_bytes"]; got != 100.0 {
+		t.Errorf("total_bytes = %v, want 100", got)
+	}
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// maxUpdateSize 限制下载的二进制大小，防止异常响应写满磁盘
+const maxUpdateSize = 200 << 20
+
+// updateRelease 是检查到的最新版本，url 为本平台二进制的下载地址
+type updateRelease struct {
+	Version   string
+	URL       string
+	SHA256    string // 十六进制
+	Signature []byte // 对二进制内容的 ed25519 签名，未提供时为空
+}
+
+// updateManifest 是控制端提供的版本清单，assets 的键为 GOOS/GOARCH（32 位 ARM 可以用 linux/armv7）：
+//
+//	{"version": "1.4.0", "assets": {"linux/arm64": {"url": "...", "sha256": "...", "signature": "base64"}}}
+type updateManifest struct {
+	Version string `json:"version"`
+	Assets  map[string]struct {
+		URL       string `json:"url"`
+		SHA256    string `json:"sha256"`
+		Signature string `json:"signature"`
+	} `json:"assets"`
+}
+
+// githubRelease 是 GitHub releases API 响应中用到的字段
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// updateAssetName 是 GitHub release 中本平台二进制的文件名，如 oci-agent-linux-arm64；
+// 32 位 ARM 按 GOARM 分别发布为 oci-agent-linux-armv6、oci-agent-linux-armv7
+func updateAssetName() string {
+	name := fmt.Sprintf("oci-agent-%s-%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOARCH == "arm" && goarm != "" {
+		name += "v" + goarm
+	}
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// compareVersions 按数字逐段比较 v1.2.3 形式的版本号，忽略 -rc1 等后缀
+func compareVersions(a, b string) int {
+	parse := func(v string) []int {
+		v = strings.TrimPrefix(v, "v")
+		if i := strings.IndexAny(v, "-+"); i >= 0 {
+			v = v[:i]
+		}
+		var parts []int
+		for _, p := range strings.Split(v, ".") {
+			n, _ := strconv.Atoi(p)
+			parts = append(parts, n)
+		}
+		return parts
+	}
+	pa, pb := parse(a), parse(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+type updater struct {
+	endpoint  string
+	github    bool
+	publicKey ed25519.PublicKey
+	client    *http.Client
+
+	restart atomic.Bool // 新版本已替换，主循环退出后重新执行自身
+}
+
+var selfUpdate *updater
+
+// newUpdater 的 endpoint 为 GitHub releases API 地址（https://api.github.com/repos/OWNER/REPO/releases/latest）
+// 或控制端的版本清单地址；请求控制端时沿用 -tls-* 和鉴权头
+func newUpdater(endpoint, publicKey string) (*updater, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("%q is not an http(s) URL", endpoint)
+	}
+	up := &updater{endpoint: endpoint, github: u.Host == "api.github.com"}
+	if publicKey != "" {
+		key, err := base64.StdEncoding.DecodeString(publicKey)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("update-public-key must be a base64 ed25519 public key")
+		}
+		up.publicKey = key
+	}
+	if up.github {
+		up.client = newHTTPClient(5*time.Minute, nil)
+	} else {
+		up.client = newHTTPClient(5*time.Minute, tlsConfig)
+	}
+	return up, nil
+}
+
+// sameHost 判断地址是否与 -update-url 位于同一主机（含端口）
+func (u *updater) sameHost(target *url.URL) bool {
+	endpoint, err := url.Parse(u.endpoint)
+	return err == nil && strings.EqualFold(endpoint.Scheme, target.Scheme) && strings.EqualFold(endpoint.Host, target.Host)
+}
+
+func (u *updater) get(ctx context.Context, rawURL string, max int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if u.github {
+		req.Header.Set("Accept", "application/vnd.github+json")
+	} else if u.sameHost(req.URL) {
+		// 清单中的下载地址可能指向 CDN 等第三方，鉴权头只发给清单所在的主机
+		setAuthHeaders(req.Header)
+	}
+	req.Header.Set("User-Agent", "oci-agent/"+version)
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{code: resp.StatusCode}
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > max {
+		return nil, fmt.Errorf("%s is larger than %d bytes", rawURL, max)
+	}
+	return body, nil
+}
+
+// latest 查询最新版本；GitHub release 的校验和取自 checksums.txt 或 <asset>.sha256，签名取自 <asset>.sig
+func (u *updater) latest(ctx context.Context) (*updateRelease, error) {
+	body, err := u.get(ctx, u.endpoint, 1<<20)
+	if err != nil {
+		return nil, err
+	}
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+	if !u.github {
+		var m updateManifest
+		if err := json.Unmarshal(body, &m); err != nil {
+			return nil, fmt.Errorf("parse manifest: %w", err)
+		}
+		asset, ok := m.Assets[platform]
+		// 32 位 ARM 优先使用 linux/armv7 这样带 GOARM 的键
+		if runtime.GOARCH == "arm" && goarm != "" {
+			if a, found := m.Assets[platform+"v"+goarm]; found {
+				asset, ok = a, true
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("version %s has no build for %s", m.Version, platform)
+		}
+		rel := &updateRelease{Version: m.Version, URL: asset.URL, SHA256: asset.SHA256}
+		if asset.Signature != "" {
+			if rel.Signature, err = base64.StdEncoding.DecodeString(asset.Signature); err != nil {
+				return nil, fmt.Errorf("invalid signature for %s: %w", platform, err)
+			}
+		}
+		return rel, nil
+	}
+
+	var gh githubRelease
+	if err := json.Unmarshal(body, &gh); err != nil {
+		return nil, fmt.Errorf("parse release: %w", err)
+	}
+	name := updateAssetName()
+	assets := map[string]string{}
+	for _, a := range gh.Assets {
+		assets[a.Name] = a.URL
+	}
+	rel := &updateRelease{Version: gh.TagName, URL: assets[name]}
+	if rel.URL == "" {
+		return nil, fmt.Errorf("release %s has no asset %s", gh.TagName, name)
+	}
+	if sumURL := assets[name+".sha256"]; sumURL != "" {
+		sum, err := u.get(ctx, sumURL, 4<<10)
+		if err != nil {
+			return nil, fmt.Errorf("download checksum: %w", err)
+		}
+		rel.SHA256 = strings.Fields(string(sum) + " ")[0]
+	} else if sumURL := assets["checksums.txt"]; sumURL != "" {
+		sums, err := u.get(ctx, sumURL, 1<<20)
+		if err != nil {
+			return nil, fmt.Errorf("download checksums: %w", err)
+		}
+		// sha256sum 的输出格式：<hex>  <文件名>，文件名前可能带 * 表示二进制模式
+		scanner := bufio.NewScanner(strings.NewReader(string(sums)))
+		for scanner.Scan() {
+			if fields := strings.Fields(scanner.Text()); len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+				rel.SHA256 = fields[0]
+			}
+		}
+	}
+	if sigURL := assets[name+".sig"]; sigURL != "" {
+		sig, err := u.get(ctx, sigURL, 4<<10)
+		if err != nil {
+			return nil, fmt.Errorf("download signature: %w", err)
+		}
+		if rel.Signature, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig))); err != nil {
+			return nil, fmt.Errorf("invalid signature: %w", err)
+		}
+	}
+	return rel, nil
+}
+
+// verify 校验和是必需的；配置了 -update-public-key 时签名也是必需的
+func (u *updater) verify(rel *updateRelease, binary []byte) error {
+	if rel.SHA256 == "" {
+		return fmt.Errorf("release %s publishes no sha256 checksum for %s", rel.Version, updateAssetName())
+	}
+	sum := sha256.Sum256(binary)
+	if !strings.EqualFold(hex.EncodeToString(sum[:]), rel.SHA256) {
+		return fmt.Errorf("checksum mismatch for %s", rel.URL)
+	}
+	if u.publicKey != nil {
+		if len(rel.Signature) == 0 {
+			return fmt.Errorf("release %s is not signed", rel.Version)
+		}
+		if !ed25519.Verify(u.publicKey, binary, rel.Signature) {
+			return fmt.Errorf("signature verification failed for %s", rel.URL)
+		}
+	}
+	return nil
+}
+
+// executablePath 返回当前二进制的真实路径，符号链接指向的文件才是需要替换的
+func executablePath() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(exe)
+}
+
+// installUpdate 把新二进制写到同目录的临时文件再重命名覆盖，同一文件系统内的重命名是原子的，
+// 中途失败不会留下半个二进制
+func installUpdate(binary []byte) error {
+	exe, err := executablePath()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".oci-agent-update-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+	return replaceExecutable(tmp.Name(), exe)
+}
+
+// check 检查并安装新版本，返回是否已替换；开发版本（version 为 dev）不参与自动更新
+func (u *updater) check(ctx context.Context) (bool, error) {
+	if version == "dev" {
+		return false, fmt.Errorf("development builds are not updated, build with -ldflags \"-X main.version=...\"")
+	}
+	rel, err := u.latest(ctx)
+	if err != nil {
+		return false, err
+	}
+	if compareVersions(rel.Version, version) <= 0 {
+		slog.Debug("agent is up to date", "component", "updater", "version", version, "latest", rel.Version)
+		return false, nil
+	}
+	slog.Info("downloading agent update", "component", "updater", "version", version, "latest", rel.Version, "url", rel.URL)
+	binary, err := u.get(ctx, rel.URL, maxUpdateSize)
+	if err != nil {
+		return false, fmt.Errorf("download %s: %w", rel.URL, err)
+	}
+	if err := u.verify(rel, binary); err != nil {
+		return false, err
+	}
+	if err := installUpdate(binary); err != nil {
+		return false, fmt.Errorf("install update: %w", err)
+	}
+	slog.Info("agent updated", "component", "updater", "from", version, "to", rel.Version)
+	return true, nil
+}
+
+// run 定期检查更新，安装成功后调用 shutdown 让主循环正常退出，再由 main 重新执行新的二进制
+func (u *updater) run(ctx context.Context, interval time.Duration, shutdown context.CancelFunc) {
+	if version == "dev" {
+		slog.Warn("self-update disabled for development builds", "component", "updater")
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		updated, err := u.check(ctx)
+		if err != nil {
+			slog.Warn("update check failed", "component", "updater", "url", u.endpoint, "err", err)
+			continue
+		}
+		if updated {
+			u.restart.Store(true)
+			shutdown()
+			return
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUpdaterAuthHeadersStayOnManifestHost(t *testing.T) {
+	restoreConfig(t)
+	cfg.AuthToken = "secret-token"
+
+	seen := map[string]string{}
+	handler := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen[name] = r.Header.Get("Authorization")
+			w.Write([]byte("ok"))
+		})
+	}
+	manifest := httptest.NewServer(handler("manifest"))
+	defer manifest.Close()
+	cdn := httptest.NewServer(handler("cdn"))
+	defer cdn.Close()
+
+	u, err := newUpdater(manifest.URL+"/version.json", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := u.get(ctx, manifest.URL+"/version.json", 1<<10); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := u.get(ctx, cdn.URL+"/oci-agent-linux-amd64", 1<<10); err != nil {
+		t.Fatal(err)
+	}
+	if seen["manifest"] != "Bearer secret-token" {
+		t.Errorf("manifest Authorization = %q, want the bearer token", seen["manifest"])
+	}
+	if seen["cdn"] != "" {
+		t.Errorf("asset host received Authorization %q", seen["cdn"])
+	}
+}
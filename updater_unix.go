@@ -0,0 +1,21 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+func replaceExecutable(newPath, exe string) error {
+	return os.Rename(newPath, exe)
+}
+
+// restartSelf 用新的二进制替换当前进程，PID 不变，systemd 不会把它当作退出
+func restartSelf() error {
+	exe, err := executablePath()
+	if err != nil {
+		return err
+	}
+	return syscall.Exec(exe, os.Args, os.Environ())
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+)
+
+// replaceExecutable Windows 上不能覆盖正在运行的 exe，但可以重命名它，旧文件留作 .old 并在下次更新时删除
+func replaceExecutable(newPath, exe string) error {
+	old := exe + ".old"
+	os.Remove(old)
+	if err := os.Rename(exe, old); err != nil {
+		return err
+	}
+	if err := os.Rename(newPath, exe); err != nil {
+		os.Rename(old, exe)
+		return err
+	}
+	return nil
+}
+
+// restartSelf 以相同参数启动新进程，当前进程随后退出
+func restartSelf() error {
+	exe, err := executablePath()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	return cmd.Start()
+}
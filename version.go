@@ -0,0 +1,15 @@
+package main
+
+import "fmt"
+
+// 以下变量在构建时通过 -ldflags "-X main.version=..." 注入
+var (
+	version   = "dev"
+	commit    = "unknown"
+	buildDate = "unknown"
+	goarm     = "" // GOARCH=arm 时的 GOARM（6 或 7），决定自动更新下载哪个 ARM 版本
+)
+
+func versionString() string {
+	return fmt.Sprintf("oci-agent %s (commit %s, built %s)", version, commit, buildDate)
+}